@@ -1,6 +1,7 @@
 package accumulator
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 	"time"
@@ -10,8 +11,6 @@ import (
 )
 
 func init() {
-	_ = Min1024.Lsh(big1, 1024-1)
-	_ = Min2048.Lsh(big1, RSABitLength-1)
 	P.SetString(PString, 10)
 	A.SetString(AString, 10)
 	B.SetString(BString, 10)
@@ -20,9 +19,10 @@ func init() {
 // TrustedSetup returns a pointer to AccumulatorSetup with 2048 bits key length
 func TrustedSetup() *Setup {
 	ret := &Setup{
-		N: &big.Int{},
-		G: &big.Int{},
-		H: &big.Int{},
+		N:      &big.Int{},
+		G:      &big.Int{},
+		H:      &big.Int{},
+		Params: Params2048,
 	}
 	ret.N.SetString(N2048String, 10)
 	ret.G.SetString(G2048String, 10)
@@ -30,22 +30,24 @@ func TrustedSetup() *Setup {
 	return ret
 }
 
-// GenRepresentatives generates different representatives that can be inputted into RSA accumulator
+// GenRepresentatives generates different representatives that can be inputted into RSA accumulator,
+// with the parameter set Params2048. It panics if encodeType is not registered or an element cannot be encoded,
+// use ParameterSet.GenRepresentatives or Setup.GenRepresentatives to get the error instead.
 func GenRepresentatives(set []string, encodeType EncodeType) []*big.Int {
-	switch encodeType {
-	case HashToPrimeFromSha256:
-		return genRepWithHashToPrimeFromSHA256(set)
-	case DIHashFromPoseidon:
-		return genRepWithDIHashFromPoseidon(set)
-	default:
-		return genRepWithHashToPrimeFromSHA256(set)
+	rep, err := Params2048.GenRepresentatives(set, encodeType)
+	if err != nil {
+		panic(err)
 	}
+	return rep
 }
 
-// AccAndProve generates the accumulator with all the memberships precomputed
-func AccAndProve(set []string, encodeType EncodeType, setup *Setup) (*big.Int, []*big.Int) {
+// AccAndProveElements generates the accumulator with all the memberships precomputed.
+// A duplicate in set is accumulated twice, use AccAndProveSet to reject duplicates or Multiset for multiplicities.
+// It panics if encodeType is not registered or not the encoder of the setup, or an element cannot be encoded,
+// use AccAndProveElementsContext to get the error instead.
+func AccAndProveElements(set []Element, encodeType EncodeType, setup *Setup) (*big.Int, []*big.Int) {
 	startingTime := time.Now().UTC()
-	rep := GenRepresentatives(set, encodeType)
+	rep := setup.mustEncodeElements(set, encodeType)
 	endingTime := time.Now().UTC()
 	var duration = endingTime.Sub(startingTime)
 	fmt.Printf("Running GenRepresentatives Takes [%.3f] Seconds \n",
@@ -58,9 +60,43 @@ func AccAndProve(set []string, encodeType EncodeType, setup *Setup) (*big.Int, [
 	return acc, proofs
 }
 
-// AccAndProveIter iteratively generates the accumulator with all the memberships precomputed
+// AccAndProve is AccAndProveElements for the bytes of the strings
+func AccAndProve(set []string, encodeType EncodeType, setup *Setup) (*big.Int, []*big.Int) {
+	return AccAndProveElements(StringElements(set), encodeType, setup)
+}
+
+// AccAndProveElementsContext is AccAndProveElements with cancellation and progress reporting, without printing
+// the timings. It returns the error of the representatives, e.g. for an encode type which is not registered,
+// or ctx.Err() if the context is done before all the proofs are computed.
+// The progress, if not nil, receives the number of elements whose proofs are computed.
+func AccAndProveElementsContext(ctx context.Context, set []Element, encodeType EncodeType, setup *Setup,
+	progress Progress) (*big.Int, []*big.Int, error) {
+	rep, err := setup.EncodeElementsParallel(ctx, set, encodeType, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rep) == 0 {
+		return new(big.Int).Set(setup.G), nil, nil
+	}
+	proofs, err := ProveMembershipContext(ctx, setup.G, setup.N, rep, progress)
+	if err != nil {
+		return nil, nil, err
+	}
+	// we generate the accumulator by anyone of the membership proof raised to its power to save some calculation
+	return AccumulateNew(proofs[0], rep[0], setup.N), proofs, nil
+}
+
+// AccAndProveContext is AccAndProveElementsContext for the bytes of the strings
+func AccAndProveContext(ctx context.Context, set []string, encodeType EncodeType, setup *Setup,
+	progress Progress) (*big.Int, []*big.Int, error) {
+	return AccAndProveElementsContext(ctx, StringElements(set), encodeType, setup, progress)
+}
+
+// AccAndProveIter iteratively generates the accumulator with all the memberships precomputed.
+// It panics if encodeType is not registered or not the encoder of the setup, or an element cannot be encoded,
+// use AccAndProveContext for the same proofs with the error instead.
 func AccAndProveIter(set []string, encodeType EncodeType, setup *Setup) (*big.Int, []*big.Int) {
-	rep := GenRepresentatives(set, encodeType)
+	rep := setup.mustGenRepresentatives(set, encodeType)
 
 	proofs := ProveMembershipIter(*setup.G, setup.N, rep)
 	// we generate the accumulator by anyone of the membership proof raised to its power to save some calculation
@@ -71,57 +107,19 @@ func AccAndProveIter(set []string, encodeType EncodeType, setup *Setup) (*big.In
 
 // ProveMembershipSingleThreadWithRandomizer uses divide-and-conquer method to pre-compute the all membership proofs in time O(nlog(n))
 func ProveMembershipSingleThreadWithRandomizer(base, randomizer, N *big.Int, set []*big.Int, table *multiexp.PreTable) []*big.Int {
-	// the left part of proof need to accumulate the right part of the set, vice versa.
-	leftProd := SetProductRecursiveFast(set[len(set)/2:])
-	rightProd := SetProductRecursiveFast(set[0 : len(set)/2])
-	leftProd = bigfft.Mul(leftProd, randomizer)
-	rightProd = bigfft.Mul(rightProd, randomizer)
-
-	leftleftProd := SetProductRecursiveFast(set[len(set)/4 : len(set)/2])
-	leftrightProd := SetProductRecursiveFast(set[0 : len(set)/4])
-	rightleftProd := SetProductRecursiveFast(set[len(set)*3/4:])
-	rightrightProd := SetProductRecursiveFast(set[len(set)/2 : len(set)*3/4])
-
-	var inputExp [4]*big.Int
-	inputExp[0] = bigfft.Mul(leftProd, leftleftProd)
-	inputExp[1] = bigfft.Mul(leftProd, leftrightProd)
-	inputExp[2] = bigfft.Mul(rightProd, rightleftProd)
-	inputExp[3] = bigfft.Mul(rightProd, rightrightProd)
-	bases := multiexp.FourfoldExpPrecomputed(base, N, inputExp, table)
-
-	proofs := ProveMembership(bases[0], N, set[0:len(set)/4])
-	proofs = append(proofs, ProveMembership(bases[1], N, set[len(set)/4:len(set)/2])...)
-	proofs = append(proofs, ProveMembership(bases[2], N, set[len(set)/2:len(set)*3/4])...)
-	proofs = append(proofs, ProveMembership(bases[3], N, set[len(set)*3/4:])...)
-	return proofs
+	return NewProductTree(set).ProveMembershipWithRandomizer(base, randomizer, N, 0, table)
 }
 
-// ProveMembership uses divide-and-conquer method to pre-compute the all membership proofs in time O(nlog(n))
+// ProveMembership uses divide-and-conquer method to pre-compute the all membership proofs in time O(nlog(n)).
+// The product tree of set is built once, use ProductTree.ProveMembership to keep it between epochs.
 func ProveMembership(base, N *big.Int, set []*big.Int) []*big.Int {
+	return NewProductTree(set).ProveMembership(base, N)
+}
 
-	if len(set) <= 4 {
-		return handleSmallSet(base, N, set)
-	}
-
-	leftProd := SetProductRecursiveFast(set[len(set)/2:])
-	rightProd := SetProductRecursiveFast(set[0 : len(set)/2])
-	leftleftProd := SetProductRecursiveFast(set[len(set)/4 : len(set)/2])
-	leftrightProd := SetProductRecursiveFast(set[0 : len(set)/4])
-	rightleftProd := SetProductRecursiveFast(set[len(set)*3/4:])
-	rightrightProd := SetProductRecursiveFast(set[len(set)/2 : len(set)*3/4])
-
-	var inputExp [4]*big.Int
-	inputExp[0] = bigfft.Mul(leftProd, leftleftProd)
-	inputExp[1] = bigfft.Mul(leftProd, leftrightProd)
-	inputExp[2] = bigfft.Mul(rightProd, rightleftProd)
-	inputExp[3] = bigfft.Mul(rightProd, rightrightProd)
-
-	bases := multiexp.FourfoldExp(base, N, inputExp)
-	proofs := ProveMembership(bases[0], N, set[0:len(set)/4])
-	proofs = append(proofs, ProveMembership(bases[1], N, set[len(set)/4:len(set)/2])...)
-	proofs = append(proofs, ProveMembership(bases[2], N, set[len(set)/2:len(set)*3/4])...)
-	proofs = append(proofs, ProveMembership(bases[3], N, set[len(set)*3/4:])...)
-	return proofs
+// ProveMembershipContext is ProveMembership with cancellation and progress reporting,
+// see ProofScheduler.ProveMembershipTreeContext
+func ProveMembershipContext(ctx context.Context, base, N *big.Int, set []*big.Int, progress Progress) ([]*big.Int, error) {
+	return NewProofScheduler(1, 0).ProveMembershipContext(ctx, base, N, set, progress)
 }
 
 // ProofNode is the linked-list node for iterating proofs
@@ -0,0 +1,136 @@
+package accumulator
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/jiajunxin/rsa_accumulator/proof"
+)
+
+var (
+	// ErrNotCoprime is returned when witnesses cannot be aggregated because their representatives are not co-prime
+	ErrNotCoprime = errors.New("representatives are not co-prime")
+	// ErrNotSubset is returned when a witness is disaggregated for elements it does not contain
+	ErrNotSubset = errors.New("representatives are not a subset of the aggregated set")
+)
+
+// BatchMembershipProof is a constant-size membership proof for many elements.
+// Witness is the aggregated membership witness W of the product X of all the representatives, i.e. W^X = acc,
+// and PoE is the proof of exponentiation for W^X = acc, so that the verifier does not exponentiate by X.
+type BatchMembershipProof struct {
+	Witness *big.Int
+	PoE     *proof.PoEProof
+}
+
+// ShamirTrick aggregates two membership witnesses w1^x1 = w2^x2 = acc into one witness w
+// s.t. w^{x1*x2} = acc, with a*x1 + b*x2 = 1 and w = w1^b * w2^a. x1 and x2 must be co-prime.
+func ShamirTrick(N, w1, w2, x1, x2 *big.Int) (*big.Int, error) {
+	var a, b, gcd big.Int
+	gcd.GCD(&a, &b, x1, x2)
+	if gcd.Cmp(big1) != 0 {
+		return nil, ErrNotCoprime
+	}
+	ret := new(big.Int).Exp(w1, &b, N)
+	if ret == nil {
+		return nil, errors.New("witness is not invertible modulo N")
+	}
+	var temp big.Int
+	if temp.Exp(w2, &a, N) == nil {
+		return nil, errors.New("witness is not invertible modulo N")
+	}
+	ret.Mul(ret, &temp)
+	ret.Mod(ret, N)
+	return ret, nil
+}
+
+// AggregateMembershipWitnesses aggregates the membership witnesses of set, witnesses[i] is the witness of set[i],
+// into one witness W s.t. W^{product of set} = acc. The representatives must be pairwise co-prime,
+// e.g. generated by HashToPrimeFromSha256. It applies ShamirTrick in a divide-and-conquer way.
+func AggregateMembershipWitnesses(N *big.Int, set, witnesses []*big.Int) (*big.Int, error) {
+	if len(set) != len(witnesses) || len(set) == 0 {
+		return nil, errors.New("invalid input, unbalanced or empty set and witnesses")
+	}
+	w, _, err := aggregateMembershipWitnesses(N, set, witnesses)
+	return w, err
+}
+
+// aggregateMembershipWitnesses returns the aggregated witness together with the product of set
+func aggregateMembershipWitnesses(N *big.Int, set, witnesses []*big.Int) (*big.Int, *big.Int, error) {
+	if len(set) == 1 {
+		return new(big.Int).Set(witnesses[0]), new(big.Int).Set(set[0]), nil
+	}
+	leftW, leftProd, err := aggregateMembershipWitnesses(N, set[:len(set)/2], witnesses[:len(set)/2])
+	if err != nil {
+		return nil, nil, err
+	}
+	rightW, rightProd, err := aggregateMembershipWitnesses(N, set[len(set)/2:], witnesses[len(set)/2:])
+	if err != nil {
+		return nil, nil, err
+	}
+	w, err := ShamirTrick(N, leftW, rightW, leftProd, rightProd)
+	if err != nil {
+		return nil, nil, err
+	}
+	return w, leftProd.Mul(leftProd, rightProd), nil
+}
+
+// ProveBatchMembership aggregates the membership witnesses of set for the accumulator acc and
+// proves the aggregated witness with a non-interactive proof of exponentiation
+func ProveBatchMembership(N, acc *big.Int, set, witnesses []*big.Int) (*BatchMembershipProof, error) {
+	w, err := AggregateMembershipWitnesses(N, set, witnesses)
+	if err != nil {
+		return nil, err
+	}
+	poe, err := proof.PoEProve(w, N, acc, SetProductRecursiveFast(set))
+	if err != nil {
+		return nil, err
+	}
+	return &BatchMembershipProof{
+		Witness: w,
+		PoE:     poe,
+	}, nil
+}
+
+// VerifyBatchElementMembership returns true if batchProof shows that all the elements are in the accumulator acc
+func VerifyBatchElementMembership(setup *Setup, acc *big.Int, elements []Element, encodeType EncodeType,
+	batchProof *BatchMembershipProof) bool {
+	rep, err := setup.EncodeElements(elements, encodeType)
+	if err != nil {
+		return false
+	}
+	return VerifyBatchMembershipWithRep(setup.N, acc, rep, batchProof)
+}
+
+// VerifyBatchMembership is VerifyBatchElementMembership for the bytes of the strings
+func VerifyBatchMembership(setup *Setup, acc *big.Int, elements []string, encodeType EncodeType, batchProof *BatchMembershipProof) bool {
+	return VerifyBatchElementMembership(setup, acc, StringElements(elements), encodeType, batchProof)
+}
+
+// VerifyBatchMembershipWithRep returns true if Witness^{product of set} = acc mod N, which is checked by the PoE
+// with two exponentiations of the challenge size
+func VerifyBatchMembershipWithRep(N, acc *big.Int, set []*big.Int, batchProof *BatchMembershipProof) bool {
+	if batchProof == nil || batchProof.Witness == nil || len(set) == 0 {
+		return false
+	}
+	return proof.PoEVerify(batchProof.Witness, N, acc, SetProductRecursiveFast(set), batchProof.PoE)
+}
+
+// DisaggregateMembershipWitness returns the membership witness of subset from the aggregated witness of set,
+// i.e. witness^{product of set / product of subset}. The subset must be a sub-multiset of set.
+func DisaggregateMembershipWitness(N, witness *big.Int, set, subset []*big.Int) (*big.Int, error) {
+	var quotient, remainder big.Int
+	quotient.DivMod(SetProductRecursiveFast(set), SetProductRecursiveFast(subset), &remainder)
+	if remainder.Sign() != 0 {
+		return nil, ErrNotSubset
+	}
+	return AccumulateNew(witness, &quotient, N), nil
+}
+
+// DisaggregateMembershipWitnesses returns the individual membership witnesses of all the elements in set
+// from the aggregated witness of set, in time O(nlog(n))
+func DisaggregateMembershipWitnesses(N, witness *big.Int, set []*big.Int) []*big.Int {
+	if len(set) == 0 {
+		return nil
+	}
+	return ProveMembership(witness, N, set)
+}
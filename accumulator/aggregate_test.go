@@ -0,0 +1,63 @@
+package accumulator
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestShamirTrick(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(8)
+	rep := GenRepresentatives(set, HashToPrimeFromSha256)
+	acc, proofs := AccAndProve(set, HashToPrimeFromSha256, setup)
+	w, err := ShamirTrick(setup.N, proofs[1], proofs[6], rep[1], rep[6])
+	if err != nil {
+		t.Fatalf("ShamirTrick returns error: %v", err)
+	}
+	if !VerifyMembershipWithRep(setup.N, acc, new(big.Int).Mul(rep[1], rep[6]), w) {
+		t.Errorf("aggregated witness does not pass verification")
+	}
+	if _, err = ShamirTrick(setup.N, proofs[1], proofs[1], rep[1], rep[1]); err != ErrNotCoprime {
+		t.Errorf("ShamirTrick should return ErrNotCoprime for the same representative, got %v", err)
+	}
+}
+
+func TestBatchMembership(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(40)
+	rep := GenRepresentatives(set, HashToPrimeFromSha256)
+	acc, proofs := AccAndProve(set, HashToPrimeFromSha256, setup)
+
+	subset := set[3:20]
+	batchProof, err := ProveBatchMembership(setup.N, acc, rep[3:20], proofs[3:20])
+	if err != nil {
+		t.Fatalf("ProveBatchMembership returns error: %v", err)
+	}
+	if !VerifyBatchMembership(setup, acc, subset, HashToPrimeFromSha256, batchProof) {
+		t.Errorf("valid batch membership proof does not pass verification")
+	}
+	if VerifyBatchMembership(setup, acc, set[3:21], HashToPrimeFromSha256, batchProof) {
+		t.Errorf("batch membership proof should not pass verification for another subset")
+	}
+	if !VerifyMembershipWithRep(setup.N, acc, SetProductRecursiveFast(rep[3:20]), batchProof.Witness) {
+		t.Errorf("aggregated witness is not a membership witness of the product")
+	}
+
+	// disaggregate the witness of a smaller subset and of all the single elements
+	w, err := DisaggregateMembershipWitness(setup.N, batchProof.Witness, rep[3:20], rep[5:9])
+	if err != nil {
+		t.Fatalf("DisaggregateMembershipWitness returns error: %v", err)
+	}
+	if !VerifyMembershipWithRep(setup.N, acc, SetProductRecursiveFast(rep[5:9]), w) {
+		t.Errorf("disaggregated witness does not pass verification")
+	}
+	if _, err = DisaggregateMembershipWitness(setup.N, batchProof.Witness, rep[3:20], rep[20:22]); err != ErrNotSubset {
+		t.Errorf("DisaggregateMembershipWitness should return ErrNotSubset, got %v", err)
+	}
+	witnesses := DisaggregateMembershipWitnesses(setup.N, batchProof.Witness, rep[3:20])
+	for i := range witnesses {
+		if witnesses[i].Cmp(proofs[3+i]) != 0 {
+			t.Errorf("disaggregated witness %d is not the original witness", i)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package accumulator
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/jiajunxin/rsa_accumulator/proof"
+)
+
+// BatchNonMembershipProof is a constant-size non-membership proof for many elements,
+// following "Batching Techniques for Accumulators with Applications to IOPs and Stateless Blockchains"
+// by Boneh, Bünz and Fisch.
+// Let u be the product of the accumulated representatives, x be the product of the representatives of the elements
+// and a*u + b*x = gcd(u, x) be the Bezout equation. The proof contains V = acc^a, B = base^b and GCD = gcd(u, x)
+// s.t. V * B^x = base^GCD mod N, together with a PoKE2 proof of the knowledge of a for V = acc^a
+// and a PoE proof for B^x = base^GCD * V^{-1}, so that the size of the proof and the work of the verifier
+// do not depend on the number of the elements, except for hashing them into the representatives.
+type BatchNonMembershipProof struct {
+	V    *big.Int
+	B    *big.Int
+	GCD  *big.Int
+	PoKE *proof.PoKE2Proof
+	PoE  *proof.PoEProof
+}
+
+// ProveBatchElementNonMembership generates the non-membership proof of all the elements for the accumulator of set,
+// which is generated with setup.G as the base, e.g. by AccAndProveElements
+func ProveBatchElementNonMembership(setup *Setup, set, elements []Element, encodeType EncodeType) (*BatchNonMembershipProof, error) {
+	rep, err := setup.EncodeElements(set, encodeType)
+	if err != nil {
+		return nil, err
+	}
+	xs, err := setup.EncodeElements(elements, encodeType)
+	if err != nil {
+		return nil, err
+	}
+	return ProveBatchNonMembershipWithRep(setup.G, setup.N, rep, xs)
+}
+
+// ProveBatchNonMembership is ProveBatchElementNonMembership for the bytes of the strings
+func ProveBatchNonMembership(setup *Setup, set, elements []string, encodeType EncodeType) (*BatchNonMembershipProof, error) {
+	return ProveBatchElementNonMembership(setup, StringElements(set), StringElements(elements), encodeType)
+}
+
+// ProveBatchNonMembershipWithRep generates the non-membership proof of all the representatives in xs for the
+// accumulator base^{product of set} mod N
+func ProveBatchNonMembershipWithRep(base, N *big.Int, set, xs []*big.Int) (*BatchNonMembershipProof, error) {
+	if len(xs) == 0 {
+		return nil, errors.New("invalid input, no element to prove")
+	}
+	u := SetProductRecursiveFast(set)
+	x := SetProductRecursiveFast(xs)
+	var a, b big.Int
+	gcd := new(big.Int).GCD(&a, &b, u, x)
+	for i := range xs {
+		if new(big.Int).Mod(gcd, xs[i]).Sign() == 0 {
+			// xs[i] divides the product
+			return nil, errors.New("the element is accumulated, cannot prove non-membership")
+		}
+	}
+
+	acc := AccumulateNew(base, u, N)
+	v := new(big.Int).Exp(acc, &a, N)
+	if v == nil {
+		return nil, errors.New("accumulator is not invertible modulo N")
+	}
+	poke, err := proof.PoKE2Prove(N, acc, v, &a)
+	if err != nil {
+		return nil, err
+	}
+	bb := new(big.Int).Exp(base, &b, N)
+	if bb == nil {
+		return nil, errors.New("base is not invertible modulo N")
+	}
+	c, ok := batchNonMembershipTarget(base, N, gcd, v)
+	if !ok {
+		return nil, errors.New("accumulator is not invertible modulo N")
+	}
+	poe, err := proof.PoEProve(bb, N, c, x)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchNonMembershipProof{
+		V:    v,
+		B:    bb,
+		GCD:  gcd,
+		PoKE: poke,
+		PoE:  poe,
+	}, nil
+}
+
+// VerifyBatchElementNonMembership returns true if batchProof shows that none of the elements is in the accumulator
+// acc, which is generated with setup.G as the base
+func VerifyBatchElementNonMembership(setup *Setup, acc *big.Int, elements []Element, encodeType EncodeType,
+	batchProof *BatchNonMembershipProof) bool {
+	xs, err := setup.EncodeElements(elements, encodeType)
+	if err != nil {
+		return false
+	}
+	return VerifyBatchNonMembershipWithRep(setup.G, setup.N, acc, xs, batchProof)
+}
+
+// VerifyBatchNonMembership is VerifyBatchElementNonMembership for the bytes of the strings
+func VerifyBatchNonMembership(setup *Setup, acc *big.Int, elements []string, encodeType EncodeType, batchProof *BatchNonMembershipProof) bool {
+	return VerifyBatchElementNonMembership(setup, acc, StringElements(elements), encodeType, batchProof)
+}
+
+// VerifyBatchNonMembershipWithRep returns true if batchProof shows that V = acc^a for a known a,
+// V * B^x = base^GCD mod N with x the product of xs, and no representative in xs divides GCD
+func VerifyBatchNonMembershipWithRep(base, N, acc *big.Int, xs []*big.Int, batchProof *BatchNonMembershipProof) bool {
+	if batchProof == nil || batchProof.V == nil || batchProof.B == nil || batchProof.GCD == nil || len(xs) == 0 {
+		return false
+	}
+	if batchProof.GCD.Sign() <= 0 {
+		return false
+	}
+	var temp big.Int
+	for i := range xs {
+		if xs[i] == nil || xs[i].Cmp(big1) <= 0 {
+			return false
+		}
+		if temp.Mod(batchProof.GCD, xs[i]).Sign() == 0 {
+			return false
+		}
+	}
+	// PoKE* is only sound for a trusted generator, while acc can be chosen by the prover,
+	// e.g. as a power of base with a known exponent, which makes V = acc^a provable for a rational a
+	if !proof.PoKE2Verify(N, acc, batchProof.V, batchProof.PoKE) {
+		return false
+	}
+	c, ok := batchNonMembershipTarget(base, N, batchProof.GCD, batchProof.V)
+	if !ok {
+		return false
+	}
+	return proof.PoEVerify(batchProof.B, N, c, SetProductRecursiveFast(xs), batchProof.PoE)
+}
+
+// batchNonMembershipTarget returns base^gcd * v^{-1} mod N
+func batchNonMembershipTarget(base, N, gcd, v *big.Int) (*big.Int, bool) {
+	inv := new(big.Int).ModInverse(v, N)
+	if inv == nil {
+		return nil, false
+	}
+	ret := new(big.Int).Exp(base, gcd, N)
+	ret.Mul(ret, inv)
+	ret.Mod(ret, N)
+	return ret, true
+}
+
+// ProveBatchElementNonMembership generates the non-membership proof of all the elements, none of which
+// may be accumulated
+func (acc *Accumulator) ProveBatchElementNonMembership(elements ...Element) (*BatchNonMembershipProof, error) {
+	if acc.setup == nil {
+		return nil, ErrNotRSASetup
+	}
+	for _, element := range elements {
+		if acc.ContainsElement(element) {
+			return nil, ErrElementExists
+		}
+	}
+	xs, err := acc.encode(elements)
+	if err != nil {
+		return nil, err
+	}
+	return ProveBatchNonMembershipWithRep(acc.setup.G, acc.setup.N, acc.reps, xs)
+}
+
+// ProveBatchNonMembership is ProveBatchElementNonMembership for the bytes of the strings
+func (acc *Accumulator) ProveBatchNonMembership(elements ...string) (*BatchNonMembershipProof, error) {
+	return acc.ProveBatchElementNonMembership(StringElements(elements)...)
+}
@@ -0,0 +1,89 @@
+package accumulator
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/jiajunxin/rsa_accumulator/proof"
+)
+
+func TestBatchNonMembership(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(40)
+	for _, encodeType := range []EncodeType{HashToPrimeFromSha256, DIHashFromPoseidon} {
+		acc, _ := AccAndProve(set[:24], encodeType, setup)
+		proof, err := ProveBatchNonMembership(setup, set[:24], set[24:38], encodeType)
+		if err != nil {
+			t.Fatalf("ProveBatchNonMembership returns error: %v", err)
+		}
+		if !VerifyBatchNonMembership(setup, acc, set[24:38], encodeType, proof) {
+			t.Errorf("valid batch non-membership proof does not pass verification")
+		}
+		if VerifyBatchNonMembership(setup, acc, set[24:39], encodeType, proof) {
+			t.Errorf("batch non-membership proof of other elements should not pass verification")
+		}
+		if _, err = ProveBatchNonMembership(setup, set[:24], set[20:30], encodeType); err == nil {
+			t.Errorf("ProveBatchNonMembership should fail if an element is accumulated")
+		}
+	}
+	if VerifyBatchNonMembershipWithRep(setup.G, setup.N, setup.G, GenRepresentatives(set[:2], HashToPrimeFromSha256), nil) {
+		t.Errorf("nil proof should not pass verification")
+	}
+}
+
+func TestAccumulatorProveBatchNonMembership(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(12)
+	acc, err := NewAccumulatorFromSet(setup, HashToPrimeFromSha256, set[:8])
+	if err != nil {
+		t.Fatalf("NewAccumulatorFromSet returns error: %v", err)
+	}
+	if _, err = acc.ProveBatchNonMembership(set[9], set[1]); err != ErrElementExists {
+		t.Errorf("ProveBatchNonMembership with an accumulated element should return ErrElementExists, got %v", err)
+	}
+	proof, err := acc.ProveBatchNonMembership(set[8:]...)
+	if err != nil {
+		t.Fatalf("ProveBatchNonMembership returns error: %v", err)
+	}
+	if !VerifyBatchNonMembership(setup, acc.Value(), set[8:], HashToPrimeFromSha256, proof) {
+		t.Errorf("valid batch non-membership proof does not pass verification")
+	}
+}
+
+func TestBatchNonMembershipForgedV(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenRepresentatives(GenBenchSet(8), HashToPrimeFromSha256)
+	acc := AccumulateNew(setup.G, SetProductRecursiveFast(set), setup.N)
+	honest, err := ProveBatchNonMembershipWithRep(setup.G, setup.N, set[:4], set[4:])
+	if err != nil {
+		t.Fatalf("ProveBatchNonMembershipWithRep returns error: %v", err)
+	}
+
+	// forge V * B^x = G for the accumulated xs: B = G^k and V = G^{1-k*x}, which is acc^{(1-k*x)/u}
+	xs := set[:3]
+	x := SetProductRecursiveFast(xs)
+	k := big.NewInt(12345)
+	exp := new(big.Int).Mul(k, x)
+	exp.Sub(big1, exp)
+	v := new(big.Int).Exp(setup.G, exp, setup.N)
+	b := new(big.Int).Exp(setup.G, k, setup.N)
+	target, ok := batchNonMembershipTarget(setup.G, setup.N, big1, v)
+	if !ok {
+		t.Fatalf("forged V is not invertible")
+	}
+	poe, err := proof.PoEProve(b, setup.N, target, x)
+	if err != nil {
+		t.Fatalf("PoEProve returns error: %v", err)
+	}
+	// the prover knows the exponent of V for the base G, but not for acc
+	poke, err := proof.PoKE2Prove(setup.N, setup.G, v, exp)
+	if err != nil {
+		t.Fatalf("PoKE2Prove returns error: %v", err)
+	}
+	for _, p := range []*proof.PoKE2Proof{poke, honest.PoKE} {
+		forged := &BatchNonMembershipProof{V: v, B: b, GCD: big.NewInt(1), PoKE: p, PoE: poe}
+		if VerifyBatchNonMembershipWithRep(setup.G, setup.N, acc, xs, forged) {
+			t.Errorf("batch non-membership proof with a forged V should not pass verification")
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package accumulator
 
 import (
+	"context"
 	crand "crypto/rand"
 	"math/big"
 	"testing"
@@ -16,6 +17,43 @@ func BenchmarkHashToPrime(b *testing.B) {
 	}
 }
 
+func BenchmarkHashToPrimeReference(b *testing.B) {
+	testBytes := []byte(testString)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hashToPrimeReference(testBytes)
+	}
+}
+
+func BenchmarkHashToPrimeSet(b *testing.B) {
+	set := GenBenchSet(100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, v := range set {
+			HashToPrime([]byte(v))
+		}
+	}
+}
+
+func BenchmarkHashToPrimeSetReference(b *testing.B) {
+	set := GenBenchSet(100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, v := range set {
+			hashToPrimeReference([]byte(v))
+		}
+	}
+}
+
+func BenchmarkGenRepresentativesParallel(b *testing.B) {
+	set := GenBenchSet(1000)
+	setup := TrustedSetup()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = setup.GenRepresentativesParallel(context.Background(), set, HashToPrimeFromSha256, 0)
+	}
+}
+
 func BenchmarkAccAndProve(b *testing.B) {
 	testSetSize := 1000
 	set := GenBenchSet(testSetSize)
@@ -37,6 +75,18 @@ func BenchmarkProveMembership(b *testing.B) {
 	}
 }
 
+func BenchmarkProductTreeProveMembership(b *testing.B) {
+	setSize := 1000
+	set := GenBenchSet(setSize)
+	rep := GenRepresentatives(set, HashToPrimeFromSha256)
+	setup := *TrustedSetup()
+	tree := NewProductTree(rep)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.ProveMembership(setup.G, setup.N)
+	}
+}
+
 func BenchmarkProveMembershipIter(b *testing.B) {
 	setSize := 1000
 	set := GenBenchSet(setSize)
@@ -59,6 +109,58 @@ func BenchmarkProveMembershipParallel(b *testing.B) {
 	}
 }
 
+func BenchmarkVerifyMembership(b *testing.B) {
+	setSize := 64
+	set := GenBenchSet(setSize)
+	setup := *TrustedSetup()
+	rep := GenRepresentatives(set, HashToPrimeFromSha256)
+	acc, proofs := AccAndProve(set, HashToPrimeFromSha256, &setup)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range rep {
+			VerifyMembershipWithRep(setup.N, acc, rep[j], proofs[j])
+		}
+	}
+}
+
+func BenchmarkBatchVerifyMembership(b *testing.B) {
+	setSize := 64
+	set := GenBenchSet(setSize)
+	setup := *TrustedSetup()
+	rep := GenRepresentatives(set, HashToPrimeFromSha256)
+	acc, proofs := AccAndProve(set, HashToPrimeFromSha256, &setup)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		BatchVerifyMembershipWithRep(setup.N, acc, rep, proofs)
+	}
+}
+
+func BenchmarkVerifyMembershipDI(b *testing.B) {
+	setSize := 64
+	set := GenBenchSet(setSize)
+	setup := *TrustedSetup()
+	rep := GenRepresentatives(set, DIHashFromPoseidon)
+	acc, proofs := AccAndProve(set, DIHashFromPoseidon, &setup)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range rep {
+			VerifyMembershipWithRep(setup.N, acc, rep[j], proofs[j])
+		}
+	}
+}
+
+func BenchmarkBatchVerifyMembershipDI(b *testing.B) {
+	setSize := 64
+	set := GenBenchSet(setSize)
+	setup := *TrustedSetup()
+	rep := GenRepresentatives(set, DIHashFromPoseidon)
+	acc, proofs := AccAndProve(set, DIHashFromPoseidon, &setup)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		BatchVerifyMembershipWithRep(setup.N, acc, rep, proofs)
+	}
+}
+
 func BenchmarkAccumulateNew256bits(b *testing.B) {
 	testObject := *TrustedSetup()
 	testBytes := []byte(testString)
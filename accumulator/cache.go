@@ -0,0 +1,156 @@
+package accumulator
+
+import (
+	"container/list"
+	"context"
+	"math/big"
+	"sync"
+)
+
+// cacheEntryOverhead approximates the bytes taken by a cache entry besides the element and the representative,
+// i.e. the list element, the map entry and the big.Int header
+const cacheEntryOverhead = 160
+
+// CacheStats is the statistics of a RepresentativeCache
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+	Bytes     int64
+}
+
+// cacheKey identifies a representative: the same element has different representatives
+// with different encoders or different parameter sets
+type cacheKey struct {
+	encodeType EncodeType
+	params     paramsKey
+	element    string
+}
+
+// paramsKey identifies a parameter set by its value, so that equal parameter sets, e.g. the one of a loaded
+// setup and the named one, share the cached representatives
+type paramsKey struct {
+	name     string
+	diDelta  string
+	diBlocks int
+}
+
+func newParamsKey(params *ParameterSet) paramsKey {
+	return paramsKey{name: params.Name, diDelta: string(params.diHash.delta.Bytes()), diBlocks: params.diHash.blocks}
+}
+
+type cacheEntry struct {
+	key  cacheKey
+	rep  *big.Int
+	size int64
+}
+
+// RepresentativeCache is a concurrency-safe LRU cache of representatives in front of GenRepresentatives,
+// so that the representatives of unchanged elements are not generated again. The memory taken by the cached
+// elements and representatives is bounded by maxBytes, the least recently used ones are evicted first.
+type RepresentativeCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	bytes    int64
+	lru      *list.List // front is the most recently used
+	entries  map[cacheKey]*list.Element
+	stats    CacheStats
+}
+
+// NewRepresentativeCache returns an empty cache taking at most about maxBytes bytes
+func NewRepresentativeCache(maxBytes int64) *RepresentativeCache {
+	return &RepresentativeCache{
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[cacheKey]*list.Element),
+	}
+}
+
+// Stats returns the statistics of the cache
+func (c *RepresentativeCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ret := c.stats
+	ret.Entries = c.lru.Len()
+	ret.Bytes = c.bytes
+	return ret
+}
+
+// Purge removes all the cached representatives, the statistics are kept
+func (c *RepresentativeCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Init()
+	c.entries = make(map[cacheKey]*list.Element)
+	c.bytes = 0
+}
+
+// GenRepresentatives is Setup.GenRepresentatives with the cached representatives reused.
+// The returned representatives are shared with the cache and must not be modified.
+func (c *RepresentativeCache) GenRepresentatives(setup *Setup, set []string, encodeType EncodeType) ([]*big.Int, error) {
+	return c.EncodeElements(setup, StringElements(set), encodeType)
+}
+
+// EncodeElements is Setup.EncodeElements with the cached representatives reused, the missing ones are
+// generated in parallel and then cached. The returned representatives are shared with the cache and must not be modified.
+func (c *RepresentativeCache) EncodeElements(setup *Setup, set []Element, encodeType EncodeType) ([]*big.Int, error) {
+	if err := setup.CheckEncodeType(encodeType); err != nil {
+		return nil, err
+	}
+	params := newParamsKey(setup.Parameters())
+	ret := make([]*big.Int, len(set))
+	var missing []Element
+	var missingIdx []int
+	c.mu.Lock()
+	for i, v := range set {
+		if e, ok := c.entries[cacheKey{encodeType: encodeType, params: params, element: string(v)}]; ok {
+			c.lru.MoveToFront(e)
+			ret[i] = e.Value.(*cacheEntry).rep
+			c.stats.Hits++
+			continue
+		}
+		missing = append(missing, v)
+		missingIdx = append(missingIdx, i)
+		c.stats.Misses++
+	}
+	c.mu.Unlock()
+	if len(missing) == 0 {
+		return ret, nil
+	}
+
+	rep, err := setup.EncodeElementsParallel(context.Background(), missing, encodeType, 0)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, v := range missing {
+		ret[missingIdx[i]] = rep[i]
+		c.add(cacheKey{encodeType: encodeType, params: params, element: string(v)}, rep[i])
+	}
+	return ret, nil
+}
+
+// add caches the representative and evicts the least recently used ones beyond the memory bound, c.mu must be held
+func (c *RepresentativeCache) add(key cacheKey, rep *big.Int) {
+	if e, ok := c.entries[key]; ok {
+		// added by another Goroutine in the meantime
+		c.lru.MoveToFront(e)
+		return
+	}
+	size := int64(len(key.element)+len(rep.Bits())*8) + cacheEntryOverhead
+	if size > c.maxBytes {
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, rep: rep, size: size})
+	c.bytes += size
+	for c.bytes > c.maxBytes {
+		oldest := c.lru.Back()
+		entry := oldest.Value.(*cacheEntry)
+		c.lru.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.bytes -= entry.size
+		c.stats.Evictions++
+	}
+}
@@ -0,0 +1,122 @@
+package accumulator
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestRepresentativeCache(t *testing.T) {
+	setup := TrustedSetup()
+	cache := NewRepresentativeCache(1 << 20)
+	set := GenBenchSet(20)
+	want := GenRepresentatives(set, HashToPrimeFromSha256)
+	for round := 0; round < 2; round++ {
+		rep, err := cache.GenRepresentatives(setup, set, HashToPrimeFromSha256)
+		if err != nil {
+			t.Fatalf("GenRepresentatives returns error: %v", err)
+		}
+		for i := range rep {
+			if rep[i].Cmp(want[i]) != 0 {
+				t.Errorf("cached representative %d is not the generated one", i)
+			}
+		}
+	}
+	stats := cache.Stats()
+	if stats.Hits != 20 || stats.Misses != 20 || stats.Entries != 20 {
+		t.Errorf("Stats() = %+v, want 20 hits, 20 misses and 20 entries", stats)
+	}
+
+	// the same element has another representative with another encoder
+	rep, err := cache.GenRepresentatives(setup, set[:1], DIHashFromPoseidon)
+	if err != nil {
+		t.Fatalf("GenRepresentatives returns error: %v", err)
+	}
+	if rep[0].Cmp(GenRepresentatives(set[:1], DIHashFromPoseidon)[0]) != 0 {
+		t.Errorf("cache returns the representative of another encoder")
+	}
+	cache.Purge()
+	if stats = cache.Stats(); stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("Purge does not remove the cached representatives")
+	}
+}
+
+func TestRepresentativeCacheEviction(t *testing.T) {
+	setup := TrustedSetup()
+	// room for about 4 representatives of 256 bits
+	cache := NewRepresentativeCache(4 * (cacheEntryOverhead + 40))
+	set := GenBenchSet(10)
+	if _, err := cache.GenRepresentatives(setup, set, HashToPrimeFromSha256); err != nil {
+		t.Fatalf("GenRepresentatives returns error: %v", err)
+	}
+	stats := cache.Stats()
+	if stats.Entries != 4 || stats.Evictions != 6 || stats.Bytes > 4*(cacheEntryOverhead+40) {
+		t.Errorf("Stats() = %+v, want 4 entries and 6 evictions", stats)
+	}
+	// the most recently used ones are kept
+	if _, err := cache.GenRepresentatives(setup, set[6:], HashToPrimeFromSha256); err != nil {
+		t.Fatalf("GenRepresentatives returns error: %v", err)
+	}
+	if hits := cache.Stats().Hits; hits != 4 {
+		t.Errorf("got %d hits, want 4", hits)
+	}
+}
+
+func TestRepresentativeCacheConcurrent(t *testing.T) {
+	setup := TrustedSetup()
+	cache := NewRepresentativeCache(1 << 20)
+	set := GenBenchSet(30)
+	want := GenRepresentatives(set, HashToPrimeFromSha256)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(offset int) {
+			defer wg.Done()
+			rep, err := cache.GenRepresentatives(setup, set[offset:], HashToPrimeFromSha256)
+			if err != nil {
+				t.Errorf("GenRepresentatives returns error: %v", err)
+				return
+			}
+			for i := range rep {
+				if rep[i].Cmp(want[offset+i]) != 0 {
+					t.Errorf("cached representative %d is not the generated one", offset+i)
+				}
+			}
+		}(i * 5)
+	}
+	wg.Wait()
+	if stats := cache.Stats(); stats.Entries != 30 || stats.Hits+stats.Misses != 30+25+20+15 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestRepresentativeCacheParameters(t *testing.T) {
+	setup := TrustedSetup()
+	cache := NewRepresentativeCache(1 << 20)
+	set := GenBenchSet(5)
+	if _, err := cache.GenRepresentatives(setup, set, DIHashFromPoseidon); err != nil {
+		t.Fatalf("GenRepresentatives returns error: %v", err)
+	}
+	// an equal parameter set allocated separately, e.g. by loading a setup, shares the cached representatives
+	equal := *setup
+	equal.Params = NewParameterSetWithDIHash(Params2048.Name, Params2048.ModulusBits, Params2048.DIHash())
+	if _, err := cache.GenRepresentatives(&equal, set, DIHashFromPoseidon); err != nil {
+		t.Fatalf("GenRepresentatives returns error: %v", err)
+	}
+	if hits := cache.Stats().Hits; hits != 5 {
+		t.Errorf("got %d hits with an equal parameter set, want 5", hits)
+	}
+	// another DI hash has other representatives
+	diHash, err := NewDIHashWithOutputBits(1024)
+	if err != nil {
+		t.Fatalf("NewDIHashWithOutputBits returns error: %v", err)
+	}
+	other := *setup
+	other.Params = NewParameterSetWithDIHash(Params2048.Name, Params2048.ModulusBits, diHash)
+	rep, err := cache.GenRepresentatives(&other, set, DIHashFromPoseidon)
+	if err != nil {
+		t.Fatalf("GenRepresentatives returns error: %v", err)
+	}
+	if hits := cache.Stats().Hits; hits != 5 || rep[0].BitLen() > 1024 {
+		t.Errorf("cache returns the representatives of another DI hash")
+	}
+}
@@ -0,0 +1,234 @@
+package accumulator
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+const (
+	// CertifiedPrimeBitLength is the bit length of the primes generated by HashToCertifiedPrime
+	CertifiedPrimeBitLength = 256
+	// CertifiedPrimeDomain is the domain of the hash used by HashToCertifiedPrime
+	CertifiedPrimeDomain = "rsa_accumulator/accumulator/certified-prime"
+
+	// maxBasePrimeBitLength bounds the base prime of the certificates, ProbablyPrime is 100% accurate below 2^64
+	maxBasePrimeBitLength = 63
+	// maxCertificateNonce bounds the nonces tried for every prime of the chain
+	maxCertificateNonce = 1 << 16
+)
+
+// ErrInvalidCertificate is returned when a primality certificate is malformed or does not prove its prime
+var ErrInvalidCertificate = errors.New("invalid primality certificate")
+
+// PocklingtonStep proves that P = 2*K*Q + 1 is a prime, where Q is the prime proved by the previous step.
+// Pocklington's criterion holds with the witness A: A^{P-1} = 1 mod P and gcd(A^{(P-1)/Q} - 1, P) = 1,
+// together with Q > sqrt(P). Nonce is the nonce of the hash which generated K.
+type PocklingtonStep struct {
+	Nonce uint32
+	K     *big.Int
+	A     *big.Int
+}
+
+// PrimalityCertificate is a Pocklington chain: the base prime is smaller than 2^63 and is checked deterministically,
+// every step proves a prime with about twice the bit length of the previous one, and the last step proves the
+// certified prime. Verifying it needs no probabilistic primality test.
+type PrimalityCertificate struct {
+	BaseNonce uint32
+	Base      *big.Int
+	Steps     []PocklingtonStep
+}
+
+// certificateBitLengths returns the bit lengths of the primes in the chain of a bitLen-bit prime, from the base prime.
+// A prime P with at most 2*l - 2 bits is smaller than Q^2 for every l-bit Q, as Pocklington's criterion requires.
+func certificateBitLengths(bitLen int) []int {
+	lengths := []int{bitLen}
+	for bitLen > maxBasePrimeBitLength {
+		bitLen = (bitLen + 3) / 2
+		lengths = append(lengths, bitLen)
+	}
+	for i, j := 0, len(lengths)-1; i < j; i, j = i+1, j-1 {
+		lengths[i], lengths[j] = lengths[j], lengths[i]
+	}
+	return lengths
+}
+
+// hashWithStep returns the 256-bit hash of the input together with the step of the chain and the nonce
+func hashWithStep(input []byte, step int, nonce uint32) *big.Int {
+	h := sha256.New()
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], uint32(len(CertifiedPrimeDomain)))
+	h.Write(buf[:])
+	h.Write([]byte(CertifiedPrimeDomain))
+	binary.BigEndian.PutUint32(buf[:], uint32(step))
+	h.Write(buf[:])
+	binary.BigEndian.PutUint32(buf[:], nonce)
+	h.Write(buf[:])
+	h.Write(input)
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+// basePrimeCandidate returns the odd bitLen-bit candidate of the base prime with the nonce
+func basePrimeCandidate(input []byte, nonce uint32, bitLen int) *big.Int {
+	ret := hashWithStep(input, 0, nonce)
+	ret.Rsh(ret, uint(sha256.Size*8-bitLen))
+	ret.SetBit(ret, bitLen-1, 1)
+	return ret.SetBit(ret, 0, 1)
+}
+
+// nextPrimeCandidate returns P = 2*K*q + 1 with K hashed from the input with the nonce into the range
+// such that P has exactly bitLen bits, i.e. 2^{bitLen-1} <= P < 2^bitLen
+func nextPrimeCandidate(input []byte, step int, nonce uint32, q *big.Int, bitLen int) (*big.Int, *big.Int) {
+	var twoQ, lo, hi big.Int
+	twoQ.Lsh(q, 1)
+	// lo = ceil((2^{bitLen-1} - 1) / 2q), hi = floor((2^bitLen - 2) / 2q)
+	lo.Lsh(big1, uint(bitLen-1))
+	lo.Sub(&lo, big2)
+	lo.Div(&lo, &twoQ)
+	lo.Add(&lo, big1)
+	hi.Lsh(big1, uint(bitLen))
+	hi.Sub(&hi, big2)
+	hi.Div(&hi, &twoQ)
+	hi.Sub(&hi, &lo)
+	hi.Add(&hi, big1)
+	k := hashWithStep(input, step, nonce)
+	k.Mod(k, &hi)
+	k.Add(k, &lo)
+	ret := new(big.Int).Mul(k, &twoQ)
+	return ret.Add(ret, big1), k
+}
+
+// HashToCertifiedPrime hashes the input to a CertifiedPrimeBitLength-bit prime, and returns the prime together with
+// its Pocklington certificate. Unlike HashToPrime, the primality of the output can be verified deterministically
+// with VerifyPrimalityCertificate.
+func HashToCertifiedPrime(input []byte) (*big.Int, *PrimalityCertificate) {
+	lengths := certificateBitLengths(CertifiedPrimeBitLength)
+	cert := new(PrimalityCertificate)
+	for nonce := uint32(0); nonce < maxCertificateNonce; nonce++ {
+		candidate := basePrimeCandidate(input, nonce, lengths[0])
+		if candidate.ProbablyPrime(0) {
+			cert.BaseNonce, cert.Base = nonce, candidate
+			break
+		}
+	}
+	if cert.Base == nil {
+		// Should never reach here
+		panic("no base prime found by HashToCertifiedPrime")
+	}
+	q := cert.Base
+	for step := 1; step < len(lengths); step++ {
+		var next *PocklingtonStep
+		for nonce := uint32(0); nonce < maxCertificateNonce && next == nil; nonce++ {
+			p, k := nextPrimeCandidate(input, step, nonce, q, lengths[step])
+			if !probablyPrimeSieved(p) {
+				continue
+			}
+			if a := findPocklingtonWitness(p, q); a != nil {
+				next = &PocklingtonStep{Nonce: nonce, K: k, A: a}
+				q = p
+			}
+		}
+		if next == nil {
+			// Should never reach here
+			panic("no prime found by HashToCertifiedPrime")
+		}
+		cert.Steps = append(cert.Steps, *next)
+	}
+	return q, cert
+}
+
+// findPocklingtonWitness returns the smallest A >= 2 satisfying Pocklington's criterion for P = 2*K*Q + 1,
+// or nil if there is none among the first few candidates, e.g. when P is not a prime
+func findPocklingtonWitness(p, q *big.Int) *big.Int {
+	for a := int64(2); a < 1000; a++ {
+		if checkPocklington(p, q, big.NewInt(a)) {
+			return big.NewInt(a)
+		}
+	}
+	return nil
+}
+
+// checkPocklington returns true if Q^2 > P, Q divides P-1, A^{P-1} = 1 mod P and gcd(A^{(P-1)/Q} - 1, P) = 1.
+// If Q is a prime, P is then a prime.
+func checkPocklington(p, q, a *big.Int) bool {
+	var pMinus1, e, r, temp big.Int
+	pMinus1.Sub(p, big1)
+	if temp.Mul(q, q).Cmp(p) <= 0 {
+		return false
+	}
+	if e.DivMod(&pMinus1, q, &r); r.Sign() != 0 {
+		return false
+	}
+	if a.Cmp(big1) <= 0 || a.Cmp(&pMinus1) >= 0 {
+		return false
+	}
+	if temp.Exp(a, &pMinus1, p).Cmp(big1) != 0 {
+		return false
+	}
+	temp.Exp(a, &e, p)
+	temp.Sub(&temp, big1)
+	return temp.GCD(nil, nil, &temp, p).Cmp(big1) == 0
+}
+
+// VerifyPrimalityCertificate deterministically checks that cert proves prime is a prime
+func VerifyPrimalityCertificate(prime *big.Int, cert *PrimalityCertificate) error {
+	if prime == nil || cert == nil || cert.Base == nil {
+		return ErrInvalidCertificate
+	}
+	if cert.Base.BitLen() > maxBasePrimeBitLength || !cert.Base.ProbablyPrime(0) {
+		return ErrInvalidCertificate
+	}
+	q := cert.Base
+	for _, step := range cert.Steps {
+		if step.K == nil || step.A == nil || step.K.Sign() <= 0 {
+			return ErrInvalidCertificate
+		}
+		p := new(big.Int).Mul(step.K, q)
+		p.Lsh(p, 1)
+		p.Add(p, big1)
+		if !checkPocklington(p, q, step.A) {
+			return ErrInvalidCertificate
+		}
+		q = p
+	}
+	if q.Cmp(prime) != 0 {
+		return ErrInvalidCertificate
+	}
+	return nil
+}
+
+// VerifyCertifiedHashToPrime checks that prime is the output of HashToCertifiedPrime for the input, i.e. every
+// prime of the chain is hashed from the input with its nonce, and that cert proves its primality
+func VerifyCertifiedHashToPrime(input []byte, prime *big.Int, cert *PrimalityCertificate) error {
+	if err := VerifyPrimalityCertificate(prime, cert); err != nil {
+		return err
+	}
+	lengths := certificateBitLengths(CertifiedPrimeBitLength)
+	if len(cert.Steps) != len(lengths)-1 {
+		return ErrInvalidCertificate
+	}
+	q := basePrimeCandidate(input, cert.BaseNonce, lengths[0])
+	if q.Cmp(cert.Base) != 0 {
+		return ErrInvalidCertificate
+	}
+	for i, step := range cert.Steps {
+		p, k := nextPrimeCandidate(input, i+1, step.Nonce, q, lengths[i+1])
+		if k.Cmp(step.K) != 0 {
+			return ErrInvalidCertificate
+		}
+		q = p
+	}
+	return nil
+}
+
+// GenCertificates generates the CertifiedHashToPrimeFromSha256 representatives of the set together with their
+// primality certificates
+func GenCertificates(set []string) ([]*big.Int, []*PrimalityCertificate) {
+	rep := make([]*big.Int, len(set))
+	certs := make([]*PrimalityCertificate, len(set))
+	for i, v := range set {
+		rep[i], certs[i] = HashToCertifiedPrime([]byte(v))
+	}
+	return rep, certs
+}
@@ -0,0 +1,78 @@
+package accumulator
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestCertificateBitLengths(t *testing.T) {
+	for _, bitLen := range []int{40, 256, 1024} {
+		lengths := certificateBitLengths(bitLen)
+		if lengths[0] > maxBasePrimeBitLength || lengths[len(lengths)-1] != bitLen {
+			t.Errorf("invalid chain %v for %d bits", lengths, bitLen)
+		}
+		for i := 1; i < len(lengths); i++ {
+			if lengths[i] > 2*lengths[i-1]-2 {
+				t.Errorf("%d-bit prime cannot be certified by a %d-bit prime", lengths[i], lengths[i-1])
+			}
+		}
+	}
+}
+
+func TestHashToCertifiedPrime(t *testing.T) {
+	set := GenBenchSet(10)
+	rep, certs := GenCertificates(set)
+	for i, v := range set {
+		if rep[i].BitLen() != CertifiedPrimeBitLength || !rep[i].ProbablyPrime(securityParaHashToPrime) {
+			t.Errorf("representative %d is not a %d-bit prime", i, CertifiedPrimeBitLength)
+		}
+		if err := VerifyPrimalityCertificate(rep[i], certs[i]); err != nil {
+			t.Errorf("VerifyPrimalityCertificate returns error: %v", err)
+		}
+		if err := VerifyCertifiedHashToPrime([]byte(v), rep[i], certs[i]); err != nil {
+			t.Errorf("VerifyCertifiedHashToPrime returns error: %v", err)
+		}
+		if err := VerifyCertifiedHashToPrime([]byte(set[(i+1)%len(set)]), rep[i], certs[i]); err == nil {
+			t.Errorf("VerifyCertifiedHashToPrime accepts the certificate of another element")
+		}
+	}
+	setup := TrustedSetup()
+	want, err := setup.GenRepresentatives(set, CertifiedHashToPrimeFromSha256)
+	if err != nil {
+		t.Fatalf("GenRepresentatives returns error: %v", err)
+	}
+	for i := range want {
+		if want[i].Cmp(rep[i]) != 0 {
+			t.Errorf("GenRepresentatives is not consistent with GenCertificates")
+		}
+	}
+
+	// a certificate does not prove a composite number
+	composite := new(big.Int).Mul(big.NewInt(1000003), big.NewInt(1000033))
+	if err := VerifyPrimalityCertificate(composite, &PrimalityCertificate{Base: composite}); err == nil {
+		t.Errorf("VerifyPrimalityCertificate accepts a composite base")
+	}
+	cert := *certs[0]
+	cert.Steps = append([]PocklingtonStep(nil), cert.Steps...)
+	last := cert.Steps[len(cert.Steps)-1]
+	cert.Steps[len(cert.Steps)-1] = PocklingtonStep{K: new(big.Int).Add(last.K, big1), A: last.A}
+	if err := VerifyPrimalityCertificate(rep[0], &cert); err == nil {
+		t.Errorf("VerifyPrimalityCertificate accepts a certificate of another number")
+	}
+	// no witness proves a composite P = 2*K*Q + 1
+	q := certs[0].Base
+	for k := int64(1); k < 100; k++ {
+		p := new(big.Int).Mul(big.NewInt(2*k), q)
+		p.Add(p, big1)
+		if p.ProbablyPrime(securityParaHashToPrime) {
+			continue
+		}
+		step := PocklingtonStep{K: big.NewInt(k)}
+		for a := int64(2); a < 100; a++ {
+			step.A = big.NewInt(a)
+			if VerifyPrimalityCertificate(p, &PrimalityCertificate{Base: q, Steps: []PocklingtonStep{step}}) == nil {
+				t.Errorf("VerifyPrimalityCertificate accepts the composite number %s", p.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,440 @@
+package accumulator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jiajunxin/multiexp"
+	"github.com/jiajunxin/rsa_accumulator/group"
+)
+
+const (
+	// CheckpointFormatVersion is the version of the on-disk format of the checkpoints of membership proofs
+	CheckpointFormatVersion = 1
+
+	checkpointMagic        = "RSAC"
+	checkpointManifestFile = "manifest.json"
+	checkpointJournalFile  = "journal"
+
+	// checkpointSyncInterval is the longest time between two syncs of the journal
+	checkpointSyncInterval = time.Second
+
+	// the types of the journal records
+	recordExpanded byte = 'E'
+	recordFinished byte = 'F'
+)
+
+var (
+	// ErrCheckpointExists is returned when starting a checkpointed pre-computation in a directory
+	// which already holds a checkpoint, use ResumeMembershipProofs to continue it
+	ErrCheckpointExists = errors.New("checkpoint already exists")
+	// ErrCheckpointMismatch is returned when resuming a checkpoint with a different base, randomizer, modulus or set
+	ErrCheckpointMismatch = errors.New("checkpoint does not match the input")
+)
+
+type checkpointManifest struct {
+	Version     int    `json:"version"`
+	Size        int    `json:"size"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// ProveMembershipWithCheckpoint is ProveMembershipParallelWithTableWithRandomizerContext with its progress recorded
+// into the directory dir: every finished step appends the bases of the subtrees it has produced, or the proofs
+// of the leaves it has computed, to a journal. If the run is interrupted, e.g. by a crash or by ctx, call
+// ResumeMembershipProofs with the same directory and input to continue it. The manifest is written atomically and
+// the journal is synced at least every checkpointSyncInterval. A nil randomizer or table is not used.
+// It returns ErrCheckpointExists if dir already holds a checkpoint. An empty set has no proof and no checkpoint.
+func ProveMembershipWithCheckpoint(ctx context.Context, dir string, base, randomizer, N *big.Int, set []*big.Int,
+	limit int, table *multiexp.PreTable, progress Progress) ([]*big.Int, error) {
+	if len(set) == 0 {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	manifestPath := filepath.Join(dir, checkpointManifestFile)
+	if _, err := os.Stat(manifestPath); err == nil {
+		return nil, ErrCheckpointExists
+	}
+	f, err := os.OpenFile(filepath.Join(dir, checkpointJournalFile), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	manifest, err := json.MarshalIndent(&checkpointManifest{
+		Version:     CheckpointFormatVersion,
+		Size:        len(set),
+		Fingerprint: hex.EncodeToString(checkpointFingerprint(base, randomizer, N, set)),
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = writeFileAtomic(manifestPath, manifest); err != nil {
+		return nil, err
+	}
+	// persist the names of the manifest and the journal
+	if err = syncDir(dir); err != nil {
+		return nil, err
+	}
+
+	tree := NewProductTree(set)
+	s := NewProofScheduler(limitWorkers(limit), 0)
+	r, err := s.newRun(ctx, tree, group.NewRSAGroup(N), randomizer, tableFourfoldExp(table, limit), progress)
+	if err != nil {
+		return nil, err
+	}
+	journal := newCheckpointJournal(f)
+	r.observer = journal
+	return journal.run(s, r, []proofTask{tree.root(base)})
+}
+
+// ResumeMembershipProofs continues the pre-computation checkpointed in dir by ProveMembershipWithCheckpoint, the input
+// must be the same. The proofs of the finished subtrees are read from the checkpoint and the unfinished subtrees
+// are computed from their recorded bases, so the proofs are the same as those of an uninterrupted run.
+// A journal record torn by a crash is discarded. It returns ErrCheckpointMismatch if the input is different.
+// An empty set has no proof, dir is not read.
+func ResumeMembershipProofs(ctx context.Context, dir string, base, randomizer, N *big.Int, set []*big.Int,
+	limit int, table *multiexp.PreTable, progress Progress) ([]*big.Int, error) {
+	if len(set) == 0 {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, checkpointManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var manifest checkpointManifest
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Version != CheckpointFormatVersion {
+		return nil, ErrUnsupportedVersion
+	}
+	if manifest.Size != len(set) ||
+		manifest.Fingerprint != hex.EncodeToString(checkpointFingerprint(base, randomizer, N, set)) {
+		return nil, ErrCheckpointMismatch
+	}
+	f, err := os.OpenFile(filepath.Join(dir, checkpointJournalFile), os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err = io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	state, valid := readCheckpointJournal(data)
+	// drop the torn record, if any, so that the new records follow the consistent ones
+	if err = f.Truncate(int64(valid)); err != nil {
+		return nil, err
+	}
+	if _, err = f.Seek(int64(valid), io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	tree := NewProductTree(set)
+	s := NewProofScheduler(limitWorkers(limit), 0)
+	r, err := s.newRun(ctx, tree, group.NewRSAGroup(N), randomizer, tableFourfoldExp(table, limit), progress)
+	if err != nil {
+		return nil, err
+	}
+	journal := newCheckpointJournal(f)
+	r.observer = journal
+	var tasks []proofTask
+	restored := state.restore(tree, tree.root(base), r.proofs, &tasks)
+	if restored > 0 {
+		r.tracker.add(restored)
+	}
+	return journal.run(s, r, tasks)
+}
+
+// writeFileAtomic writes data to a temporary file in the directory of path, syncs it and renames it to path,
+// so that path holds either nothing or all the data after a crash
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// syncDir syncs the directory, so that the files created or renamed in it survive a crash
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// checkpointFingerprint returns the SHA-256 hash of the input of a checkpointed pre-computation
+func checkpointFingerprint(base, randomizer, N *big.Int, set []*big.Int) []byte {
+	h := sha256.New()
+	h.Write([]byte(checkpointMagic))
+	writeVersion(h, CheckpointFormatVersion)
+	writeLengthPrefixed(h, N.Bytes())
+	writeLengthPrefixed(h, base.Bytes())
+	// a nil randomizer is not the same as a randomizer of 0
+	if randomizer == nil {
+		h.Write([]byte{0})
+	} else {
+		h.Write([]byte{1})
+		writeLengthPrefixed(h, randomizer.Bytes())
+	}
+	for _, v := range set {
+		writeLengthPrefixed(h, v.Bytes())
+	}
+	return h.Sum(nil)
+}
+
+// checkpointJournal appends the finished steps of a proofRun to the journal file. Every record is framed by
+// the uint32 big-endian length of its payload and the CRC-32 of the payload, so that a torn record is detected.
+// The journal is synced at least every checkpointSyncInterval and when the run ends, so a power loss drops
+// at most the records of the last interval, which are computed again on resume.
+// The checkpoints are in the RSA group, the bases and the proofs are recorded as integers.
+type checkpointJournal struct {
+	mu       sync.Mutex
+	f        *os.File
+	lastSync time.Time
+}
+
+func newCheckpointJournal(f *os.File) *checkpointJournal {
+	return &checkpointJournal{f: f, lastSync: time.Now()}
+}
+
+// run runs the tasks of r and syncs the journal once they are done, the error of the run is returned first
+func (j *checkpointJournal) run(s *ProofScheduler, r *proofRun, tasks []proofTask) ([]*big.Int, error) {
+	proofs, err := s.runTasks(r, tasks)
+	j.mu.Lock()
+	syncErr := j.f.Sync()
+	j.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if syncErr != nil {
+		return nil, syncErr
+	}
+	return elementInts(proofs), nil
+}
+
+// expanded records the bases of the subtasks, the payload is the type, the node of the task, the number
+// of subtasks, and every subtask node with its length-prefixed base
+func (j *checkpointJournal) expanded(task proofTask, subtasks []proofTask) error {
+	payload := appendRecordHeader(nil, recordExpanded, task.node, len(subtasks))
+	for _, sub := range subtasks {
+		payload = appendTreeNode(payload, sub.node)
+		payload = appendLengthPrefixed(payload, sub.base.(*big.Int).Bytes())
+	}
+	return j.append(payload)
+}
+
+// finished records the proofs of the leaves under the task, the payload is the type, the node of the task,
+// the number of proofs and every length-prefixed proof
+func (j *checkpointJournal) finished(task proofTask, proofs []group.Element) error {
+	payload := appendRecordHeader(nil, recordFinished, task.node, len(proofs))
+	for _, v := range proofs {
+		payload = appendLengthPrefixed(payload, v.(*big.Int).Bytes())
+	}
+	return j.append(payload)
+}
+
+func (j *checkpointJournal) append(payload []byte) error {
+	record := make([]byte, 0, len(payload)+8)
+	record = binary.BigEndian.AppendUint32(record, uint32(len(payload)))
+	record = append(record, payload...)
+	record = binary.BigEndian.AppendUint32(record, crc32.ChecksumIEEE(payload))
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err := j.f.Write(record); err != nil {
+		return err
+	}
+	if time.Since(j.lastSync) < checkpointSyncInterval {
+		return nil
+	}
+	j.lastSync = time.Now()
+	return j.f.Sync()
+}
+
+func appendRecordHeader(buf []byte, recordType byte, node treeNode, count int) []byte {
+	buf = append(buf, recordType)
+	buf = appendTreeNode(buf, node)
+	return binary.BigEndian.AppendUint32(buf, uint32(count))
+}
+
+func appendTreeNode(buf []byte, node treeNode) []byte {
+	buf = binary.BigEndian.AppendUint32(buf, uint32(node.level))
+	return binary.BigEndian.AppendUint64(buf, uint64(node.index))
+}
+
+func appendLengthPrefixed(buf, data []byte) []byte {
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(data)))
+	return append(buf, data...)
+}
+
+// checkpointState is the content of a journal: the subtasks of the expanded tasks and the proofs of the finished ones
+type checkpointState struct {
+	expanded map[treeNode][]proofTask
+	finished map[treeNode][]group.Element
+}
+
+// readCheckpointJournal decodes the records of the journal up to the first torn or invalid one,
+// it returns the decoded state and the length of the consistent records
+func readCheckpointJournal(data []byte) (*checkpointState, int) {
+	state := &checkpointState{
+		expanded: make(map[treeNode][]proofTask),
+		finished: make(map[treeNode][]group.Element),
+	}
+	valid := 0
+	for len(data)-valid >= 8 {
+		length := int(binary.BigEndian.Uint32(data[valid:]))
+		if length > len(data)-valid-8 {
+			break
+		}
+		payload := data[valid+4 : valid+4+length]
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(data[valid+4+length:]) {
+			break
+		}
+		if !state.decode(payload) {
+			break
+		}
+		valid += length + 8
+	}
+	return state, valid
+}
+
+// decode adds the record to the state, it returns false if the record is invalid
+func (st *checkpointState) decode(payload []byte) bool {
+	d := &recordDecoder{data: payload}
+	recordType := d.byte()
+	node := d.treeNode()
+	count := d.uint32()
+	if d.err || count > uint32(len(payload)) {
+		return false
+	}
+	switch recordType {
+	case recordExpanded:
+		subtasks := make([]proofTask, count)
+		for i := range subtasks {
+			subtasks[i].node = d.treeNode()
+			subtasks[i].base = new(big.Int).SetBytes(d.lengthPrefixed())
+		}
+		if d.err || len(d.data) != 0 {
+			return false
+		}
+		st.expanded[node] = subtasks
+	case recordFinished:
+		proofs := make([]group.Element, count)
+		for i := range proofs {
+			proofs[i] = new(big.Int).SetBytes(d.lengthPrefixed())
+		}
+		if d.err || len(d.data) != 0 {
+			return false
+		}
+		st.finished[node] = proofs
+	default:
+		return false
+	}
+	return true
+}
+
+// restore copies the recorded proofs under the task into proofs and appends the unfinished tasks to tasks,
+// it returns the number of restored proofs
+func (st *checkpointState) restore(tree *ProductTree, task proofTask, proofs []group.Element, tasks *[]proofTask) int {
+	if subtasks, ok := st.expanded[task.node]; ok && tree.validSubtasks(task.node, subtasks) {
+		restored := 0
+		for _, sub := range subtasks {
+			restored += st.restore(tree, sub, proofs, tasks)
+		}
+		return restored
+	}
+	start, end := tree.span(task.node)
+	if recorded, ok := st.finished[task.node]; ok && len(recorded) == end-start {
+		copy(proofs[start:end], recorded)
+		return end - start
+	}
+	*tasks = append(*tasks, task)
+	return 0
+}
+
+// validSubtasks returns true if the subtasks are nodes of the tree under n, so that a journal of another
+// tree never makes restore run out of range
+func (t *ProductTree) validSubtasks(n treeNode, subtasks []proofTask) bool {
+	start, end := t.span(n)
+	for _, sub := range subtasks {
+		if sub.node.level < 0 || sub.node.level >= n.level || sub.node.index < 0 ||
+			sub.node.index >= len(t.levels[sub.node.level]) {
+			return false
+		}
+		subStart, subEnd := t.span(sub.node)
+		if subStart < start || subEnd > end {
+			return false
+		}
+	}
+	return len(subtasks) > 0
+}
+
+// recordDecoder reads the fields of a journal record, err is set once the record is too short
+type recordDecoder struct {
+	data []byte
+	err  bool
+}
+
+func (d *recordDecoder) next(n int) []byte {
+	if d.err || n < 0 || len(d.data) < n {
+		d.err = true
+		return nil
+	}
+	ret := d.data[:n]
+	d.data = d.data[n:]
+	return ret
+}
+
+func (d *recordDecoder) byte() byte {
+	if b := d.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *recordDecoder) uint32() uint32 {
+	if b := d.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *recordDecoder) treeNode() treeNode {
+	level := d.uint32()
+	var index uint64
+	if b := d.next(8); b != nil {
+		index = binary.BigEndian.Uint64(b)
+	}
+	return treeNode{level: int(level), index: int(index)}
+}
+
+func (d *recordDecoder) lengthPrefixed() []byte {
+	return d.next(int(d.uint32()))
+}
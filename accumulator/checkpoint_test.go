@@ -0,0 +1,133 @@
+package accumulator
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jiajunxin/multiexp"
+)
+
+func checkSameProofs(t *testing.T, proofs, expected []*big.Int) {
+	t.Helper()
+	if len(proofs) != len(expected) {
+		t.Fatalf("got %d proofs, want %d", len(proofs), len(expected))
+	}
+	for i := range proofs {
+		if proofs[i].Cmp(expected[i]) != 0 {
+			t.Fatalf("proof %d is different from the uninterrupted run", i)
+		}
+	}
+}
+
+func TestCheckpointResume(t *testing.T) {
+	setup := TrustedSetup()
+	rep := GenRepresentatives(GenTestSet(50), HashToPrimeFromSha256)
+	r := GenRandomizer()
+	table := multiexp.NewPrecomputeTable(setup.G, setup.N, 50*1025/64+64)
+	expected := ProveMembershipParallelWithTableWithRandomizer(setup.G, r, setup.N, rep, 0, table)
+	checkProofs(t, AccumulateNew(setup.G, r, setup.N), setup.N, rep, expected)
+
+	for _, stopAfter := range []int{1, 10, 30} {
+		dir := t.TempDir()
+		ctx, cancel := context.WithCancel(context.Background())
+		progress := ProgressFunc(func(completed, total int, eta time.Duration) {
+			if completed >= stopAfter {
+				cancel()
+			}
+		})
+		_, err := ProveMembershipWithCheckpoint(ctx, dir, setup.G, r, setup.N, rep, 0, table, progress)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("interrupted run returns %v, want %v", err, context.Canceled)
+		}
+		// resume twice, the first resumed run is interrupted as well
+		ctx, cancel = context.WithCancel(context.Background())
+		progress = ProgressFunc(func(completed, total int, eta time.Duration) {
+			if completed >= stopAfter+10 {
+				cancel()
+			}
+		})
+		_, err = ResumeMembershipProofs(ctx, dir, setup.G, r, setup.N, rep, 2, table, progress)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			t.Fatalf("ResumeMembershipProofs returns error: %v", err)
+		}
+		proofs, err := ResumeMembershipProofs(context.Background(), dir, setup.G, r, setup.N, rep, 0, table, nil)
+		if err != nil {
+			t.Fatalf("ResumeMembershipProofs returns error: %v", err)
+		}
+		checkSameProofs(t, proofs, expected)
+	}
+}
+
+func TestCheckpointTornRecord(t *testing.T) {
+	setup := TrustedSetup()
+	rep := GenRepresentatives(GenTestSet(20), HashToPrimeFromSha256)
+	expected := ProveMembership(setup.G, setup.N, rep)
+	dir := t.TempDir()
+	proofs, err := ProveMembershipWithCheckpoint(context.Background(), dir, setup.G, nil, setup.N, rep, 0, nil, nil)
+	if err != nil {
+		t.Fatalf("ProveMembershipWithCheckpoint returns error: %v", err)
+	}
+	checkSameProofs(t, proofs, expected)
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 2 {
+		t.Errorf("the checkpoint directory holds %d files, want the manifest and the journal only", len(entries))
+	}
+	if _, err = ProveMembershipWithCheckpoint(context.Background(), dir, setup.G, nil, setup.N, rep, 0, nil, nil); !errors.Is(err, ErrCheckpointExists) {
+		t.Errorf("ProveMembershipWithCheckpoint on an existing checkpoint returns %v, want %v", err, ErrCheckpointExists)
+	}
+
+	// cut the journal in the middle of a record, and corrupt the checksum of the record before
+	path := filepath.Join(dir, checkpointJournalFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, valid := readCheckpointJournal(data)
+	if valid != len(data) {
+		t.Fatalf("%d bytes of the journal are consistent, want %d", valid, len(data))
+	}
+	data = data[:len(data)*2/3]
+	data[len(data)-1] ^= 1
+	if err = os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	proofs, err = ResumeMembershipProofs(context.Background(), dir, setup.G, nil, setup.N, rep, 1, nil, nil)
+	if err != nil {
+		t.Fatalf("ResumeMembershipProofs returns error: %v", err)
+	}
+	checkSameProofs(t, proofs, expected)
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, valid = readCheckpointJournal(data); valid != len(data) {
+		t.Errorf("the resumed journal is not consistent")
+	}
+
+	if _, err = ResumeMembershipProofs(context.Background(), dir, setup.G, nil, setup.N, rep[1:], 0, nil, nil); !errors.Is(err, ErrCheckpointMismatch) {
+		t.Errorf("ResumeMembershipProofs with another set returns %v, want %v", err, ErrCheckpointMismatch)
+	}
+	if _, err = ResumeMembershipProofs(context.Background(), dir, setup.G, big1, setup.N, rep, 0, nil, nil); !errors.Is(err, ErrCheckpointMismatch) {
+		t.Errorf("ResumeMembershipProofs with a randomizer returns %v, want %v", err, ErrCheckpointMismatch)
+	}
+}
+
+func TestCheckpointEmptySet(t *testing.T) {
+	setup := TrustedSetup()
+	dir := filepath.Join(t.TempDir(), "checkpoint")
+	proofs, err := ProveMembershipWithCheckpoint(context.Background(), dir, setup.G, nil, setup.N, nil, 0, nil, nil)
+	if proofs != nil || err != nil {
+		t.Errorf("ProveMembershipWithCheckpoint of an empty set returns %v, %v", proofs, err)
+	}
+	if _, err = os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("ProveMembershipWithCheckpoint of an empty set creates the checkpoint directory")
+	}
+	if proofs, err = ResumeMembershipProofs(context.Background(), dir, setup.G, nil, setup.N, nil, 0, nil, nil); proofs != nil || err != nil {
+		t.Errorf("ResumeMembershipProofs of an empty set returns %v, %v", proofs, err)
+	}
+}
@@ -11,7 +11,7 @@ import (
 
 const (
 	securityPara = 128
-	// RSABitLength denotes the test bit length of RSA
+	// RSABitLength denotes the bit length of RSA of the default parameter set Params2048
 	RSABitLength = 2048
 	// Note that the securityParaHashToPrime is running securityParaHashToPrime rounds of Miller-Robin test
 	// together with one time Baillie-PSW test. Totally heuristic value for now.
@@ -27,8 +27,20 @@ const (
 
 	// HashToPrimeFromSha256 is a prime number generated from Sha256
 	HashToPrimeFromSha256 = iota
-	// DIHashFromPoseidon is a division intractable Hash output
+	// DIHashFromPoseidon is a division intractable Hash output, the elements are parsed as decimal integers
 	DIHashFromPoseidon
+	// HashToPrimeWithNonceFromSha256 is a prime number generated from Sha256 with a nonce, see HashToPrimeWithNonce
+	HashToPrimeWithNonceFromSha256
+	// CertifiedHashToPrimeFromSha256 is a prime number with a primality certificate, see HashToCertifiedPrime
+	CertifiedHashToPrimeFromSha256
+	// HashToPrimeFromSha3256 is a prime number generated from SHA3-256
+	HashToPrimeFromSha3256
+	// HashToPrimeFromKeccak256 is a prime number generated from Keccak-256, as used by Ethereum
+	HashToPrimeFromKeccak256
+	// HashToPrimeFromBlake2b is a prime number generated from BLAKE2b-256
+	HashToPrimeFromBlake2b
+	// DIHashFromPoseidonBytes is a division intractable Hash output of the elements of arbitrary bytes, e.g. records
+	DIHashFromPoseidonBytes
 	// PString stores P, generated by RandomSetupForUniversalHash
 	PString = "90906479945022450706608444255860322124872501190254782434061962615363326054763"
 	// AString stores P, generated by RandomSetupForUniversalHash
@@ -53,10 +65,10 @@ var (
 	big37 = big.NewInt(37)
 	// Min1024 is set to a 1024 bits number with most significant bit 1 and other bits 0
 	// This can speed up the calculation
-	// Min1024 is set to 2^1023
-	Min1024 = big.NewInt(0)
-	// Min2048 is set to 2^2047
-	Min2048 = big.NewInt(0)
+	// Min1024 is set to 2^1023, the DI hash offset of Params2048
+	Min1024 = Params2048.DIOffset()
+	// Min2048 is set to 2^2047, the randomizer bound of Params2048
+	Min2048 = Params2048.RandomizerBound()
 	// P is generated by RandomSetupForUniversalHash for UniversalHash
 	P = big.NewInt(0)
 	// A is generated by RandomSetupForUniversalHash for UniversalHash
@@ -67,36 +79,34 @@ var (
 
 // Setup is a basic struct for a hidden order group
 type Setup struct {
-	N *big.Int
-	G *big.Int //default generator in Z*_N
-	H *big.Int //default generator in Z*_N
+	N      *big.Int
+	G      *big.Int      //default generator in Z*_N
+	H      *big.Int      //default generator in Z*_N
+	Params *ParameterSet //nil means Params2048
+	// Encoder is the name of the encoder of the representatives, see RegisterEncoder.
+	// If it is set, the setup can only be used with the encode type of this encoder.
+	Encoder string
 }
 
-// Element should be able to be accumulated into RSA accumulator
+// Element should be able to be accumulated into RSA accumulator, it is made of arbitrary bytes.
+// The functions taking strings are wrappers of those taking elements, accumulating the bytes of the strings,
+// see StringElement.
 type Element []byte
 
-// EncodeType is the type of generating Element, should be consistent all the time
+// EncodeType is the type of generating Element, should be consistent all the time.
+// Every encode type is backed by an Encoder, more encode types can be added by RegisterEncoder.
 type EncodeType int
 
 // GenerateG generates a generator for a hidden order group randomly
 func GenerateG() {
-	buffer := make([]big.Int, 8)
-	buffer[0].Set(SHA256ToInt([]byte(N2048String))) //g1 should be 256 bit.
-	for i := 1; i < 8; i++ {
-		buffer[i].Set(SHA256ToInt(buffer[i-1].Bytes()))
-	}
-	prod := SetProduct(buffer)
 	var N big.Int
 	N.SetString(N2048String, 10)
-	prod.Mod(prod, &N)
-	fmt.Println("prod = ", prod.String())
-	var gcd big.Int
-	gcd.GCD(nil, nil, &N, prod)
-	if gcd.Cmp(big1) != 0 {
-		// gcd != 1
+	g, err := DeriveGenerator(&N)
+	if err != nil {
 		//this condition should never happen
 		fmt.Println("g and N not co-prime! We win the RSA-2048 challenge!")
 	}
+	fmt.Println("prod = ", g.String())
 }
 
 // SetProduct calculates the products of the input set
@@ -229,35 +239,17 @@ func GetPseudoRandomElement(input int) *Element {
 
 // TrustedSetupForQRN outputs a hidden order group
 func TrustedSetupForQRN() {
-	var p, q, N, g, h big.Int
-	p = *getSafePrime()
-	q = *getSafePrime()
-	fmt.Println("Bit length of p = ", p.BitLen())
-	fmt.Println("Bit length of q = ", q.BitLen())
-	N.Mul(&p, &q)
-
-	g = *getRanQR(&p, &q)
-	// get a uniform random value randomNum in the QR_N, where the order of the group is p'q'
-	randomNum, err := crand.Prime(crand.Reader, RSABitLength)
-	if err != nil {
-		panic(err)
-	}
-	order := getOrder(&p, &q)
-	randomNum.Mod(randomNum, order)
-	h.Exp(&g, randomNum, &N)
-	fmt.Println("N = ", N.String())
-	fmt.Println("g = ", g.String())
-	fmt.Println("h = ", h.String())
+	setup, trapdoor := TrustedSetupWithTrapdoor()
+	fmt.Println("Bit length of p = ", trapdoor.P.BitLen())
+	fmt.Println("Bit length of q = ", trapdoor.Q.BitLen())
+	fmt.Println("N = ", setup.N.String())
+	fmt.Println("g = ", setup.G.String())
+	fmt.Println("h = ", setup.H.String())
 }
 
 // RandomSetupForUniversalHash generates parameters for a universal hash.
 func RandomSetupForUniversalHash() {
-	p := getPrime256()
-	a, err := crand.Int(crand.Reader, p)
-	if err != nil {
-		panic(err)
-	}
-	b, err := crand.Int(crand.Reader, p)
+	p, a, b, err := GenerateUniversalHashParameters()
 	if err != nil {
 		panic(err)
 	}
@@ -343,68 +335,46 @@ func safePrimeSieve(input *big.Int) bool {
 	return true
 }
 
-func getSuitablePrime() *big.Int {
-	flag := false
-	for !flag {
-		ranNum, err := crand.Prime(crand.Reader, RSABitLength/2-1)
+// a safe prime p = 2p' +1 where p' is also a prime number
+func getSafePrime() *big.Int {
+	ranNum, err := genSafePrime(Params2048.ModulusBits / 2)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("Found one safe prime = ", ranNum.String())
+	return ranNum
+}
+
+// genSafePrime returns a bitLen-bit safe prime
+func genSafePrime(bitLen int) (*big.Int, error) {
+	for {
+		ranNum, err := crand.Prime(crand.Reader, bitLen-1)
 		if err != nil {
-			panic(err)
-		}
-		flag = safePrimeSieve(ranNum)
-		if !flag {
-			continue
+			return nil, err
 		}
-		flag = ranNum.ProbablyPrime(securityPara / 2)
-		if !flag {
+		if !safePrimeSieve(ranNum) || !ranNum.ProbablyPrime(securityPara/2) {
 			continue
 		}
-		return ranNum
-	}
-	return nil
-}
-
-// a safe prime p = 2p' +1 where p' is also a prime number
-func getSafePrime() *big.Int {
-	flag := false
-	for !flag {
-		ranNum := getSuitablePrime()
-		//fmt.Println("get a prime = ", ranNum.String())
 		ranNum.Mul(ranNum, big2)
 		ranNum.Add(ranNum, big1)
-		flag = ranNum.ProbablyPrime(securityPara / 2)
-		if !flag {
-			continue
-		} else {
-			fmt.Println("Found one safe prime = ", ranNum.String())
-			return ranNum
+		if ranNum.ProbablyPrime(securityPara / 2) {
+			return ranNum, nil
 		}
 	}
-	return nil
 }
 
-func getRanQR(p, q *big.Int) *big.Int {
-	var N big.Int
-	N.Mul(p, q)
-
-	flag := false
-	for !flag {
-		ranNum, err := crand.Int(crand.Reader, Min2048)
+// genRanQR returns a uniformly random quadratic residue in Z*_N
+func genRanQR(N *big.Int) (*big.Int, error) {
+	var gcd big.Int
+	for {
+		ranNum, err := crand.Int(crand.Reader, N)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
-		flag = isQR(ranNum, p, q)
-		if !flag {
+		if ranNum.Cmp(big1) <= 0 || gcd.GCD(nil, nil, ranNum, N).Cmp(big1) != 0 {
 			continue
-		} else {
-			return ranNum
 		}
+		ranNum.Mul(ranNum, ranNum)
+		return ranNum.Mod(ranNum, N), nil
 	}
-	return nil
-}
-
-func isQR(input, p, q *big.Int) bool {
-	if big.Jacobi(input, p) == 1 && big.Jacobi(input, q) == 1 {
-		return true
-	}
-	return false
 }
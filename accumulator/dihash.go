@@ -0,0 +1,102 @@
+package accumulator
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/poseidon"
+)
+
+// DIHashBlockBits is the bit length of every Poseidon block of the DI hash, the Poseidon output is smaller than 2^254
+const DIHashBlockBits = 254
+
+// ErrInvalidDIHash is returned when the offset of a DI hash is too small for its blocks
+var ErrInvalidDIHash = errors.New("invalid DI hash parameters")
+
+// DIHash is a division intractable hash Delta + H(x). H(x) concatenates Blocks Poseidon outputs of DIHashBlockBits bits,
+// the first block is Poseidon(x) and the j-th block is Poseidon(j, Poseidon(x)), so H(x) < 2^{DIHashBlockBits*Blocks}.
+// Delta is at least 2^{DIHashBlockBits*Blocks}, so the bit length of the output is always the bit length of Delta.
+type DIHash struct {
+	delta  *big.Int
+	blocks int
+}
+
+// NewDIHash returns a DI hash with the offset delta and the given number of Poseidon blocks
+func NewDIHash(delta *big.Int, blocks int) (*DIHash, error) {
+	if blocks < 1 || delta == nil || delta.BitLen() <= DIHashBlockBits*blocks {
+		return nil, fmt.Errorf("%w: %d blocks need an offset of more than %d bits", ErrInvalidDIHash, blocks, DIHashBlockBits*blocks)
+	}
+	return &DIHash{
+		delta:  new(big.Int).Set(delta),
+		blocks: blocks,
+	}, nil
+}
+
+// NewDIHashWithOutputBits returns the DI hash with outputBits-bit outputs, with the offset 2^{outputBits-1}
+// and as many Poseidon blocks as the offset allows
+func NewDIHashWithOutputBits(outputBits int) (*DIHash, error) {
+	if outputBits <= DIHashBlockBits {
+		return nil, fmt.Errorf("%w: output of %d bits is too short", ErrInvalidDIHash, outputBits)
+	}
+	return NewDIHash(new(big.Int).Lsh(big1, uint(outputBits-1)), (outputBits-1)/DIHashBlockBits)
+}
+
+// Delta returns the offset of the DI hash
+func (h *DIHash) Delta() *big.Int {
+	return new(big.Int).Set(h.delta)
+}
+
+// Blocks returns the number of Poseidon blocks of the DI hash
+func (h *DIHash) Blocks() int {
+	return h.blocks
+}
+
+// OutputBits returns the bit length of the outputs of the DI hash
+func (h *DIHash) OutputBits() int {
+	return h.delta.BitLen()
+}
+
+// equal returns true if h and other have the same offset and number of blocks, i.e. the same outputs
+func (h *DIHash) equal(other *DIHash) bool {
+	return h.blocks == other.blocks && h.delta.Cmp(other.delta) == 0
+}
+
+// Hash returns the DI hash of the input
+func (h *DIHash) Hash(input ...*fr.Element) *big.Int {
+	_, ret := h.PoseidonAndHash(input...)
+	return ret
+}
+
+// PoseidonAndHash returns the first Poseidon block together with the DI hash of the input.
+// The first block is short enough to be hashed again, e.g. to build a hash chain.
+func (h *DIHash) PoseidonAndHash(input ...*fr.Element) (*fr.Element, *big.Int) {
+	first := poseidon.Poseidon(input...)
+	ret := new(big.Int)
+	first.ToBigIntRegular(ret)
+	var block big.Int
+	for j := 1; j < h.blocks; j++ {
+		temp := poseidon.Poseidon(ElementFromUint32(uint32(j)), first)
+		temp.ToBigIntRegular(&block)
+		block.Lsh(&block, uint(DIHashBlockBits*j))
+		ret.Add(ret, &block)
+	}
+	ret.Add(ret, h.delta)
+	return first, ret
+}
+
+// DeltaModL returns Delta mod l, the circuits use it in place of Delta which does not fit in a field element
+func (h *DIHash) DeltaModL(l *big.Int) *big.Int {
+	return new(big.Int).Mod(h.delta, l)
+}
+
+// ShiftsModL returns 2^{DIHashBlockBits*j} mod l for j = 1, ..., Blocks-1, the circuits use them to combine the blocks
+func (h *DIHash) ShiftsModL(l *big.Int) []*big.Int {
+	ret := make([]*big.Int, h.blocks-1)
+	for j := range ret {
+		ret[j] = new(big.Int).Lsh(big1, uint(DIHashBlockBits*(j+1)))
+		ret[j].Mod(ret[j], l)
+	}
+	return ret
+}
@@ -0,0 +1,93 @@
+package accumulator
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/poseidon"
+)
+
+func TestNewDIHash(t *testing.T) {
+	if _, err := NewDIHash(new(big.Int).Lsh(big1, 2*DIHashBlockBits-1), 2); !errors.Is(err, ErrInvalidDIHash) {
+		t.Errorf("NewDIHash should reject an offset not larger than the blocks, got %v", err)
+	}
+	if _, err := NewDIHash(Min1024, 0); !errors.Is(err, ErrInvalidDIHash) {
+		t.Errorf("NewDIHash should reject zero blocks, got %v", err)
+	}
+	if _, err := NewDIHashWithOutputBits(DIHashBlockBits); !errors.Is(err, ErrInvalidDIHash) {
+		t.Errorf("NewDIHashWithOutputBits should reject a too short output, got %v", err)
+	}
+	for _, outputBits := range []int{255, 508, 509, 1024, 2048} {
+		diHash, err := NewDIHashWithOutputBits(outputBits)
+		if err != nil {
+			t.Fatalf("NewDIHashWithOutputBits(%d) returns error: %v", outputBits, err)
+		}
+		if diHash.Blocks() != (outputBits-1)/DIHashBlockBits {
+			t.Errorf("DI hash with %d-bit outputs has %d blocks", outputBits, diHash.Blocks())
+		}
+		for i := uint32(0); i < 8; i++ {
+			if bitLen := diHash.Hash(ElementFromUint32(i)).BitLen(); bitLen != outputBits {
+				t.Errorf("DI hash output has %d bits, want %d", bitLen, outputBits)
+			}
+		}
+	}
+}
+
+func TestDIHashDefault(t *testing.T) {
+	diHash := Params2048.DIHash()
+	if diHash.Blocks() != 1 || diHash.Delta().Cmp(Min1024) != 0 {
+		t.Fatalf("the DI hash of Params2048 is not 2^1023 + Poseidon(x)")
+	}
+	input := ElementFromUint32(12345)
+	var want big.Int
+	temp := poseidon.Poseidon(input)
+	temp.ToBigIntRegular(&want)
+	want.Add(&want, Min1024)
+	if DIHashPoseidon(input).Cmp(&want) != 0 {
+		t.Errorf("DIHashPoseidon is not 2^1023 + Poseidon(x)")
+	}
+	if len(diHash.ShiftsModL(big.NewInt(1009))) != 0 {
+		t.Errorf("a single-block DI hash should have no shifts")
+	}
+}
+
+func TestDIHashModL(t *testing.T) {
+	diHash, err := NewDIHashWithOutputBits(1024)
+	if err != nil {
+		t.Fatalf("NewDIHashWithOutputBits returns error: %v", err)
+	}
+	l := HashToPrime([]byte("challenge"))
+	shifts := diHash.ShiftsModL(l)
+	if len(shifts) != diHash.Blocks()-1 {
+		t.Fatalf("ShiftsModL returns %d shifts, want %d", len(shifts), diHash.Blocks()-1)
+	}
+	input := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	first, hash := diHash.PoseidonAndHash(ElementFromBigInt(input[0]), ElementFromBigInt(input[1]), ElementFromBigInt(input[2]))
+
+	// combine the blocks modulo l as the circuits do
+	var got, block big.Int
+	first.ToBigIntRegular(&got)
+	got.Add(&got, diHash.DeltaModL(l))
+	for j := range shifts {
+		temp := poseidon.Poseidon(ElementFromUint32(uint32(j+1)), first)
+		temp.ToBigIntRegular(&block)
+		block.Mul(&block, shifts[j])
+		got.Add(&got, &block)
+	}
+	got.Mod(&got, l)
+	if got.Cmp(new(big.Int).Mod(hash, l)) != 0 {
+		t.Errorf("DI hash modulo l is not combined from DeltaModL and ShiftsModL")
+	}
+
+	params := NewParameterSetWithDIHash("2048-di1024", 2048, diHash)
+	rep, err := params.GenRepresentatives([]string{"1", "2"}, DIHashFromPoseidon)
+	if err != nil {
+		t.Fatalf("GenRepresentatives returns error: %v", err)
+	}
+	for _, v := range rep {
+		if v.BitLen() != 1024 {
+			t.Errorf("representative has %d bits, want 1024", v.BitLen())
+		}
+	}
+}
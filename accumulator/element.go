@@ -0,0 +1,119 @@
+package accumulator
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// fieldChunkSize is the number of bytes of an element packed into one field element, 31 bytes always fit into fr
+const fieldChunkSize = 31
+
+// ErrInvalidElement is returned when an element or a record cannot be encoded
+var ErrInvalidElement = errors.New("invalid element")
+
+// Record is a typed value with a canonical encoding as an Element, e.g. a structured user record,
+// so that it can be accumulated directly
+type Record interface {
+	// Element returns the canonical encoding of the record, or an error if it cannot be encoded
+	Element() (Element, error)
+}
+
+// Element returns the element itself, so that an Element is also a Record
+func (e Element) Element() (Element, error) {
+	return e, nil
+}
+
+// StringElement returns the element with the bytes of s
+func StringElement(s string) Element {
+	return Element(s)
+}
+
+// StringElements returns the elements with the bytes of the strings in set
+func StringElements(set []string) []Element {
+	ret := make([]Element, len(set))
+	for i, v := range set {
+		ret[i] = Element(v)
+	}
+	return ret
+}
+
+// Uint64Element returns the 8-byte big-endian encoding of v, e.g. for a binary user ID
+func Uint64Element(v uint64) Element {
+	ret := make(Element, 8)
+	binary.BigEndian.PutUint64(ret, v)
+	return ret
+}
+
+// BigIntElement returns the big-endian encoding of a non-negative integer
+func BigIntElement(v *big.Int) (Element, error) {
+	if v == nil || v.Sign() < 0 {
+		return nil, fmt.Errorf("%w: only non-negative integers can be encoded", ErrInvalidElement)
+	}
+	return v.Bytes(), nil
+}
+
+// Fields is a record made of several fields, it is encoded as the concatenation of the length-prefixed fields,
+// so that different field lists never have the same encoding
+type Fields []Element
+
+// Element returns the canonical encoding of the fields
+func (f Fields) Element() (Element, error) {
+	size := 0
+	for _, v := range f {
+		size += 4 + len(v)
+	}
+	ret := make(Element, 0, size)
+	var buf [4]byte
+	for _, v := range f {
+		binary.BigEndian.PutUint32(buf[:], uint32(len(v)))
+		ret = append(ret, buf[:]...)
+		ret = append(ret, v...)
+	}
+	return ret, nil
+}
+
+// EncodeRecords returns the canonical encodings of the records, it stops at the first record which cannot be encoded
+func EncodeRecords(records []Record) ([]Element, error) {
+	ret := make([]Element, len(records))
+	for i, v := range records {
+		if v == nil {
+			return nil, fmt.Errorf("%w: record %d is nil", ErrInvalidElement, i)
+		}
+		e, err := v.Element()
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+		ret[i] = e
+	}
+	return ret, nil
+}
+
+// elementStrings returns the elements as strings, which hold arbitrary bytes
+func elementStrings(elements []Element) []string {
+	ret := make([]string, len(elements))
+	for i, v := range elements {
+		ret[i] = string(v)
+	}
+	return ret
+}
+
+// fieldElements packs the element into field elements: the length of the element followed by
+// its big-endian chunks of fieldChunkSize bytes
+func fieldElements(element Element) []*fr.Element {
+	ret := make([]*fr.Element, 0, 1+(len(element)+fieldChunkSize-1)/fieldChunkSize)
+	ret = append(ret, new(fr.Element).SetUint64(uint64(len(element))))
+	var chunk big.Int
+	for i := 0; i < len(element); i += fieldChunkSize {
+		end := i + fieldChunkSize
+		if end > len(element) {
+			end = len(element)
+		}
+		chunk.SetBytes(element[i:end])
+		ret = append(ret, new(fr.Element).SetBigInt(&chunk))
+	}
+	return ret
+}
@@ -0,0 +1,161 @@
+package accumulator
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+type testRecord struct {
+	id   uint64
+	name string
+}
+
+func (r testRecord) Element() (Element, error) {
+	if r.name == "" {
+		return nil, errors.New("empty name")
+	}
+	return Fields{Uint64Element(r.id), StringElement(r.name)}.Element()
+}
+
+func TestFields(t *testing.T) {
+	a, _ := Fields{Element("ab"), Element("c")}.Element()
+	b, _ := Fields{Element("a"), Element("bc")}.Element()
+	if bytes.Equal(a, b) {
+		t.Errorf("different fields have the same encoding")
+	}
+	if _, err := BigIntElement(big.NewInt(-1)); !errors.Is(err, ErrInvalidElement) {
+		t.Errorf("BigIntElement should return ErrInvalidElement, got %v", err)
+	}
+	if e, err := BigIntElement(big.NewInt(258)); err != nil || !bytes.Equal(e, []byte{1, 2}) {
+		t.Errorf("BigIntElement(258) = %v, %v", e, err)
+	}
+}
+
+func TestEncodeElements(t *testing.T) {
+	setup := TrustedSetup()
+	long := bytes.Repeat([]byte{0xff}, 1000)
+	set := []Element{Uint64Element(1), Uint64Element(1 << 40), Element("a"), Element("\x00a"), Element{}, long, append(long, 0)}
+	for _, encodeType := range []EncodeType{HashToPrimeFromSha256, DIHashFromPoseidonBytes} {
+		rep, err := setup.EncodeElements(set, encodeType)
+		if err != nil {
+			t.Fatalf("EncodeElements returns error: %v", err)
+		}
+		for i := range rep {
+			for j := 0; j < i; j++ {
+				if rep[i].Cmp(rep[j]) == 0 {
+					t.Errorf("elements %d and %d have the same representative with %s", i, j, encodeType)
+				}
+			}
+		}
+		strRep, err := setup.GenRepresentatives([]string{"a"}, encodeType)
+		if err != nil || strRep[0].Cmp(rep[2]) != 0 {
+			t.Errorf("a string and the element of its bytes have different representatives with %s", encodeType)
+		}
+	}
+}
+
+func TestAccumulateRecords(t *testing.T) {
+	setup := TrustedSetup()
+	records := []Record{testRecord{1, "alice"}, testRecord{2, "bob"}, Uint64Element(3)}
+	acc, err := NewAccumulatorFromRecords(setup, DIHashFromPoseidonBytes, records)
+	if err != nil {
+		t.Fatalf("NewAccumulatorFromRecords returns error: %v", err)
+	}
+	if !acc.ContainsElement(Uint64Element(3)) || acc.Size() != 3 {
+		t.Errorf("records are not accumulated")
+	}
+	witnesses := acc.ProveMembership()
+	for i, v := range records {
+		if !VerifyRecordMembership(setup, acc.Value(), v, DIHashFromPoseidonBytes, witnesses[i]) {
+			t.Errorf("membership proof of record %d does not pass verification", i)
+		}
+	}
+	if VerifyRecordMembership(setup, acc.Value(), testRecord{1, "bob"}, DIHashFromPoseidonBytes, witnesses[0]) {
+		t.Errorf("membership proof should not pass verification for another record")
+	}
+
+	value := acc.Value()
+	if err = acc.AddRecords(testRecord{4, "carol"}, testRecord{5, ""}); err == nil {
+		t.Errorf("AddRecords should return the error of the record")
+	}
+	if acc.Size() != 3 || acc.Value().Cmp(value) != 0 {
+		t.Errorf("accumulator is changed by an invalid record")
+	}
+	if err = acc.DeleteElements(Uint64Element(3)); err != nil || acc.ContainsElement(Uint64Element(3)) {
+		t.Errorf("DeleteElements does not delete the element: %v", err)
+	}
+}
+
+func TestElementProofs(t *testing.T) {
+	setup := TrustedSetup()
+	encodeType := EncodeType(HashToPrimeFromSha256)
+	set := []Element{{0xff, 0xfe}, {0x80}, Uint64Element(7), {}}
+	absent := []Element{{0xff}, {0xc3, 0x28}}
+	acc, proofs := AccAndProveElements(set, encodeType, setup)
+	if !BatchVerifyElementMembership(setup, acc, set, encodeType, proofs) {
+		t.Errorf("membership proofs of the elements do not pass verification")
+	}
+	if VerifyElementMembership(setup, acc, absent[0], encodeType, proofs[0]) {
+		t.Errorf("membership proof should not pass verification for another element")
+	}
+	batch, err := ProveBatchMembership(setup.N, acc, mustEncode(t, setup, set[:2], encodeType), proofs[:2])
+	if err != nil {
+		t.Fatalf("ProveBatchMembership returns error: %v", err)
+	}
+	if !VerifyBatchElementMembership(setup, acc, set[:2], encodeType, batch) {
+		t.Errorf("batch membership proof of the elements does not pass verification")
+	}
+
+	nonMembership, err := ProveElementNonMembership(setup, set, absent[0], encodeType)
+	if err != nil {
+		t.Fatalf("ProveElementNonMembership returns error: %v", err)
+	}
+	if !VerifyElementNonMembership(setup, acc, absent[0], encodeType, nonMembership) {
+		t.Errorf("non-membership proof of the element does not pass verification")
+	}
+	batchNonMembership, err := ProveBatchElementNonMembership(setup, set, absent, encodeType)
+	if err != nil {
+		t.Fatalf("ProveBatchElementNonMembership returns error: %v", err)
+	}
+	if !VerifyBatchElementNonMembership(setup, acc, absent, encodeType, batchNonMembership) {
+		t.Errorf("batch non-membership proof of the elements does not pass verification")
+	}
+
+	stateful, err := NewAccumulatorFromElements(setup, encodeType, set)
+	if err != nil {
+		t.Fatalf("NewAccumulatorFromElements returns error: %v", err)
+	}
+	if stateful.Value().Cmp(acc) != 0 {
+		t.Errorf("stateful accumulator has a different value")
+	}
+	if _, err = stateful.ProveElementNonMembership(set[0]); !errors.Is(err, ErrElementExists) {
+		t.Errorf("ProveElementNonMembership should return ErrElementExists, got %v", err)
+	}
+	if _, err = stateful.ProveBatchElementNonMembership(absent...); err != nil {
+		t.Errorf("ProveBatchElementNonMembership returns error: %v", err)
+	}
+	if !bytes.Equal(stateful.AccumulatedElements()[0], set[0]) {
+		t.Errorf("accumulated element is changed")
+	}
+
+	m, err := NewMultisetFromElements(setup, encodeType, append(set, set[0]))
+	if err != nil {
+		t.Fatalf("NewMultisetFromElements returns error: %v", err)
+	}
+	if m.Size() != len(set) || m.Multiplicity(string(set[0])) != 2 {
+		t.Errorf("multiset has size %d and multiplicity %d", m.Size(), m.Multiplicity(string(set[0])))
+	}
+	if !bytes.Equal(m.AccumulatedElements()[0], set[0]) {
+		t.Errorf("element of the multiset is changed")
+	}
+}
+
+func mustEncode(t *testing.T, setup *Setup, set []Element, encodeType EncodeType) []*big.Int {
+	rep, err := setup.EncodeElements(set, encodeType)
+	if err != nil {
+		t.Fatalf("EncodeElements returns error: %v", err)
+	}
+	return rep
+}
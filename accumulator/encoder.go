@@ -0,0 +1,218 @@
+package accumulator
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	// ErrUnknownEncoder is returned when using an encode type or an encoder name which is not registered
+	ErrUnknownEncoder = errors.New("unknown encoder")
+	// ErrEncoderExists is returned when registering an encoder with a name which is already registered
+	ErrEncoderExists = errors.New("encoder already registered")
+	// ErrEncoderMismatch is returned when using an encode type different from the one recorded in the setup
+	ErrEncoderMismatch = errors.New("encode type does not match the encoder of the setup")
+)
+
+// Encoder maps an element to its representative in the accumulator
+type Encoder interface {
+	// Encode returns the representative of element, params is the parameter set of the setup.
+	// It returns an error wrapping ErrInvalidElement if the element cannot be encoded.
+	Encode(element Element, params *ParameterSet) (*big.Int, error)
+}
+
+// EncoderFunc is an adapter to use a function as an Encoder
+type EncoderFunc func(element Element, params *ParameterSet) (*big.Int, error)
+
+// Encode calls f(element, params)
+func (f EncoderFunc) Encode(element Element, params *ParameterSet) (*big.Int, error) {
+	return f(element, params)
+}
+
+type encoderEntry struct {
+	name    string
+	encoder Encoder
+}
+
+var (
+	encodersMu     sync.RWMutex
+	encoders       = make(map[EncodeType]encoderEntry)
+	encodeTypes    = make(map[string]EncodeType)
+	nextEncodeType EncodeType
+)
+
+func init() {
+	builtin := []struct {
+		encodeType EncodeType
+		name       string
+		encoder    EncoderFunc
+	}{
+		{HashToPrimeFromSha256, "sha256", hashToPrimeEncoder(sha256.New)},
+		{DIHashFromPoseidon, "poseidon-di", encodeDIHashFromPoseidon},
+		{HashToPrimeWithNonceFromSha256, "sha256-nonce", encodeHashToPrimeWithNonce},
+		{CertifiedHashToPrimeFromSha256, "sha256-certified", encodeCertifiedHashToPrime},
+		{HashToPrimeFromSha3256, "sha3-256", hashToPrimeEncoder(sha3.New256)},
+		{HashToPrimeFromKeccak256, "keccak256", hashToPrimeEncoder(sha3.NewLegacyKeccak256)},
+		{HashToPrimeFromBlake2b, "blake2b", hashToPrimeEncoder(newBlake2b256)},
+		{DIHashFromPoseidonBytes, "poseidon-di-bytes", encodeDIHashFromPoseidonBytes},
+	}
+	for _, v := range builtin {
+		encoders[v.encodeType] = encoderEntry{name: v.name, encoder: v.encoder}
+		encodeTypes[v.name] = v.encodeType
+		if v.encodeType >= nextEncodeType {
+			nextEncodeType = v.encodeType + 1
+		}
+	}
+}
+
+// RegisterEncoder registers the encoder with a unique name, and returns the new encode type of the encoder,
+// which can be used everywhere an EncodeType is expected
+func RegisterEncoder(name string, encoder Encoder) (EncodeType, error) {
+	if name == "" || encoder == nil {
+		return 0, errors.New("encoder must have a name")
+	}
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	if _, ok := encodeTypes[name]; ok {
+		return 0, fmt.Errorf("%w: %q", ErrEncoderExists, name)
+	}
+	ret := nextEncodeType
+	nextEncodeType++
+	encoders[ret] = encoderEntry{name: name, encoder: encoder}
+	encodeTypes[name] = ret
+	return ret, nil
+}
+
+// EncodeTypeByName returns the encode type of the encoder registered with name
+func EncodeTypeByName(name string) (EncodeType, error) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	ret, ok := encodeTypes[name]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownEncoder, name)
+	}
+	return ret, nil
+}
+
+// EncoderNames returns the names of all the registered encoders in alphabetical order
+func EncoderNames() []string {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	ret := make([]string, 0, len(encodeTypes))
+	for name := range encodeTypes {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Encoder returns the encoder registered for the encode type
+func (t EncodeType) Encoder() (Encoder, error) {
+	entry, err := t.lookup()
+	if err != nil {
+		return nil, err
+	}
+	return entry.encoder, nil
+}
+
+// Name returns the name of the encoder registered for the encode type
+func (t EncodeType) Name() (string, error) {
+	entry, err := t.lookup()
+	if err != nil {
+		return "", err
+	}
+	return entry.name, nil
+}
+
+// String returns the name of the encode type, or EncodeType(n) if it is not registered
+func (t EncodeType) String() string {
+	name, err := t.Name()
+	if err != nil {
+		return fmt.Sprintf("EncodeType(%d)", int(t))
+	}
+	return name
+}
+
+func (t EncodeType) lookup() (encoderEntry, error) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	entry, ok := encoders[t]
+	if !ok {
+		return encoderEntry{}, fmt.Errorf("%w: %d", ErrUnknownEncoder, int(t))
+	}
+	return entry, nil
+}
+
+// hashToPrimeEncoder returns the encoder hashing the element with newHash repeatedly until it hits a prime
+func hashToPrimeEncoder(newHash func() hash.Hash) EncoderFunc {
+	return func(element Element, _ *ParameterSet) (*big.Int, error) {
+		return hashToPrime(newHash(), element), nil
+	}
+}
+
+func newBlake2b256() hash.Hash {
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		// only happens with a key longer than 64 bytes
+		panic(err)
+	}
+	return h
+}
+
+// encodeDIHashFromPoseidon parses the element as a decimal integer, and returns the DI hash of it
+func encodeDIHashFromPoseidon(element Element, params *ParameterSet) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(string(element), 10)
+	if !ok {
+		return nil, fmt.Errorf("%w: poseidon-di encodes decimal integers only, got %q", ErrInvalidElement, element)
+	}
+	var e fr.Element
+	e.SetBigInt(n)
+	return params.DIHashPoseidon(&e), nil
+}
+
+// encodeDIHashFromPoseidonBytes packs the element of arbitrary bytes into field elements, and returns their DI hash
+func encodeDIHashFromPoseidonBytes(element Element, params *ParameterSet) (*big.Int, error) {
+	return params.DIHashPoseidon(fieldElements(element)...), nil
+}
+
+func encodeHashToPrimeWithNonce(element Element, _ *ParameterSet) (*big.Int, error) {
+	ret, _ := HashToPrimeWithNonce(element)
+	return ret, nil
+}
+
+func encodeCertifiedHashToPrime(element Element, _ *ParameterSet) (*big.Int, error) {
+	ret, _ := HashToCertifiedPrime(element)
+	return ret, nil
+}
+
+// CheckEncodeType returns ErrEncoderMismatch if the setup records an encoder other than the one of encodeType
+func (setup *Setup) CheckEncodeType(encodeType EncodeType) error {
+	if setup.Encoder == "" {
+		return nil
+	}
+	name, err := encodeType.Name()
+	if err != nil {
+		return err
+	}
+	if name != setup.Encoder {
+		return fmt.Errorf("%w: %s, want %s", ErrEncoderMismatch, name, setup.Encoder)
+	}
+	return nil
+}
+
+// EncodeType returns the encode type of the encoder recorded in the setup
+func (setup *Setup) EncodeType() (EncodeType, error) {
+	if setup.Encoder == "" {
+		return 0, fmt.Errorf("%w: the setup does not record an encoder", ErrUnknownEncoder)
+	}
+	return EncodeTypeByName(setup.Encoder)
+}
@@ -0,0 +1,226 @@
+package accumulator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestBuiltinEncoders(t *testing.T) {
+	set := GenBenchSet(4)
+	for _, name := range []string{"sha256", "sha3-256", "keccak256", "blake2b", "poseidon-di", "poseidon-di-bytes", "sha256-nonce", "sha256-certified"} {
+		encodeType, err := EncodeTypeByName(name)
+		if err != nil {
+			t.Fatalf("EncodeTypeByName(%q) returns error: %v", name, err)
+		}
+		if encodeType.String() != name {
+			t.Errorf("String() = %s, want %s", encodeType.String(), name)
+		}
+		rep := GenRepresentatives(set, encodeType)
+		for i := range rep {
+			if encodeType != DIHashFromPoseidon && encodeType != DIHashFromPoseidonBytes && !rep[i].ProbablyPrime(securityParaHashToPrime) {
+				t.Errorf("representative of %s is not a prime", name)
+			}
+			for j := 0; j < i; j++ {
+				if rep[i].Cmp(rep[j]) == 0 {
+					t.Errorf("different elements have the same representative with %s", name)
+				}
+			}
+		}
+	}
+	if GenRepresentatives(set[:1], HashToPrimeFromSha256)[0].Cmp(HashToPrime([]byte(set[0]))) != 0 {
+		t.Errorf("sha256 encoder is not consistent with HashToPrime")
+	}
+	if len(EncoderNames()) < 8 {
+		t.Errorf("EncoderNames() = %v, missing built-in encoders", EncoderNames())
+	}
+}
+
+func TestDIHashFromPoseidonRegression(t *testing.T) {
+	// the representative of "12345" with the DI hash of the original implementation
+	want, _ := new(big.Int).SetString("89884656743115795386465259539451236680898848947115328636715040578866337902750481566354238661203768010560056939935696678829394884407208311246423715319737062188883946712432742638151109800623047059726541476042502884419075341171231440749207162390961257531390218890226209927846111198926221239644829981344338237572", 10)
+	rep := GenRepresentatives([]string{"12345"}, DIHashFromPoseidon)
+	if rep[0].Cmp(want) != 0 {
+		t.Errorf("poseidon-di representative of 12345 = %s, want %s", rep[0], want)
+	}
+	bytesRep := GenRepresentatives([]string{"12345"}, DIHashFromPoseidonBytes)
+	if bytesRep[0].Cmp(want) == 0 {
+		t.Errorf("poseidon-di-bytes should encode the bytes of the element")
+	}
+	if _, err := Params2048.GenRepresentatives([]string{"a"}, DIHashFromPoseidon); !errors.Is(err, ErrInvalidElement) {
+		t.Errorf("poseidon-di should return ErrInvalidElement for non-decimal elements, got %v", err)
+	}
+}
+
+func TestUnknownEncoder(t *testing.T) {
+	setup := TrustedSetup()
+	unknown := EncodeType(-1)
+	if _, err := setup.GenRepresentatives([]string{"1"}, unknown); !errors.Is(err, ErrUnknownEncoder) {
+		t.Errorf("GenRepresentatives should return ErrUnknownEncoder, got %v", err)
+	}
+	if _, err := EncodeTypeByName("md5"); !errors.Is(err, ErrUnknownEncoder) {
+		t.Errorf("EncodeTypeByName should return ErrUnknownEncoder, got %v", err)
+	}
+	if err := NewAccumulator(setup, unknown).Add("1"); !errors.Is(err, ErrUnknownEncoder) {
+		t.Errorf("Add should return ErrUnknownEncoder, got %v", err)
+	}
+	if VerifyMembership(setup, setup.G, "1", unknown, setup.G) {
+		t.Errorf("VerifyMembership should fail with an unknown encoder")
+	}
+	if _, err := setup.EncodeRecords([]Record{Element("1"), nil}, HashToPrimeFromSha256); !errors.Is(err, ErrInvalidElement) {
+		t.Errorf("EncodeRecords should return ErrInvalidElement, got %v", err)
+	}
+	ctx := context.Background()
+	for _, set := range [][]string{nil, {"1", "2"}} {
+		if _, _, err := AccAndProveContext(ctx, set, unknown, setup, nil); !errors.Is(err, ErrUnknownEncoder) {
+			t.Errorf("AccAndProveContext of %d elements should return ErrUnknownEncoder, got %v", len(set), err)
+		}
+		if _, _, err := AccAndProveParallelContext(ctx, set, unknown, setup, 2, nil); !errors.Is(err, ErrUnknownEncoder) {
+			t.Errorf("AccAndProveParallelContext of %d elements should return ErrUnknownEncoder, got %v", len(set), err)
+		}
+		if _, _, err := ZKAccumulateContext(ctx, set, unknown, setup, nil); !errors.Is(err, ErrUnknownEncoder) {
+			t.Errorf("ZKAccumulateContext of %d elements should return ErrUnknownEncoder, got %v", len(set), err)
+		}
+	}
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, ErrUnknownEncoder) {
+			t.Errorf("AccAndProve should panic with ErrUnknownEncoder, got %v", err)
+		}
+	}()
+	AccAndProve([]string{"1"}, unknown, setup)
+}
+
+func TestZKAccumulateContext(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(10)
+	acc, proofs, err := ZKAccumulateContext(context.Background(), set, HashToPrimeFromSha256, setup, nil)
+	if err != nil {
+		t.Fatalf("ZKAccumulateContext returns error: %v", err)
+	}
+	rep := GenRepresentatives(set, HashToPrimeFromSha256)
+	for i := range rep {
+		if !VerifyMembershipWithRep(setup.N, acc, rep[i], proofs[i]) {
+			t.Errorf("membership proof %d does not pass verification", i)
+		}
+	}
+	if acc.Cmp(AccumulateNew(setup.G, SetProductRecursiveFast(rep), setup.N)) == 0 {
+		t.Errorf("the accumulator is not randomized")
+	}
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	reversed := EncoderFunc(func(element Element, _ *ParameterSet) (*big.Int, error) {
+		b := append([]byte(nil), element...)
+		for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+			b[i], b[j] = b[j], b[i]
+		}
+		return HashToPrime(b), nil
+	})
+	encodeType, err := RegisterEncoder("test-reversed-sha256", reversed)
+	if errors.Is(err, ErrEncoderExists) {
+		// the test runs more than once
+		encodeType, err = EncodeTypeByName("test-reversed-sha256")
+	}
+	if err != nil {
+		t.Fatalf("RegisterEncoder returns error: %v", err)
+	}
+	if _, err = RegisterEncoder("sha256", reversed); !errors.Is(err, ErrEncoderExists) {
+		t.Errorf("RegisterEncoder should return ErrEncoderExists, got %v", err)
+	}
+	setup := TrustedSetup()
+	setup.Encoder = "test-reversed-sha256"
+	set := GenBenchSet(20)
+	acc, err := NewAccumulatorFromSet(setup, encodeType, set)
+	if err != nil {
+		t.Fatalf("NewAccumulatorFromSet returns error: %v", err)
+	}
+	if rep, _ := acc.Representative("12"); rep.Cmp(HashToPrime([]byte("21"))) != 0 {
+		t.Errorf("the registered encoder is not used")
+	}
+	witnesses := acc.ProveMembership()
+	if !VerifyMembership(setup, acc.Value(), set[3], encodeType, witnesses[3]) {
+		t.Errorf("valid membership proof does not pass verification")
+	}
+	if VerifyMembership(setup, acc.Value(), set[3], HashToPrimeFromSha256, witnesses[3]) {
+		t.Errorf("verification should fail with an encoder other than the one of the setup")
+	}
+	if _, err = NewAccumulatorFromSet(setup, HashToPrimeFromSha256, set); !errors.Is(err, ErrEncoderMismatch) {
+		t.Errorf("NewAccumulatorFromSet should return ErrEncoderMismatch, got %v", err)
+	}
+}
+
+func TestEncoderSerialization(t *testing.T) {
+	setup, _, err := GenerateSetupWithParameters(ParamsToy512)
+	if err != nil {
+		t.Fatalf("GenerateSetupWithParameters returns error: %v", err)
+	}
+	setup.Encoder = "keccak256"
+	for _, encode := range []func(*Setup) ([]byte, error){
+		func(s *Setup) ([]byte, error) { return json.Marshal(s) },
+		func(s *Setup) ([]byte, error) { return s.MarshalBinary() },
+	} {
+		data, err := encode(setup)
+		if err != nil {
+			t.Fatalf("encoding returns error: %v", err)
+		}
+		decoded := new(Setup)
+		if err = unmarshalByContent(data, decoded); err != nil {
+			t.Fatalf("decoding returns error: %v", err)
+		}
+		if encodeType, err := decoded.EncodeType(); err != nil || encodeType != HashToPrimeFromKeccak256 {
+			t.Errorf("decoded setup records %s, want keccak256", decoded.Encoder)
+		}
+	}
+	// the encoder is covered by the fingerprint
+	data, err := json.Marshal(setup)
+	if err != nil {
+		t.Fatalf("MarshalJSON returns error: %v", err)
+	}
+	tampered := bytes.Replace(data, []byte(`"keccak256"`), []byte(`"sha3-256"`), 1)
+	if err = json.Unmarshal(tampered, new(Setup)); err != ErrFingerprintMismatch {
+		t.Errorf("UnmarshalJSON should return ErrFingerprintMismatch, got %v", err)
+	}
+
+	// files of format version 1 can still be loaded
+	setup.Encoder = ""
+	var buf bytes.Buffer
+	buf.WriteString(setupMagic)
+	writeVersion(&buf, 1)
+	for _, v := range []*big.Int{setup.N, setup.G, setup.H} {
+		writeLengthPrefixed(&buf, v.Bytes())
+	}
+	buf.Write(setup.fingerprint(1))
+	var decoded Setup
+	if err = decoded.UnmarshalBinary(buf.Bytes()); err != nil {
+		t.Fatalf("UnmarshalBinary of version 1 returns error: %v", err)
+	}
+	if decoded.N.Cmp(setup.N) != 0 || decoded.Encoder != "" {
+		t.Errorf("version 1 setup is not decoded correctly")
+	}
+
+	acc, err := NewAccumulatorFromSet(setup, HashToPrimeFromBlake2b, GenBenchSet(8))
+	if err != nil {
+		t.Fatalf("NewAccumulatorFromSet returns error: %v", err)
+	}
+	data, err = json.Marshal(acc)
+	if err != nil {
+		t.Fatalf("MarshalJSON returns error: %v", err)
+	}
+	decodedAcc := new(Accumulator)
+	if err = json.Unmarshal(data, decodedAcc); err != nil {
+		t.Fatalf("UnmarshalJSON returns error: %v", err)
+	}
+	if decodedAcc.EncodeType() != HashToPrimeFromBlake2b || decodedAcc.Value().Cmp(acc.Value()) != 0 {
+		t.Errorf("decoded accumulator is not the encoded one")
+	}
+	if decodedAcc.Setup().Parameters() != ParamsToy512 {
+		t.Errorf("decoded accumulator uses %s, want %s", decodedAcc.Setup().Parameters(), ParamsToy512)
+	}
+	tampered = bytes.Replace(data, []byte(`"blake2b"`), []byte(`"sha256"`), 1)
+	if err = json.Unmarshal(tampered, decodedAcc); err == nil {
+		t.Errorf("UnmarshalJSON should fail if the encoder does not match the value")
+	}
+}
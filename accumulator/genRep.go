@@ -1,26 +1,157 @@
 package accumulator
 
 import (
+	"context"
 	"math/big"
-
-	"github.com/consensys/gnark-crypto/ecc/bn254/fr/poseidon"
+	"runtime"
+	"sync"
 )
 
-func genRepWithHashToPrimeFromSHA256(set []string) []*big.Int {
+// genRepresentatives encodes every element of the set with encoder
+func genRepresentatives(encoder Encoder, set []Element, params *ParameterSet) ([]*big.Int, error) {
 	ret := make([]*big.Int, len(set))
 	for i, v := range set {
-		ret[i] = HashToPrime([]byte(v))
+		rep, err := encoder.Encode(v, params)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = rep
 	}
-	return ret
+	return ret, nil
 }
 
-func genRepWithDIHashFromPoseidon(set []string) []*big.Int {
-	ret := make([]*big.Int, len(set))
-	for i := range set {
-		ret[i] = new(big.Int)
-		temp := poseidon.Poseidon(ElementFromString(set[i]))
-		temp.ToBigIntRegular(ret[i])
-		ret[i].Add(ret[i], Min1024)
+type repJob struct {
+	index   int
+	element Element
+}
+
+type repResult struct {
+	index int
+	rep   *big.Int
+	err   error
+}
+
+// genRepresentativesParallel encodes the elements received from the channel with numWorkers Goroutines,
+// and returns the representatives in the order the elements are received. sizeHint is the expected number
+// of elements. It stops at the first error or when ctx is done.
+func genRepresentativesParallel(ctx context.Context, encoder Encoder, elements <-chan Element, params *ParameterSet,
+	numWorkers, sizeHint int) ([]*big.Int, error) {
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan repJob, numWorkers)
+	results := make(chan repResult, numWorkers)
+	go func() {
+		defer close(jobs)
+		for index := 0; ; index++ {
+			select {
+			case v, ok := <-elements:
+				if !ok {
+					return
+				}
+				select {
+				case jobs <- repJob{index: index, element: v}:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				rep, err := encoder.Encode(job.element, params)
+				select {
+				case results <- repResult{index: job.index, rep: rep, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	ret := make([]*big.Int, 0, sizeHint)
+	for res := range results {
+		if res.err != nil {
+			return nil, res.err
+		}
+		for len(ret) <= res.index {
+			ret = append(ret, nil)
+		}
+		ret[res.index] = res.rep
+	}
+	// the elements may be cut short if ctx is done
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
+	return ret, nil
+}
+
+// GenRepresentativesParallel generates the representatives of set with numWorkers Goroutines, in the same
+// order as set. numWorkers <= 0 means runtime.NumCPU(). It returns ctx.Err() if ctx is done before all
+// the representatives are generated.
+func (setup *Setup) GenRepresentativesParallel(ctx context.Context, set []string, encodeType EncodeType,
+	numWorkers int) ([]*big.Int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	elements := sendElements(ctx, len(set), func(i int) Element { return Element(set[i]) })
+	return setup.encodeElementsFromChan(ctx, elements, encodeType, numWorkers, len(set))
+}
+
+// EncodeElementsParallel is GenRepresentativesParallel for elements of arbitrary bytes
+func (setup *Setup) EncodeElementsParallel(ctx context.Context, set []Element, encodeType EncodeType,
+	numWorkers int) ([]*big.Int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	elements := sendElements(ctx, len(set), func(i int) Element { return set[i] })
+	return setup.encodeElementsFromChan(ctx, elements, encodeType, numWorkers, len(set))
+}
+
+// sendElements sends element(0), ..., element(size-1) to the returned channel until ctx is done
+func sendElements(ctx context.Context, size int, element func(int) Element) <-chan Element {
+	ret := make(chan Element)
+	go func() {
+		defer close(ret)
+		for i := 0; i < size; i++ {
+			select {
+			case ret <- element(i):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
 	return ret
 }
+
+// GenRepresentativesFromChan generates the representatives of the elements received from the channel with
+// numWorkers Goroutines, in the order they are received, until the channel is closed. The elements are
+// never held in memory together, which suits sets too large to be kept in memory.
+// The sender should stop sending once ctx is done, as the elements are no longer received after an error.
+func (setup *Setup) GenRepresentativesFromChan(ctx context.Context, elements <-chan Element, encodeType EncodeType,
+	numWorkers int) ([]*big.Int, error) {
+	return setup.encodeElementsFromChan(ctx, elements, encodeType, numWorkers, 0)
+}
+
+func (setup *Setup) encodeElementsFromChan(ctx context.Context, elements <-chan Element, encodeType EncodeType,
+	numWorkers, sizeHint int) ([]*big.Int, error) {
+	if err := setup.CheckEncodeType(encodeType); err != nil {
+		return nil, err
+	}
+	encoder, err := encodeType.Encoder()
+	if err != nil {
+		return nil, err
+	}
+	return genRepresentativesParallel(ctx, encoder, elements, setup.Parameters(), numWorkers, sizeHint)
+}
@@ -0,0 +1,87 @@
+package accumulator
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestGenRepresentativesParallel(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(64)
+	for _, encodeType := range []EncodeType{HashToPrimeFromSha256, DIHashFromPoseidon} {
+		want := GenRepresentatives(set, encodeType)
+		for _, numWorkers := range []int{0, 1, 3, 100} {
+			rep, err := setup.GenRepresentativesParallel(context.Background(), set, encodeType, numWorkers)
+			if err != nil {
+				t.Fatalf("GenRepresentativesParallel returns error: %v", err)
+			}
+			if len(rep) != len(want) {
+				t.Fatalf("GenRepresentativesParallel returns %d representatives, want %d", len(rep), len(want))
+			}
+			for i := range rep {
+				if rep[i].Cmp(want[i]) != 0 {
+					t.Errorf("representative %d with %d workers is not in the order of the set", i, numWorkers)
+				}
+			}
+		}
+	}
+
+	rep, err := setup.GenRepresentativesParallel(context.Background(), nil, HashToPrimeFromSha256, 2)
+	if err != nil || len(rep) != 0 {
+		t.Errorf("GenRepresentativesParallel of an empty set = %v, %v", rep, err)
+	}
+	failing, err := RegisterEncoder("test-failing", EncoderFunc(func(element Element, _ *ParameterSet) (*big.Int, error) {
+		if string(element) == "x" {
+			return nil, ErrInvalidElement
+		}
+		return HashToPrime(element), nil
+	}))
+	if errors.Is(err, ErrEncoderExists) {
+		// the test runs more than once
+		failing, err = EncodeTypeByName("test-failing")
+	}
+	if err != nil {
+		t.Fatalf("RegisterEncoder returns error: %v", err)
+	}
+	_, err = setup.GenRepresentativesParallel(context.Background(), []string{"1", "x", "3"}, failing, 2)
+	if !errors.Is(err, ErrInvalidElement) {
+		t.Errorf("GenRepresentativesParallel should return the error of the encoder, got %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = setup.GenRepresentativesParallel(ctx, set, HashToPrimeFromSha256, 2); !errors.Is(err, context.Canceled) {
+		t.Errorf("GenRepresentativesParallel should return context.Canceled, got %v", err)
+	}
+}
+
+func TestGenRepresentativesFromChan(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(50)
+	elements := make(chan Element)
+	go func() {
+		defer close(elements)
+		for _, v := range set {
+			elements <- Element(v)
+		}
+	}()
+	rep, err := setup.GenRepresentativesFromChan(context.Background(), elements, HashToPrimeFromSha256, 4)
+	if err != nil {
+		t.Fatalf("GenRepresentativesFromChan returns error: %v", err)
+	}
+	want := GenRepresentatives(set, HashToPrimeFromSha256)
+	if len(rep) != len(want) {
+		t.Fatalf("GenRepresentativesFromChan returns %d representatives, want %d", len(rep), len(want))
+	}
+	for i := range rep {
+		if rep[i].Cmp(want[i]) != 0 {
+			t.Errorf("representative %d is not in the order of the channel", i)
+		}
+	}
+
+	setup.Encoder = "sha256"
+	if _, err = setup.GenRepresentativesFromChan(context.Background(), nil, DIHashFromPoseidon, 4); !errors.Is(err, ErrEncoderMismatch) {
+		t.Errorf("GenRepresentativesFromChan should return ErrEncoderMismatch, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package accumulator
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/jiajunxin/rsa_accumulator/group"
+)
+
+// Group returns the RSA group of the setup, so that the setup can be used with the group-generic functions
+func (setup *Setup) Group() *group.RSAGroup {
+	return group.NewRSAGroup(setup.N)
+}
+
+// intElements returns the integers as elements of the RSA group
+func intElements(xs []*big.Int) []group.Element {
+	ret := make([]group.Element, len(xs))
+	for i, v := range xs {
+		ret[i] = v
+	}
+	return ret
+}
+
+// elementInts returns the elements of the RSA group as integers, nil for nil
+func elementInts(elements []group.Element) []*big.Int {
+	if elements == nil {
+		return nil
+	}
+	ret := make([]*big.Int, len(elements))
+	for i, v := range elements {
+		ret[i] = v.(*big.Int)
+	}
+	return ret
+}
+
+// GroupAccumulate calculates base^{product of set} in grp
+func GroupAccumulate(grp group.Group, base group.Element, set []*big.Int) group.Element {
+	return grp.Exp(base, SetProductRecursiveFast(set))
+}
+
+// GroupProveMembership uses divide-and-conquer method to pre-compute all the membership proofs in grp in time O(nlog(n)).
+// It works in any hidden order group, e.g. a class group, with the product tree of set as ProveMembership,
+// see ProductTree.GroupProveMembership.
+func GroupProveMembership(grp group.Group, base group.Element, set []*big.Int) []group.Element {
+	return NewProductTree(set).GroupProveMembership(grp, base)
+}
+
+// GroupVerifyMembership returns true if witness^x = acc in grp
+func GroupVerifyMembership(grp group.Group, acc group.Element, x *big.Int, witness group.Element) bool {
+	if witness == nil || x == nil {
+		return false
+	}
+	return grp.Equal(grp.Exp(witness, x), acc)
+}
+
+// GroupNonMembershipProof is the non-membership witness in a generic group, see NonMembershipProof
+type GroupNonMembershipProof struct {
+	A   *big.Int
+	D   group.Element
+	GCD *big.Int
+}
+
+// GroupProveNonMembership generates the non-membership proof of the representative x for the
+// accumulator base^{product of set} in grp
+func GroupProveNonMembership(grp group.Group, base group.Element, set []*big.Int, x *big.Int) (*GroupNonMembershipProof, error) {
+	var a, b big.Int
+	gcd := new(big.Int).GCD(&a, &b, SetProductRecursiveFast(set), x)
+	if gcd.Cmp(x) == 0 {
+		// x divides the product
+		return nil, errors.New("the element is accumulated, cannot prove non-membership")
+	}
+	return &GroupNonMembershipProof{
+		A:   &a,
+		D:   grp.Exp(base, &b),
+		GCD: gcd,
+	}, nil
+}
+
+// GroupVerifyNonMembership returns true if acc^A * D^x = base^GCD in grp and 0 < GCD < x.
+// A is usually negative, a non-invertible acc gives nil in the RSA group, which is not equal to any element.
+func GroupVerifyNonMembership(grp group.Group, base, acc group.Element, x *big.Int, proof *GroupNonMembershipProof) bool {
+	if proof == nil || proof.A == nil || proof.D == nil || proof.GCD == nil {
+		return false
+	}
+	if proof.GCD.Sign() <= 0 || proof.GCD.Cmp(x) >= 0 {
+		return false
+	}
+	lhs := grp.Mul(grp.Exp(acc, proof.A), grp.Exp(proof.D, x))
+	return grp.Equal(lhs, grp.Exp(base, proof.GCD))
+}
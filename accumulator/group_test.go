@@ -0,0 +1,147 @@
+package accumulator
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/jiajunxin/rsa_accumulator/group"
+)
+
+func TestGroupAccumulator(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(13)
+	rep := GenRepresentatives(set, HashToPrimeFromSha256)
+
+	// the generic functions in the RSA group are consistent with the RSA accumulator
+	rsaGroup := setup.Group()
+	acc, proofs := AccAndProve(set, HashToPrimeFromSha256, setup)
+	if !rsaGroup.Equal(GroupAccumulate(rsaGroup, setup.G, rep), acc) {
+		t.Errorf("GroupAccumulate is not consistent with AccAndProve")
+	}
+	groupProofs := GroupProveMembership(rsaGroup, setup.G, rep)
+	for i := range proofs {
+		if !rsaGroup.Equal(groupProofs[i], proofs[i]) {
+			t.Errorf("GroupProveMembership is not consistent with ProveMembership")
+		}
+	}
+
+	classGroup := group.NewClassGroupFromSeed([]byte("TestGroupAccumulator"), 256)
+	g := classGroup.Generator()
+	classAcc := GroupAccumulate(classGroup, g, rep[:12])
+	witnesses := GroupProveMembership(classGroup, g, rep[:12])
+	for i := range witnesses {
+		if !GroupVerifyMembership(classGroup, classAcc, rep[i], witnesses[i]) {
+			t.Errorf("valid membership proof %d does not pass verification in the class group", i)
+		}
+	}
+	if GroupVerifyMembership(classGroup, classAcc, rep[12], witnesses[0]) {
+		t.Errorf("membership proof of another element should not pass verification")
+	}
+	proof, err := GroupProveNonMembership(classGroup, g, rep[:12], rep[12])
+	if err != nil {
+		t.Fatalf("GroupProveNonMembership returns error: %v", err)
+	}
+	if !GroupVerifyNonMembership(classGroup, g, classAcc, rep[12], proof) {
+		t.Errorf("valid non-membership proof does not pass verification in the class group")
+	}
+	if _, err = GroupProveNonMembership(classGroup, g, rep[:12], rep[3]); err == nil {
+		t.Errorf("GroupProveNonMembership should fail for an accumulated element")
+	}
+}
+
+func TestGroupVerifyNonUnit(t *testing.T) {
+	setup := TrustedSetup()
+	grp := setup.Group()
+	rep := GenRepresentatives(GenBenchSet(3), HashToPrimeFromSha256)
+	proof, err := GroupProveNonMembership(grp, setup.G, rep[:2], rep[2])
+	if err != nil {
+		t.Fatalf("GroupProveNonMembership returns error: %v", err)
+	}
+	if proof.A.Sign() >= 0 {
+		proof.A.Neg(proof.A)
+	}
+	// 0 and N are not invertible, the verifiers must reject them instead of panicking
+	for _, acc := range []*big.Int{new(big.Int), new(big.Int).Set(setup.N)} {
+		if GroupVerifyNonMembership(grp, setup.G, acc, rep[2], proof) {
+			t.Errorf("non-membership proof for a non-unit accumulator should not pass verification")
+		}
+		if GroupVerifyMembership(grp, acc, new(big.Int).Neg(rep[2]), acc) {
+			t.Errorf("membership proof with a non-unit witness should not pass verification")
+		}
+	}
+	if GroupVerifyMembership(grp, setup.G, rep[2], (*big.Int)(nil)) {
+		t.Errorf("nil proof should not pass verification")
+	}
+}
+
+func TestClassGroupAccumulator(t *testing.T) {
+	classGroup := group.NewClassGroupFromSeed([]byte("TestClassGroupAccumulator"), 256)
+	g := classGroup.Generator()
+	set := StringElements(GenBenchSet(20))
+	acc, err := NewGroupAccumulatorFromElements(classGroup, g, nil, HashToPrimeFromSha256, set[:16])
+	if err != nil {
+		t.Fatalf("NewGroupAccumulatorFromElements returns error: %v", err)
+	}
+	if err = acc.AddElements(set[16:]...); err != nil {
+		t.Fatalf("AddElements returns error: %v", err)
+	}
+	if err = acc.DeleteElements(set[3], set[17]); err != nil {
+		t.Fatalf("DeleteElements returns error: %v", err)
+	}
+	rep := acc.Representatives()
+	if !classGroup.Equal(acc.GroupValue(), GroupAccumulate(classGroup, g, rep)) {
+		t.Errorf("accumulator value is not consistent with GroupAccumulate")
+	}
+	witnesses := acc.GroupProveMembership()
+	if len(witnesses) != acc.Size() {
+		t.Fatalf("GroupProveMembership returns %d proofs, want %d", len(witnesses), acc.Size())
+	}
+	for i := range witnesses {
+		if !GroupVerifyMembership(classGroup, acc.GroupValue(), rep[i], witnesses[i]) {
+			t.Errorf("valid membership proof %d does not pass verification in the class group", i)
+		}
+	}
+	if acc.Value() != nil || acc.ProveMembership() != nil {
+		t.Errorf("an accumulator in a class group has no RSA value or proofs")
+	}
+	if _, err = acc.ProveElementNonMembership(set[3]); !errors.Is(err, ErrNotRSASetup) {
+		t.Errorf("ProveElementNonMembership should return ErrNotRSASetup, got %v", err)
+	}
+
+	// the scheduler runs the same pre-computation within a memory budget on several workers
+	tree := NewProductTree(rep)
+	s := NewProofScheduler(2, 0)
+	s = NewProofScheduler(2, s.GroupMinMemoryBudget(tree, classGroup))
+	scheduled, err := s.GroupProveMembershipTreeContext(context.Background(), tree, classGroup, g, nil)
+	if err != nil {
+		t.Fatalf("GroupProveMembershipTreeContext returns error: %v", err)
+	}
+	for i := range witnesses {
+		if !classGroup.Equal(scheduled[i], witnesses[i]) {
+			t.Errorf("proof %d of the scheduler is different", i)
+		}
+	}
+}
+
+func TestRSAGroupAccumulator(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(10)
+	acc, err := NewAccumulatorFromSet(setup, HashToPrimeFromSha256, set)
+	if err != nil {
+		t.Fatalf("NewAccumulatorFromSet returns error: %v", err)
+	}
+	groupAcc, err := NewGroupAccumulatorFromElements(setup.Group(), setup.G, setup.Params, HashToPrimeFromSha256,
+		StringElements(set))
+	if err != nil {
+		t.Fatalf("NewGroupAccumulatorFromElements returns error: %v", err)
+	}
+	if groupAcc.Value().Cmp(acc.Value()) != 0 {
+		t.Errorf("accumulator in the RSA group of the setup has a different value")
+	}
+	checkSameProofs(t, groupAcc.ProveMembership(), acc.ProveMembership())
+	if _, err = groupAcc.MarshalJSON(); !errors.Is(err, ErrNotRSASetup) {
+		t.Errorf("MarshalJSON should return ErrNotRSASetup, got %v", err)
+	}
+}
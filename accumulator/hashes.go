@@ -2,12 +2,18 @@ package accumulator
 
 import (
 	"crypto/sha256"
+	"hash"
 	"math/big"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/poseidon"
+	fiatshamir "github.com/jiajunxin/rsa_accumulator/fiat-shamir"
 )
 
+// ElementDomain is the domain of HashToPrimeWithNonce, which separates the representatives of the elements
+// from the challenges of the transcripts
+const ElementDomain = "rsa_accumulator/accumulator/element"
+
 // ElementFromBigInt returns an element in BN256 generated from BigInt
 func ElementFromBigInt(v *big.Int) *fr.Element {
 	var e fr.Element
@@ -35,8 +41,13 @@ func ElementFromUint32(v uint32) *fr.Element {
 
 // HashToPrime takes the input into Sha256 and take the hash output to input repeatedly until we hit a prime number
 func HashToPrime(input []byte) *big.Int {
+	return hashToPrime(sha256.New(), input)
+}
+
+// hashToPrime takes the input into h and take the hash output to input repeatedly until we hit a prime number.
+// The candidates are sieved by small primes before the primality test, see probablyPrimeSieved.
+func hashToPrime(h hash.Hash, input []byte) *big.Int {
 	var ret big.Int
-	h := sha256.New()
 	_, err := h.Write(input)
 	if err != nil {
 		panic(err)
@@ -45,7 +56,7 @@ func HashToPrime(input []byte) *big.Int {
 	ret.SetBytes(hashTemp)
 	flag := false
 	for !flag {
-		flag = ret.ProbablyPrime(securityParaHashToPrime)
+		flag = probablyPrimeSieved(&ret)
 		if !flag {
 			h.Reset()
 			_, err := h.Write(hashTemp)
@@ -59,6 +70,19 @@ func HashToPrime(input []byte) *big.Int {
 	return &ret
 }
 
+// HashToPrimeWithNonce hashes the input together with a nonce = 0, 1, 2... in the domain ElementDomain until
+// it hits a prime number, and returns the prime together with the nonce. With the nonce, VerifyHashToPrime
+// checks the prime with one hash and one primality test instead of repeating the search.
+func HashToPrimeWithNonce(input []byte) (*big.Int, uint32) {
+	return fiatshamir.HashToPrimeWithNonce(ElementDomain, input, fiatshamir.Default)
+}
+
+// VerifyHashToPrime returns true if prime is the hash of input with nonce in the domain ElementDomain and it is a prime.
+// Any nonce leading to a prime is accepted, so it must not be used to check the representative of a non-member.
+func VerifyHashToPrime(input []byte, nonce uint32, prime *big.Int) bool {
+	return fiatshamir.VerifyHashToPrime(ElementDomain, input, nonce, fiatshamir.Default, prime)
+}
+
 // SHA256ToInt calculates the input with Sha256 and change it to big.Int
 func SHA256ToInt(input []byte) *big.Int {
 	var ret big.Int
@@ -96,20 +120,12 @@ func UniversalHashToInt(input *big.Int) *big.Int {
 	return &ret
 }
 
-// DIHashPoseidon generates DI hash with Poseidon hash
+// DIHashPoseidon generates DI hash with Poseidon hash, with the DI offset of Params2048
 func DIHashPoseidon(input ...*fr.Element) *big.Int {
-	ret := new(big.Int)
-	temp := poseidon.Poseidon(input...)
-	temp.ToBigIntRegular(ret)
-	ret.Add(ret, Min1024)
-	return ret
+	return Params2048.DIHashPoseidon(input...)
 }
 
-// PoseidonAndDIHash returns the Poseidon Hash result together with DI hash result
+// PoseidonAndDIHash returns the Poseidon Hash result together with DI hash result, with the DI offset of Params2048
 func PoseidonAndDIHash(input ...*fr.Element) (*fr.Element, *big.Int) {
-	ret := new(big.Int)
-	temp := poseidon.Poseidon(input...)
-	temp.ToBigIntRegular(ret)
-	ret.Add(ret, Min1024)
-	return temp, ret
+	return Params2048.PoseidonAndDIHash(input...)
 }
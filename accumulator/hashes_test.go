@@ -0,0 +1,31 @@
+package accumulator
+
+import "testing"
+
+func TestHashToPrimeWithNonce(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(8)
+	rep, err := setup.GenRepresentatives(set, HashToPrimeWithNonceFromSha256)
+	if err != nil {
+		t.Fatalf("GenRepresentatives returns error: %v", err)
+	}
+	acc, witnesses := AccAndProve(set, HashToPrimeWithNonceFromSha256, setup)
+	for i, v := range set {
+		prime, nonce := HashToPrimeWithNonce([]byte(v))
+		if prime.Cmp(rep[i]) != 0 {
+			t.Errorf("representative %d is not the output of HashToPrimeWithNonce", i)
+		}
+		if !VerifyHashToPrime([]byte(v), nonce, prime) {
+			t.Errorf("VerifyHashToPrime rejects the output of HashToPrimeWithNonce")
+		}
+		if !VerifyMembershipWithNonce(setup, acc, v, nonce, witnesses[i]) {
+			t.Errorf("VerifyMembershipWithNonce rejects valid membership proof %d", i)
+		}
+		if VerifyMembershipWithNonce(setup, acc, v, nonce+1, witnesses[i]) {
+			t.Errorf("VerifyMembershipWithNonce accepts a wrong nonce")
+		}
+		if !VerifyMembership(setup, acc, v, HashToPrimeWithNonceFromSha256, witnesses[i]) {
+			t.Errorf("VerifyMembership rejects valid membership proof %d", i)
+		}
+	}
+}
@@ -77,3 +77,78 @@ func CommonBits(a, b big.Word) big.Word {
 
 	return big.Word(ret)
 }
+
+// simultaneousExpWindow is the maximum window size in bits of SimultaneousExp
+const simultaneousExpWindow = 5
+
+// SimultaneousExp calculates prod(bases[i]^exps[i]) mod n with the Straus (Shamir's) trick,
+// all the exponentiations share one chain of squarings and each exponent is scanned with a sliding
+// window of at most simultaneousExpWindow bits. All exponents should be non-negative.
+func SimultaneousExp(bases, exps []*big.Int, n *big.Int) *big.Int {
+	if len(bases) != len(exps) {
+		panic("invalid input for function SimultaneousExp, unbalanced bases and exponents")
+	}
+	maxBitLen := 0
+	for _, v := range exps {
+		if v.Sign() < 0 {
+			panic("invalid input for function SimultaneousExp, negative exponent")
+		}
+		if v.BitLen() > maxBitLen {
+			maxBitLen = v.BitLen()
+		}
+	}
+	// schedule[pos] lists the multiplications to run after the squaring at bit position pos
+	type step struct {
+		base  int
+		digit uint
+	}
+	schedule := make([][]step, maxBitLen)
+	// oddPowers[i][j] = bases[i]^{2j+1} mod n
+	const numOddPowers = 1 << (simultaneousExpWindow - 1)
+	oddPowers := make([][numOddPowers]*big.Int, len(bases))
+	for i, v := range exps {
+		if v.Sign() == 0 {
+			continue
+		}
+		oddPowers[i][0] = new(big.Int).Mod(bases[i], n)
+		var square big.Int
+		square.Mul(oddPowers[i][0], oddPowers[i][0])
+		square.Mod(&square, n)
+		for j := 1; j < numOddPowers; j++ {
+			oddPowers[i][j] = new(big.Int).Mul(oddPowers[i][j-1], &square)
+			oddPowers[i][j].Mod(oddPowers[i][j], n)
+		}
+		for pos := v.BitLen() - 1; pos >= 0; {
+			if v.Bit(pos) == 0 {
+				pos--
+				continue
+			}
+			// the window is v[end..pos], it always ends with a 1 bit so the digit is odd
+			end := pos - simultaneousExpWindow + 1
+			if end < 0 {
+				end = 0
+			}
+			for v.Bit(end) == 0 {
+				end++
+			}
+			var digit uint
+			for k := pos; k >= end; k-- {
+				digit = digit<<1 | v.Bit(k)
+			}
+			schedule[end] = append(schedule[end], step{base: i, digit: digit})
+			pos = end - 1
+		}
+	}
+	ret := big.NewInt(1)
+	for pos := maxBitLen - 1; pos >= 0; pos-- {
+		if pos != maxBitLen-1 {
+			ret.Mul(ret, ret)
+			ret.Mod(ret, n)
+		}
+		for _, s := range schedule[pos] {
+			ret.Mul(ret, oddPowers[s.base][s.digit>>1])
+			ret.Mod(ret, n)
+		}
+	}
+	return ret.Mod(ret, n)
+}
@@ -80,3 +80,33 @@ func TestSimpleExp(t *testing.T) {
 		t.Errorf("Wrong result for SimpleExp")
 	}
 }
+
+func TestSimultaneousExp(t *testing.T) {
+	setup := TrustedSetup()
+	setSize := 10
+	bases := make([]*big.Int, setSize)
+	exps := make([]*big.Int, setSize)
+	want := big.NewInt(1)
+	var err error
+	for i := 0; i < setSize; i++ {
+		bases[i], err = rand.Int(rand.Reader, setup.N)
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+		exps[i], err = rand.Int(rand.Reader, Min1024)
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+		if i == 3 {
+			// a zero exponent should be skipped
+			exps[i].SetInt64(0)
+		}
+		want.Mul(want, AccumulateNew(bases[i], exps[i], setup.N))
+		want.Mod(want, setup.N)
+	}
+
+	result := SimultaneousExp(bases, exps, setup.N)
+	if result.Cmp(want) != 0 {
+		t.Errorf("Wrong result for SimultaneousExp")
+	}
+}
@@ -0,0 +1,321 @@
+package accumulator
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	// ErrDuplicateElement is returned in set mode when an element appears more than once in the input set
+	ErrDuplicateElement = errors.New("duplicate element in set")
+	// ErrInvalidMultiplicity is returned when a multiplicity is not positive, or exceeds the multiplicity of an element
+	ErrInvalidMultiplicity = errors.New("invalid multiplicity")
+)
+
+// CheckSet returns ErrDuplicateElement if any element appears more than once in set.
+// The stateless functions taking a set, e.g. AccAndProve, accumulate a duplicate twice without checking it.
+func CheckSet(set []string) error {
+	seen := make(map[string]struct{}, len(set))
+	for _, v := range set {
+		if _, ok := seen[v]; ok {
+			return fmt.Errorf("%w: %q", ErrDuplicateElement, v)
+		}
+		seen[v] = struct{}{}
+	}
+	return nil
+}
+
+// AccAndProveSet is AccAndProve in set mode, it returns ErrDuplicateElement if set contains duplicates
+// instead of accumulating the same representative twice. Use Multiset to accumulate elements with multiplicities.
+func AccAndProveSet(set []string, encodeType EncodeType, setup *Setup) (*big.Int, []*big.Int, error) {
+	if err := CheckSet(set); err != nil {
+		return nil, nil, err
+	}
+	rep, err := setup.GenRepresentatives(set, encodeType)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rep) == 0 {
+		return new(big.Int).Set(setup.G), nil, nil
+	}
+	proofs := ProveMembership(setup.G, setup.N, rep)
+	// we generate the accumulator by anyone of the membership proof raised to its power to save some calculation
+	return AccumulateNew(proofs[0], rep[0], setup.N), proofs, nil
+}
+
+// Multiset is a stateful RSA accumulator in multiset mode: every element has a multiplicity k,
+// and its representative x is accumulated k times, i.e. the accumulator value is G^{product of x^k} mod N.
+type Multiset struct {
+	setup      *Setup
+	encodeType EncodeType
+	value      *big.Int
+	elements   []Element      // distinct elements, in the order they were first added
+	reps       []*big.Int     // reps[i] is the representative of elements[i]
+	counts     []int          // counts[i] is the multiplicity of elements[i]
+	index      map[string]int // string(element) -> position in elements
+}
+
+// NewMultiset returns an empty multiset accumulator, whose value is the generator G of the setup
+func NewMultiset(setup *Setup, encodeType EncodeType) *Multiset {
+	return &Multiset{
+		setup:      setup,
+		encodeType: encodeType,
+		value:      new(big.Int).Set(setup.G),
+		index:      make(map[string]int),
+	}
+}
+
+// NewMultisetFromElements returns a multiset accumulator with all the elements accumulated,
+// an element appearing k times has multiplicity k
+func NewMultisetFromElements(setup *Setup, encodeType EncodeType, elements []Element) (*Multiset, error) {
+	m := NewMultiset(setup, encodeType)
+	if err := m.AddElements(elements...); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// NewMultisetFromSet is NewMultisetFromElements for the bytes of the strings
+func NewMultisetFromSet(setup *Setup, encodeType EncodeType, set []string) (*Multiset, error) {
+	return NewMultisetFromElements(setup, encodeType, StringElements(set))
+}
+
+// Setup returns the setup of the multiset accumulator
+func (m *Multiset) Setup() *Setup {
+	return m.setup
+}
+
+// EncodeType returns the encode type used to generate the representatives
+func (m *Multiset) EncodeType() EncodeType {
+	return m.encodeType
+}
+
+// Value returns a copy of the current accumulator value
+func (m *Multiset) Value() *big.Int {
+	return new(big.Int).Set(m.value)
+}
+
+// Size returns the number of distinct accumulated elements
+func (m *Multiset) Size() int {
+	return len(m.elements)
+}
+
+// Elements returns the distinct accumulated elements as strings, in the order they were first added
+func (m *Multiset) Elements() []string {
+	return elementStrings(m.elements)
+}
+
+// AccumulatedElements returns a copy of the distinct accumulated elements, in the order they were first added
+func (m *Multiset) AccumulatedElements() []Element {
+	ret := make([]Element, len(m.elements))
+	for i, v := range m.elements {
+		ret[i] = append(Element(nil), v...)
+	}
+	return ret
+}
+
+// Multiplicity returns the multiplicity of the element, 0 if it is not accumulated
+func (m *Multiset) Multiplicity(element string) int {
+	idx, ok := m.index[element]
+	if !ok {
+		return 0
+	}
+	return m.counts[idx]
+}
+
+// AddElements accumulates every occurrence of the elements, i.e. an element appearing k times in elements
+// has its multiplicity increased by k, with one exponentiation for all of them
+func (m *Multiset) AddElements(elements ...Element) error {
+	if len(elements) == 0 {
+		return nil
+	}
+	var distinct []Element
+	added := make(map[string]int, len(elements))
+	for _, v := range elements {
+		if _, ok := added[string(v)]; !ok {
+			distinct = append(distinct, v)
+		}
+		added[string(v)]++
+	}
+	rep, err := m.setup.EncodeElements(distinct, m.encodeType)
+	if err != nil {
+		return err
+	}
+	factors := make([]*big.Int, len(distinct))
+	for i, v := range distinct {
+		factors[i] = power(rep[i], added[string(v)])
+	}
+	m.value.Exp(m.value, SetProductRecursiveFast(factors), m.setup.N)
+	for i, v := range distinct {
+		m.insert(v, rep[i], added[string(v)])
+	}
+	return nil
+}
+
+// Add is AddElements for the bytes of the strings
+func (m *Multiset) Add(elements ...string) error {
+	return m.AddElements(StringElements(elements)...)
+}
+
+// AddWithMultiplicity increases the multiplicity of the element by k > 0
+func (m *Multiset) AddWithMultiplicity(element string, k int) error {
+	if k <= 0 {
+		return ErrInvalidMultiplicity
+	}
+	x, err := m.representative(element)
+	if err != nil {
+		return err
+	}
+	m.value.Exp(m.value, power(x, k), m.setup.N)
+	m.insert(Element(element), x, k)
+	return nil
+}
+
+// Remove decreases the multiplicity of the element by k > 0, and recomputes the accumulator value.
+// It returns ErrElementNotFound if the element is not accumulated, or ErrInvalidMultiplicity
+// if its multiplicity is smaller than k.
+func (m *Multiset) Remove(element string, k int) error {
+	idx, ok := m.index[element]
+	if !ok {
+		return ErrElementNotFound
+	}
+	if k <= 0 || k > m.counts[idx] {
+		return ErrInvalidMultiplicity
+	}
+	m.counts[idx] -= k
+	if m.counts[idx] == 0 {
+		m.removeAt(idx)
+	}
+	m.value = AccumulateNew(m.setup.G, m.product(-1, 0), m.setup.N)
+	return nil
+}
+
+// ProveMultiplicity generates the witness that the element is accumulated at least k times, i.e.
+// witness^{x^k} = acc mod N, where x is the representative of the element. k must be between 1 and
+// the multiplicity of the element.
+func (m *Multiset) ProveMultiplicity(element string, k int) (*big.Int, error) {
+	idx, ok := m.index[element]
+	if !ok {
+		return nil, ErrElementNotFound
+	}
+	if k <= 0 || k > m.counts[idx] {
+		return nil, ErrInvalidMultiplicity
+	}
+	return AccumulateNew(m.setup.G, m.product(idx, m.counts[idx]-k), m.setup.N), nil
+}
+
+// MultiplicityProof proves that an element is accumulated exactly K times: Witness^{x^K} = acc mod N,
+// and x is not accumulated in Witness, which NonMembership proves with setup.G as the base.
+type MultiplicityProof struct {
+	K             int
+	Witness       *big.Int
+	NonMembership *NonMembershipProof
+}
+
+// ProveExactMultiplicity generates the proof that the element is accumulated exactly as many times as its
+// multiplicity, which is 0 if the element is not accumulated
+func (m *Multiset) ProveExactMultiplicity(element string) (*MultiplicityProof, error) {
+	x, err := m.representative(element)
+	if err != nil {
+		return nil, err
+	}
+	k := m.Multiplicity(element)
+	idx := -1
+	if k > 0 {
+		idx = m.index[element]
+	}
+	prod := m.product(idx, 0)
+	nonMembership, err := proveNonMembershipWithProd(m.setup.G, m.setup.N, prod, x)
+	if err != nil {
+		return nil, err
+	}
+	return &MultiplicityProof{
+		K:             k,
+		Witness:       AccumulateNew(m.setup.G, prod, m.setup.N),
+		NonMembership: nonMembership,
+	}, nil
+}
+
+// VerifyMultiplicity returns true if witness proves that element is accumulated at least k times in acc,
+// i.e. witness^{x^k} = acc mod N
+func VerifyMultiplicity(setup *Setup, acc *big.Int, element string, k int, encodeType EncodeType, witness *big.Int) bool {
+	if k <= 0 {
+		return false
+	}
+	x, err := setup.GenRepresentatives([]string{element}, encodeType)
+	if err != nil {
+		return false
+	}
+	return VerifyMembershipWithRep(setup.N, acc, power(x[0], k), witness)
+}
+
+// VerifyExactMultiplicity returns true if proof shows that element is accumulated exactly proof.K times in acc
+func VerifyExactMultiplicity(setup *Setup, acc *big.Int, element string, encodeType EncodeType, proof *MultiplicityProof) bool {
+	if proof == nil || proof.K < 0 || proof.Witness == nil {
+		return false
+	}
+	x, err := setup.GenRepresentatives([]string{element}, encodeType)
+	if err != nil {
+		return false
+	}
+	if !VerifyMembershipWithRep(setup.N, acc, power(x[0], proof.K), proof.Witness) {
+		return false
+	}
+	return VerifyNonMembershipWithRep(setup.G, setup.N, proof.Witness, x[0], proof.NonMembership)
+}
+
+// representative returns the representative of the element, accumulated or not
+func (m *Multiset) representative(element string) (*big.Int, error) {
+	if idx, ok := m.index[element]; ok {
+		return m.reps[idx], nil
+	}
+	x, err := m.setup.GenRepresentatives([]string{element}, m.encodeType)
+	if err != nil {
+		return nil, err
+	}
+	return x[0], nil
+}
+
+// product returns the product of x^k over all the accumulated elements, where the multiplicity of
+// the element at position skip is replaced by k; skip < 0 replaces none
+func (m *Multiset) product(skip, k int) *big.Int {
+	factors := make([]*big.Int, 0, len(m.reps))
+	for i, x := range m.reps {
+		count := m.counts[i]
+		if i == skip {
+			count = k
+		}
+		if count > 0 {
+			factors = append(factors, power(x, count))
+		}
+	}
+	return SetProductRecursiveFast(factors)
+}
+
+func (m *Multiset) insert(element Element, x *big.Int, k int) {
+	if idx, ok := m.index[string(element)]; ok {
+		m.counts[idx] += k
+		return
+	}
+	m.index[string(element)] = len(m.elements)
+	m.elements = append(m.elements, append(Element(nil), element...))
+	m.reps = append(m.reps, x)
+	m.counts = append(m.counts, k)
+}
+
+// removeAt deletes the element at position idx while keeping the order of the remaining ones
+func (m *Multiset) removeAt(idx int) {
+	delete(m.index, string(m.elements[idx]))
+	m.elements = append(m.elements[:idx], m.elements[idx+1:]...)
+	m.reps = append(m.reps[:idx], m.reps[idx+1:]...)
+	m.counts = append(m.counts[:idx], m.counts[idx+1:]...)
+	for i := idx; i < len(m.elements); i++ {
+		m.index[string(m.elements[i])] = i
+	}
+}
+
+// power returns x^k
+func power(x *big.Int, k int) *big.Int {
+	return new(big.Int).Exp(x, big.NewInt(int64(k)), nil)
+}
@@ -0,0 +1,101 @@
+package accumulator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAccAndProveSet(t *testing.T) {
+	setup := TrustedSetup()
+	if _, _, err := AccAndProveSet([]string{"1", "2", "1"}, HashToPrimeFromSha256, setup); !errors.Is(err, ErrDuplicateElement) {
+		t.Errorf("AccAndProveSet should return ErrDuplicateElement, got %v", err)
+	}
+	set := GenBenchSet(8)
+	acc, proofs, err := AccAndProveSet(set, HashToPrimeFromSha256, setup)
+	if err != nil {
+		t.Fatalf("AccAndProveSet returns error: %v", err)
+	}
+	for i, v := range set {
+		if !VerifyMembership(setup, acc, v, HashToPrimeFromSha256, proofs[i]) {
+			t.Errorf("membership proof %d does not pass verification", i)
+		}
+	}
+}
+
+func TestMultiset(t *testing.T) {
+	setup := TrustedSetup()
+	for _, encodeType := range []EncodeType{HashToPrimeFromSha256, DIHashFromPoseidon} {
+		m, err := NewMultisetFromSet(setup, encodeType, []string{"1", "2", "1", "3", "1"})
+		if err != nil {
+			t.Fatalf("NewMultisetFromSet returns error: %v", err)
+		}
+		if m.Size() != 3 || m.Multiplicity("1") != 3 || m.Multiplicity("2") != 1 || m.Multiplicity("4") != 0 {
+			t.Fatalf("multiplicities are not counted correctly")
+		}
+		if err = m.AddWithMultiplicity("2", 2); err != nil {
+			t.Fatalf("AddWithMultiplicity returns error: %v", err)
+		}
+		// the value only depends on the multiplicities
+		other, err := NewMultisetFromSet(setup, encodeType, []string{"3", "2", "1", "2", "1", "1", "2"})
+		if err != nil {
+			t.Fatalf("NewMultisetFromSet returns error: %v", err)
+		}
+		if other.Value().Cmp(m.Value()) != 0 {
+			t.Errorf("multisets with the same multiplicities have different values")
+		}
+
+		for k := 1; k <= 3; k++ {
+			witness, err := m.ProveMultiplicity("1", k)
+			if err != nil {
+				t.Fatalf("ProveMultiplicity returns error: %v", err)
+			}
+			if !VerifyMultiplicity(setup, m.Value(), "1", k, encodeType, witness) {
+				t.Errorf("multiplicity %d does not pass verification", k)
+			}
+			if VerifyMultiplicity(setup, m.Value(), "1", k+1, encodeType, witness) {
+				t.Errorf("witness of multiplicity %d should not pass verification for %d", k, k+1)
+			}
+		}
+		if _, err = m.ProveMultiplicity("1", 4); !errors.Is(err, ErrInvalidMultiplicity) {
+			t.Errorf("ProveMultiplicity should return ErrInvalidMultiplicity, got %v", err)
+		}
+
+		for _, element := range []string{"1", "2", "3", "4"} {
+			proof, err := m.ProveExactMultiplicity(element)
+			if err != nil {
+				t.Fatalf("ProveExactMultiplicity returns error: %v", err)
+			}
+			if proof.K != m.Multiplicity(element) || !VerifyExactMultiplicity(setup, m.Value(), element, encodeType, proof) {
+				t.Errorf("exact multiplicity of %s does not pass verification", element)
+			}
+			proof.K++
+			if VerifyExactMultiplicity(setup, m.Value(), element, encodeType, proof) {
+				t.Errorf("a wrong multiplicity of %s should not pass verification", element)
+			}
+		}
+		// a witness of a smaller multiplicity cannot prove an exact multiplicity
+		witness, _ := m.ProveMultiplicity("1", 2)
+		proof, _ := m.ProveExactMultiplicity("1")
+		proof.K, proof.Witness = 2, witness
+		if VerifyExactMultiplicity(setup, m.Value(), "1", encodeType, proof) {
+			t.Errorf("multiplicity 2 should not pass verification as exact multiplicity")
+		}
+
+		if err = m.Remove("1", 2); err != nil {
+			t.Fatalf("Remove returns error: %v", err)
+		}
+		if err = m.Remove("3", 1); err != nil {
+			t.Fatalf("Remove returns error: %v", err)
+		}
+		if err = m.Remove("3", 1); !errors.Is(err, ErrElementNotFound) {
+			t.Errorf("Remove should return ErrElementNotFound, got %v", err)
+		}
+		if err = m.Remove("2", 4); !errors.Is(err, ErrInvalidMultiplicity) {
+			t.Errorf("Remove should return ErrInvalidMultiplicity, got %v", err)
+		}
+		expected, _ := NewMultisetFromSet(setup, encodeType, []string{"1", "2", "2", "2"})
+		if expected.Value().Cmp(m.Value()) != 0 || m.Size() != 2 {
+			t.Errorf("Remove does not update the accumulator value")
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package accumulator
+
+import (
+	"errors"
+	"math/big"
+)
+
+// NonMembershipProof is the non-membership witness of an element with representative x.
+// Let u be the product of the accumulated representatives and a*u + b*x = gcd(u, x) be the Bezout equation,
+// the witness is A = a, D = base^b and GCD = gcd(u, x), and it is checked by acc^A * D^x = base^GCD mod N.
+// It is the universal accumulator construction in
+// "Universal Accumulators with Efficient Nonmembership Proofs" by Li, Li and Xue.
+// GCD is always 1 for prime representatives. DI hash representatives are not primes and may share
+// small factors with u, the proof is still sound as long as x does not divide u, i.e. GCD < x,
+// because otherwise one could compute a non-trivial root of base.
+type NonMembershipProof struct {
+	A   *big.Int
+	D   *big.Int
+	GCD *big.Int
+}
+
+// ProveElementNonMembership generates the non-membership proof of element for the accumulator of set,
+// which is generated with setup.G as the base, e.g. by AccAndProveElements
+func ProveElementNonMembership(setup *Setup, set []Element, element Element, encodeType EncodeType) (*NonMembershipProof, error) {
+	rep, err := setup.EncodeElements(set, encodeType)
+	if err != nil {
+		return nil, err
+	}
+	x, err := setup.EncodeElements([]Element{element}, encodeType)
+	if err != nil {
+		return nil, err
+	}
+	return ProveNonMembershipWithRep(setup.G, setup.N, rep, x[0])
+}
+
+// ProveNonMembership is ProveElementNonMembership for the bytes of the strings
+func ProveNonMembership(setup *Setup, set []string, element string, encodeType EncodeType) (*NonMembershipProof, error) {
+	return ProveElementNonMembership(setup, StringElements(set), Element(element), encodeType)
+}
+
+// ProveNonMembershipWithRep generates the non-membership proof of the representative x for the
+// accumulator base^{product of set} mod N
+func ProveNonMembershipWithRep(base, N *big.Int, set []*big.Int, x *big.Int) (*NonMembershipProof, error) {
+	return proveNonMembershipWithProd(base, N, SetProductRecursiveFast(set), x)
+}
+
+// ProveNonMembershipWithRandomizer generates the non-membership proof of the representative x for the
+// zero-knowledge accumulator base^{randomizer * product of set} mod N.
+// The randomizer must be co-prime with x, otherwise the proof cannot be generated.
+func ProveNonMembershipWithRandomizer(base, randomizer, N *big.Int, set []*big.Int, x *big.Int) (*NonMembershipProof, error) {
+	prod := SetProductRecursiveFast(set)
+	prod.Mul(prod, randomizer)
+	return proveNonMembershipWithProd(base, N, prod, x)
+}
+
+func proveNonMembershipWithProd(base, N, prod, x *big.Int) (*NonMembershipProof, error) {
+	var a, b big.Int
+	gcd := new(big.Int).GCD(&a, &b, prod, x)
+	if gcd.Cmp(x) == 0 {
+		// x divides the product
+		return nil, errors.New("the element is accumulated, cannot prove non-membership")
+	}
+	d := new(big.Int).Exp(base, &b, N)
+	if d == nil {
+		return nil, errors.New("base is not invertible modulo N")
+	}
+	return &NonMembershipProof{
+		A:   &a,
+		D:   d,
+		GCD: gcd,
+	}, nil
+}
+
+// VerifyElementNonMembership returns true if proof shows that element is not in the accumulator acc,
+// which is generated with setup.G as the base
+func VerifyElementNonMembership(setup *Setup, acc *big.Int, element Element, encodeType EncodeType, proof *NonMembershipProof) bool {
+	x, err := setup.EncodeElements([]Element{element}, encodeType)
+	if err != nil {
+		return false
+	}
+	return VerifyNonMembershipWithRep(setup.G, setup.N, acc, x[0], proof)
+}
+
+// VerifyNonMembership is VerifyElementNonMembership for the bytes of the string
+func VerifyNonMembership(setup *Setup, acc *big.Int, element string, encodeType EncodeType, proof *NonMembershipProof) bool {
+	return VerifyElementNonMembership(setup, acc, Element(element), encodeType, proof)
+}
+
+// VerifyNonMembershipWithRep returns true if acc^A * D^x = base^GCD mod N and 0 < GCD < x
+func VerifyNonMembershipWithRep(base, N, acc, x *big.Int, proof *NonMembershipProof) bool {
+	if proof == nil || proof.A == nil || proof.D == nil || proof.GCD == nil {
+		return false
+	}
+	if proof.GCD.Sign() <= 0 || proof.GCD.Cmp(x) >= 0 {
+		return false
+	}
+	var lhs, temp big.Int
+	if lhs.Exp(acc, proof.A, N) == nil {
+		return false
+	}
+	temp.Exp(proof.D, x, N)
+	lhs.Mul(&lhs, &temp)
+	lhs.Mod(&lhs, N)
+	temp.Exp(base, proof.GCD, N)
+	return lhs.Cmp(&temp) == 0
+}
+
+// ProveElementNonMembership generates the non-membership proof of element, which must not be accumulated
+func (acc *Accumulator) ProveElementNonMembership(element Element) (*NonMembershipProof, error) {
+	if acc.setup == nil {
+		return nil, ErrNotRSASetup
+	}
+	if acc.ContainsElement(element) {
+		return nil, ErrElementExists
+	}
+	x, err := acc.encode([]Element{element})
+	if err != nil {
+		return nil, err
+	}
+	return ProveNonMembershipWithRep(acc.setup.G, acc.setup.N, acc.reps, x[0])
+}
+
+// ProveNonMembership is ProveElementNonMembership for the bytes of the string
+func (acc *Accumulator) ProveNonMembership(element string) (*NonMembershipProof, error) {
+	return acc.ProveElementNonMembership(Element(element))
+}
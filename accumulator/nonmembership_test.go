@@ -0,0 +1,71 @@
+package accumulator
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestNonMembership(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(20)
+	for _, encodeType := range []EncodeType{HashToPrimeFromSha256, DIHashFromPoseidon} {
+		acc, _ := AccAndProve(set[:16], encodeType, setup)
+		proof, err := ProveNonMembership(setup, set[:16], set[18], encodeType)
+		if err != nil {
+			t.Fatalf("ProveNonMembership returns error: %v", err)
+		}
+		if !VerifyNonMembership(setup, acc, set[18], encodeType, proof) {
+			t.Errorf("valid non-membership proof does not pass verification")
+		}
+		if VerifyNonMembership(setup, acc, set[19], encodeType, proof) {
+			t.Errorf("non-membership proof of another element should not pass verification")
+		}
+		if _, err = ProveNonMembership(setup, set[:16], set[3], encodeType); err == nil {
+			t.Errorf("ProveNonMembership should fail for an accumulated element")
+		}
+	}
+	if VerifyNonMembershipWithRep(setup.G, setup.N, setup.G, big.NewInt(3), nil) {
+		t.Errorf("nil proof should not pass verification")
+	}
+}
+
+func TestNonMembershipWithRandomizer(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(17)
+	rep := GenRepresentatives(set, DIHashFromPoseidon)
+	r := GenRandomizer()
+	base := AccumulateNew(setup.G, r, setup.N)
+	acc := accumulateNew(base, setup.N, rep[:16])
+
+	proof, err := ProveNonMembershipWithRandomizer(setup.G, r, setup.N, rep[:16], rep[16])
+	if err != nil {
+		// the randomizer shares a factor with the representative, which happens with negligible probability
+		t.Fatalf("ProveNonMembershipWithRandomizer returns error: %v", err)
+	}
+	if !VerifyNonMembershipWithRep(setup.G, setup.N, acc, rep[16], proof) {
+		t.Errorf("valid non-membership proof does not pass verification")
+	}
+}
+
+func TestAccumulatorProveNonMembership(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(10)
+	acc, err := NewAccumulatorFromSet(setup, HashToPrimeFromSha256, set[:8])
+	if err != nil {
+		t.Fatalf("NewAccumulatorFromSet returns error: %v", err)
+	}
+	if _, err = acc.ProveNonMembership(set[2]); err != ErrElementExists {
+		t.Errorf("ProveNonMembership of an accumulated element should return ErrElementExists, got %v", err)
+	}
+	// a deleted element can be proved to be absent
+	if err = acc.Delete(set[2]); err != nil {
+		t.Fatalf("Delete returns error: %v", err)
+	}
+	proof, err := acc.ProveNonMembership(set[2])
+	if err != nil {
+		t.Fatalf("ProveNonMembership returns error: %v", err)
+	}
+	if !VerifyNonMembership(setup, acc.Value(), set[2], HashToPrimeFromSha256, proof) {
+		t.Errorf("valid non-membership proof does not pass verification")
+	}
+}
@@ -0,0 +1,190 @@
+package accumulator
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// minDIOffsetBits is the smallest bit length of the DI hash offset, the Poseidon output is smaller than 2^254,
+// so the offset 2^255 always fixes the bit length of the DI hash output
+const minDIOffsetBits = 256
+
+var (
+	// ParamsToy512 uses a 512-bit modulus, for fast unit tests only. DO NOT use in production.
+	ParamsToy512 = NewParameterSet("toy-512", 512)
+	// Params2048 uses a 2048-bit modulus, it is the default parameter set
+	Params2048 = NewParameterSet("2048", 2048)
+	// Params3072 uses a 3072-bit modulus
+	Params3072 = NewParameterSet("3072", 3072)
+	// Params4096 uses a 4096-bit modulus
+	Params4096 = NewParameterSet("4096", 4096)
+
+	namedParameterSets = []*ParameterSet{ParamsToy512, Params2048, Params3072, Params4096}
+
+	// ErrUnknownParameterSet is returned when looking up a parameter set with an unknown name
+	ErrUnknownParameterSet = errors.New("unknown parameter set")
+)
+
+// ParameterSet is a named choice of the RSA modulus size, all the other sizes are derived from it:
+// the randomizers of the zero-knowledge accumulator are uniform in [0, 2^{ModulusBits-1}) and
+// the DI hash adds the offset 2^{ModulusBits/2-1}, but at least 2^255, to a single Poseidon output,
+// unless another DI hash is given to NewParameterSetWithDIHash.
+type ParameterSet struct {
+	Name        string
+	ModulusBits int
+
+	randomizerBound *big.Int
+	diHash          *DIHash
+}
+
+// NewParameterSet returns a parameter set with a modulusBits-bit modulus
+func NewParameterSet(name string, modulusBits int) *ParameterSet {
+	diOffsetBits := modulusBits / 2
+	if diOffsetBits < minDIOffsetBits {
+		diOffsetBits = minDIOffsetBits
+	}
+	diHash, err := NewDIHash(new(big.Int).Lsh(big1, uint(diOffsetBits-1)), 1)
+	if err != nil {
+		// Should never reach here, the offset has at least minDIOffsetBits bits
+		panic(err)
+	}
+	return NewParameterSetWithDIHash(name, modulusBits, diHash)
+}
+
+// NewParameterSetWithDIHash returns a parameter set with a modulusBits-bit modulus and the DI hash diHash,
+// e.g. with longer outputs than the default one
+func NewParameterSetWithDIHash(name string, modulusBits int, diHash *DIHash) *ParameterSet {
+	return &ParameterSet{
+		Name:            name,
+		ModulusBits:     modulusBits,
+		randomizerBound: new(big.Int).Lsh(big1, uint(modulusBits-1)),
+		diHash:          diHash,
+	}
+}
+
+// ParameterSetByName returns the named parameter set, i.e. "toy-512", "2048", "3072" or "4096"
+func ParameterSetByName(name string) (*ParameterSet, error) {
+	for _, v := range namedParameterSets {
+		if v.Name == name {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownParameterSet, name)
+}
+
+// ParametersForBitLength returns the named parameter set with a bitLen-bit modulus,
+// or a new parameter set named "custom-<bitLen>" if there is none
+func ParametersForBitLength(bitLen int) *ParameterSet {
+	for _, v := range namedParameterSets {
+		if v.ModulusBits == bitLen {
+			return v
+		}
+	}
+	return NewParameterSet(fmt.Sprintf("custom-%d", bitLen), bitLen)
+}
+
+// String returns the name of the parameter set
+func (p *ParameterSet) String() string {
+	return p.Name
+}
+
+// RandomizerBound returns the exclusive upper bound 2^{ModulusBits-1} of the randomizers
+func (p *ParameterSet) RandomizerBound() *big.Int {
+	return new(big.Int).Set(p.randomizerBound)
+}
+
+// DIOffset returns the offset Delta added to the Poseidon output by the DI hash
+func (p *ParameterSet) DIOffset() *big.Int {
+	return p.diHash.Delta()
+}
+
+// DIHash returns the DI hash of the parameter set
+func (p *ParameterSet) DIHash() *DIHash {
+	return p.diHash
+}
+
+// GenRandomizer outputs random number uniformly between 0 to RandomizerBound
+func (p *ParameterSet) GenRandomizer() *big.Int {
+	return genRandomizer(p.randomizerBound)
+}
+
+// DIHashPoseidon generates DI hash with Poseidon hash and the DI offset of the parameter set
+func (p *ParameterSet) DIHashPoseidon(input ...*fr.Element) *big.Int {
+	return p.diHash.Hash(input...)
+}
+
+// PoseidonAndDIHash returns the Poseidon Hash result together with DI hash result of the parameter set
+func (p *ParameterSet) PoseidonAndDIHash(input ...*fr.Element) (*fr.Element, *big.Int) {
+	return p.diHash.PoseidonAndHash(input...)
+}
+
+// GenRepresentatives generates the representatives of set with the encoder registered for encodeType
+// and the DI offset of the parameter set
+func (p *ParameterSet) GenRepresentatives(set []string, encodeType EncodeType) ([]*big.Int, error) {
+	return p.EncodeElements(StringElements(set), encodeType)
+}
+
+// EncodeElements generates the representatives of the elements of arbitrary bytes with the encoder registered
+// for encodeType and the DI offset of the parameter set
+func (p *ParameterSet) EncodeElements(set []Element, encodeType EncodeType) ([]*big.Int, error) {
+	encoder, err := encodeType.Encoder()
+	if err != nil {
+		return nil, err
+	}
+	return genRepresentatives(encoder, set, p)
+}
+
+// Parameters returns the parameter set of the setup, Params2048 if none is set
+func (setup *Setup) Parameters() *ParameterSet {
+	if setup.Params == nil {
+		return Params2048
+	}
+	return setup.Params
+}
+
+// GenRepresentatives generates the representatives of set for the parameter set of the setup.
+// It returns ErrEncoderMismatch if the setup records an encoder other than encodeType.
+func (setup *Setup) GenRepresentatives(set []string, encodeType EncodeType) ([]*big.Int, error) {
+	return setup.EncodeElements(StringElements(set), encodeType)
+}
+
+// EncodeElements generates the representatives of the elements of arbitrary bytes for the parameter set of the setup.
+// It returns ErrEncoderMismatch if the setup records an encoder other than encodeType.
+func (setup *Setup) EncodeElements(set []Element, encodeType EncodeType) ([]*big.Int, error) {
+	if err := setup.CheckEncodeType(encodeType); err != nil {
+		return nil, err
+	}
+	return setup.Parameters().EncodeElements(set, encodeType)
+}
+
+// EncodeRecords generates the representatives of the canonical encodings of the records for the parameter set
+// of the setup. It returns an error wrapping ErrInvalidElement if a record cannot be encoded.
+func (setup *Setup) EncodeRecords(records []Record, encodeType EncodeType) ([]*big.Int, error) {
+	set, err := EncodeRecords(records)
+	if err != nil {
+		return nil, err
+	}
+	return setup.EncodeElements(set, encodeType)
+}
+
+// mustGenRepresentatives is GenRepresentatives panicking on errors, for the functions without an error result
+func (setup *Setup) mustGenRepresentatives(set []string, encodeType EncodeType) []*big.Int {
+	return setup.mustEncodeElements(StringElements(set), encodeType)
+}
+
+// mustEncodeElements is EncodeElements panicking on errors, for the functions without an error result
+func (setup *Setup) mustEncodeElements(set []Element, encodeType EncodeType) []*big.Int {
+	rep, err := setup.EncodeElements(set, encodeType)
+	if err != nil {
+		panic(err)
+	}
+	return rep
+}
+
+// GenRandomizer outputs a random randomizer for the parameter set of the setup
+func (setup *Setup) GenRandomizer() *big.Int {
+	return setup.Parameters().GenRandomizer()
+}
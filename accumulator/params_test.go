@@ -0,0 +1,86 @@
+package accumulator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParameterSetByName(t *testing.T) {
+	for _, v := range []*ParameterSet{ParamsToy512, Params2048, Params3072, Params4096} {
+		params, err := ParameterSetByName(v.Name)
+		if err != nil {
+			t.Fatalf("ParameterSetByName(%q) returns error: %v", v.Name, err)
+		}
+		if params != v || ParametersForBitLength(v.ModulusBits) != v {
+			t.Errorf("lookup of %q returns a different parameter set", v.Name)
+		}
+		if params.RandomizerBound().BitLen() != v.ModulusBits {
+			t.Errorf("randomizer bound of %q has %d bits, want %d", v.Name, params.RandomizerBound().BitLen(), v.ModulusBits)
+		}
+	}
+	if _, err := ParameterSetByName("1024"); !errors.Is(err, ErrUnknownParameterSet) {
+		t.Errorf("ParameterSetByName should return ErrUnknownParameterSet, got %v", err)
+	}
+	if Params2048.DIOffset().Cmp(Min1024) != 0 || Params2048.RandomizerBound().Cmp(Min2048) != 0 {
+		t.Errorf("Params2048 is not consistent with Min1024 and Min2048")
+	}
+	if Params4096.DIOffset().BitLen() != 2048 || ParamsToy512.DIOffset().BitLen() != minDIOffsetBits {
+		t.Errorf("DI offset is not derived from the modulus size")
+	}
+	if params := ParametersForBitLength(128); params.Name != "custom-128" || params.DIOffset().BitLen() != minDIOffsetBits {
+		t.Errorf("ParametersForBitLength(128) = %s with a %d-bit DI offset", params, params.DIOffset().BitLen())
+	}
+}
+
+func TestSetupWithParameters(t *testing.T) {
+	setup, _, err := GenerateSetupWithParameters(ParamsToy512)
+	if err != nil {
+		t.Fatalf("GenerateSetupWithParameters returns error: %v", err)
+	}
+	if setup.N.BitLen() != 512 || setup.Parameters() != ParamsToy512 {
+		t.Errorf("setup does not use the toy-512 parameter set")
+	}
+	if TrustedSetup().Parameters() != Params2048 || (&Setup{}).Parameters() != Params2048 {
+		t.Errorf("the default parameter set is not Params2048")
+	}
+	if r := setup.GenRandomizer(); r.Cmp(ParamsToy512.RandomizerBound()) >= 0 {
+		t.Errorf("randomizer is not smaller than the randomizer bound")
+	}
+
+	set := GenBenchSet(16)
+	rep, err := setup.GenRepresentatives(set, DIHashFromPoseidon)
+	if err != nil {
+		t.Fatalf("GenRepresentatives returns error: %v", err)
+	}
+	for i, v := range rep {
+		if v.BitLen() != minDIOffsetBits {
+			t.Errorf("representative %d has %d bits, want %d", i, v.BitLen(), minDIOffsetBits)
+		}
+	}
+	acc, err := NewAccumulatorFromSet(setup, DIHashFromPoseidon, set)
+	if err != nil {
+		t.Fatalf("NewAccumulatorFromSet returns error: %v", err)
+	}
+	witnesses := acc.ProveMembership()
+	for i, v := range set {
+		if !VerifyMembership(setup, acc.Value(), v, DIHashFromPoseidon, witnesses[i]) {
+			t.Errorf("membership proof %d does not pass verification with the toy-512 parameter set", i)
+		}
+	}
+
+	data, err := setup.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary returns error: %v", err)
+	}
+	var decoded Setup
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary returns error: %v", err)
+	}
+	if decoded.Parameters() != ParamsToy512 {
+		t.Errorf("decoded setup uses %s, want %s", decoded.Parameters(), ParamsToy512)
+	}
+	decoded.Params = Params2048
+	if decoded.Validate() == nil {
+		t.Errorf("Validate should reject a parameter set of another modulus size")
+	}
+}
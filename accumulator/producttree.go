@@ -0,0 +1,355 @@
+package accumulator
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/jiajunxin/multiexp"
+	"github.com/jiajunxin/rsa_accumulator/group"
+	"github.com/remyoudompheng/bigfft"
+)
+
+// ProductTree is the product tree of a set of representatives, with every level kept.
+// Level 0 is the set itself, and every node of level k+1 is the product of two adjacent nodes of level k,
+// the last node of level k is carried up if level k has odd length. The root is the product of the whole set.
+// The proofs of membership are generated from the tree without multiplying the same sub-products again,
+// and a changed representative only updates the nodes on its path to the root.
+type ProductTree struct {
+	levels [][]*big.Int
+}
+
+// treeNode is the index-th node of a level in a ProductTree
+type treeNode struct {
+	level int
+	index int
+}
+
+// fourfoldExpFunc is the signature of multiexp.FourfoldExp, the variants with a precomputed table are wrapped to it
+type fourfoldExpFunc func(x, m *big.Int, y4 [4]*big.Int) [4]*big.Int
+
+// expGroup runs the exponentiations of the pre-computation in a group: the multi-exponentiations of multiexp
+// modulo N in the RSA group, and group.MultiExp in any other group, e.g. a class group
+type expGroup struct {
+	grp group.Group
+	N   *big.Int // the modulus of the RSA group, nil for any other group
+}
+
+func newExpGroup(grp group.Group) expGroup {
+	ret := expGroup{grp: grp}
+	if rsa, ok := grp.(*group.RSAGroup); ok {
+		ret.N = rsa.N
+	}
+	return ret
+}
+
+// fourfoldExp returns base^{y4[i]} for the four exponents, fourfoldExp is used in the RSA group
+func (g expGroup) fourfoldExp(base group.Element, y4 [4]*big.Int, fourfoldExp fourfoldExpFunc) [4]group.Element {
+	var ret [4]group.Element
+	if g.N == nil {
+		copy(ret[:], group.MultiExp(g.grp, base, y4[:]))
+		return ret
+	}
+	for i, v := range fourfoldExp(base.(*big.Int), g.N, y4) {
+		ret[i] = v
+	}
+	return ret
+}
+
+// smallSetProofs is handleSmallSet in the group, the proof of set[i] is base^{product of the others}
+func (g expGroup) smallSetProofs(base group.Element, set []*big.Int) []group.Element {
+	if g.N != nil {
+		return intElements(handleSmallSet(base.(*big.Int), g.N, set))
+	}
+	exps := make([]*big.Int, len(set))
+	for i := range set {
+		exps[i] = big1
+		for j, v := range set {
+			if j != i {
+				exps[i] = bigfft.Mul(exps[i], v)
+			}
+		}
+	}
+	return group.MultiExp(g.grp, base, exps)
+}
+
+// NewProductTree builds the product tree of set, the representatives are shared with the tree
+// and must not be modified
+func NewProductTree(set []*big.Int) *ProductTree {
+	leaves := make([]*big.Int, len(set))
+	copy(leaves, set)
+	t := &ProductTree{levels: [][]*big.Int{leaves}}
+	t.rebuildFrom(0)
+	return t
+}
+
+// Size returns the number of representatives in the tree
+func (t *ProductTree) Size() int {
+	return len(t.levels[0])
+}
+
+// Height returns the number of levels of the tree, including the leaves and the root
+func (t *ProductTree) Height() int {
+	return len(t.levels)
+}
+
+// Level returns a copy of the k-th level of the tree, level 0 is the set and level Height()-1 is the root.
+// The products are shared with the tree and must not be modified.
+func (t *ProductTree) Level(k int) []*big.Int {
+	ret := make([]*big.Int, len(t.levels[k]))
+	copy(ret, t.levels[k])
+	return ret
+}
+
+// Root returns a copy of the product of all the representatives, 1 for an empty tree
+func (t *ProductTree) Root() *big.Int {
+	if t.Size() == 0 {
+		return big.NewInt(1)
+	}
+	return new(big.Int).Set(t.levels[len(t.levels)-1][0])
+}
+
+// Leaf returns the i-th representative
+func (t *ProductTree) Leaf(i int) *big.Int {
+	return t.levels[0][i]
+}
+
+// Update replaces the i-th representative by x and updates the products on its path to the root
+func (t *ProductTree) Update(i int, x *big.Int) {
+	t.levels[0][i] = x
+	for k := 1; k < len(t.levels); k++ {
+		i /= 2
+		t.levels[k][i] = t.parentProduct(k, i)
+	}
+}
+
+// UpdateLeaves replaces the representatives at the indices by reps, every changed product is computed only once
+// even if the paths of several representatives share it, e.g. for the users updated in the same epoch
+func (t *ProductTree) UpdateLeaves(indices []int, reps []*big.Int) {
+	if len(indices) != len(reps) {
+		panic("UpdateLeaves: indices and reps have different lengths")
+	}
+	dirty := make(map[int]struct{}, len(indices))
+	for j, i := range indices {
+		t.levels[0][i] = reps[j]
+		dirty[i/2] = struct{}{}
+	}
+	for k := 1; k < len(t.levels); k++ {
+		parents := make(map[int]struct{}, len(dirty))
+		for i := range dirty {
+			t.levels[k][i] = t.parentProduct(k, i)
+			parents[i/2] = struct{}{}
+		}
+		dirty = parents
+	}
+}
+
+// Append adds new representatives to the end of the set, only the products covering them are updated
+func (t *ProductTree) Append(reps ...*big.Int) {
+	if len(reps) == 0 {
+		return
+	}
+	start := t.Size()
+	t.levels[0] = append(t.levels[0], reps...)
+	t.rebuildFrom(start)
+}
+
+// rebuildFrom recomputes all the products covering the representatives from the start-th one to the end
+func (t *ProductTree) rebuildFrom(start int) {
+	k := 0
+	for len(t.levels[k]) > 1 {
+		k++
+		start /= 2
+		size := (len(t.levels[k-1]) + 1) / 2
+		if k == len(t.levels) {
+			t.levels = append(t.levels, nil)
+		}
+		if len(t.levels[k]) < size {
+			t.levels[k] = append(t.levels[k], make([]*big.Int, size-len(t.levels[k]))...)
+		}
+		for i := start; i < size; i++ {
+			t.levels[k][i] = t.parentProduct(k, i)
+		}
+	}
+	t.levels = t.levels[:k+1]
+}
+
+// bytes estimates the memory in bytes taken by the products of the tree, the leaves included
+func (t *ProductTree) bytes() int64 {
+	var ret int64
+	for _, level := range t.levels {
+		for _, v := range level {
+			ret += intBytes(v)
+		}
+	}
+	return ret
+}
+
+// parentProduct returns the product of the children of the index-th node of level k
+func (t *ProductTree) parentProduct(k, index int) *big.Int {
+	children := t.levels[k-1]
+	if 2*index+1 == len(children) {
+		return children[2*index]
+	}
+	return bigfft.Mul(children[2*index], children[2*index+1])
+}
+
+// span returns the range of the representatives covered by the node
+func (t *ProductTree) span(n treeNode) (int, int) {
+	start := n.index << n.level
+	end := (n.index + 1) << n.level
+	if end > t.Size() {
+		end = t.Size()
+	}
+	return start, end
+}
+
+// children returns the one or two children of a node which is not a leaf
+func (t *ProductTree) children(n treeNode) []treeNode {
+	left := treeNode{level: n.level - 1, index: 2 * n.index}
+	if left.index+1 == len(t.levels[left.level]) {
+		return []treeNode{left}
+	}
+	return []treeNode{left, {level: left.level, index: left.index + 1}}
+}
+
+// descend skips the carried nodes, it returns the first node under n which is a leaf or has two children
+func (t *ProductTree) descend(n treeNode) treeNode {
+	for n.level > 0 {
+		children := t.children(n)
+		if len(children) == 2 {
+			break
+		}
+		n = children[0]
+	}
+	return n
+}
+
+func (t *ProductTree) product(n treeNode) *big.Int {
+	return t.levels[n.level][n.index]
+}
+
+// ProveMembership uses divide-and-conquer method to pre-compute the all membership proofs in time O(nlog(n)),
+// the products are taken from the tree
+func (t *ProductTree) ProveMembership(base, N *big.Int) []*big.Int {
+	return t.ProveMembershipWithRandomizer(base, nil, N, 0, nil)
+}
+
+// ProveMembershipParallel is ProveMembership with at most min(2^limit, GOMAXPROCS) workers
+func (t *ProductTree) ProveMembershipParallel(base, N *big.Int, limit int) []*big.Int {
+	return t.ProveMembershipWithRandomizer(base, nil, N, limit, nil)
+}
+
+// ProveMembershipWithRandomizer pre-computes the all membership proofs with base^randomizer as the generator,
+// with at most min(2^limit, GOMAXPROCS) workers. The first layer of exponentiations uses the table precomputed for base,
+// a nil randomizer or table is not used.
+func (t *ProductTree) ProveMembershipWithRandomizer(base, randomizer, N *big.Int, limit int, table *multiexp.PreTable) []*big.Int {
+	proofs, _ := t.ProveMembershipWithRandomizerContext(context.Background(), base, randomizer, N, limit, table, nil)
+	return proofs
+}
+
+// ProveMembershipWithRandomizerContext is ProveMembershipWithRandomizer with cancellation and progress reporting,
+// see ProofScheduler.ProveMembershipTreeContext
+func (t *ProductTree) ProveMembershipWithRandomizerContext(ctx context.Context, base, randomizer, N *big.Int, limit int,
+	table *multiexp.PreTable, progress Progress) ([]*big.Int, error) {
+	proofs, err := NewProofScheduler(limitWorkers(limit), 0).prove(ctx, t, group.NewRSAGroup(N), base, randomizer,
+		tableFourfoldExp(table, limit), progress)
+	return elementInts(proofs), err
+}
+
+// GroupProveMembership pre-computes the all membership proofs in grp with base as the generator, the same way as
+// ProveMembership: every step takes one fourfold exponentiation, with multiexp in the RSA group and with
+// group.MultiExp in any other group, e.g. a class group
+func (t *ProductTree) GroupProveMembership(grp group.Group, base group.Element) []group.Element {
+	proofs, _ := t.GroupProveMembershipContext(context.Background(), grp, base, 0, nil)
+	return proofs
+}
+
+// GroupProveMembershipContext is GroupProveMembership with at most min(2^limit, GOMAXPROCS) workers,
+// cancellation and progress reporting, see ProofScheduler.GroupProveMembershipTreeContext
+func (t *ProductTree) GroupProveMembershipContext(ctx context.Context, grp group.Group, base group.Element, limit int,
+	progress Progress) ([]group.Element, error) {
+	return NewProofScheduler(limitWorkers(limit), 0).prove(ctx, t, grp, base, nil, multiexp.FourfoldExp, progress)
+}
+
+// tableFourfoldExp returns the fourfold exponentiation with the table precomputed for the base, in parallel if limit > 0.
+// It returns multiexp.FourfoldExp if table is nil.
+func tableFourfoldExp(table *multiexp.PreTable, limit int) fourfoldExpFunc {
+	if table == nil {
+		return multiexp.FourfoldExp
+	}
+	return func(x, m *big.Int, y4 [4]*big.Int) [4]*big.Int {
+		if limit > 0 {
+			return multiexp.FourfoldExpPrecomputedParallel(x, m, y4, table)
+		}
+		return multiexp.FourfoldExpPrecomputed(x, m, y4, table)
+	}
+}
+
+// proofTask is the pre-computation of the proofs of the representatives under node, whose generator is base
+type proofTask struct {
+	base group.Element
+	node treeNode
+}
+
+// root returns the task of all the proofs
+func (t *ProductTree) root(base group.Element) proofTask {
+	return proofTask{base: base, node: t.rootNode()}
+}
+
+func (t *ProductTree) rootNode() treeNode {
+	return treeNode{level: len(t.levels) - 1}
+}
+
+// step runs one layer of the task. If the node has at most 4 representatives, it writes their proofs into proofs.
+// Otherwise it returns the tasks of the grandchildren of the node: the proof of a representative under a grandchild
+// accumulates the other child and the sibling of the grandchild, the four grandchildren share one fourfold
+// exponentiation. The randomizer, if not nil, is multiplied into the exponents.
+func (t *ProductTree) step(task proofTask, g expGroup, randomizer *big.Int, fourfoldExp fourfoldExpFunc,
+	proofs []group.Element) []proofTask {
+	n := t.descend(task.node)
+	start, end := t.span(n)
+	if end-start <= 4 {
+		base := task.base
+		if randomizer != nil {
+			base = g.grp.Exp(base, randomizer)
+		}
+		copy(proofs[start:end], g.smallSetProofs(base, t.levels[0][start:end]))
+		return nil
+	}
+
+	var (
+		grandchildren []treeNode
+		inputExp      [4]*big.Int
+	)
+	children := t.children(n)
+	for i, child := range children {
+		other := t.product(children[1-i])
+		child = t.descend(child)
+		if child.level == 0 {
+			inputExp[len(grandchildren)] = other
+			grandchildren = append(grandchildren, child)
+			continue
+		}
+		pair := t.children(child)
+		inputExp[len(grandchildren)] = bigfft.Mul(other, t.product(pair[1]))
+		inputExp[len(grandchildren)+1] = bigfft.Mul(other, t.product(pair[0]))
+		grandchildren = append(grandchildren, pair...)
+	}
+	for i := range inputExp {
+		if inputExp[i] == nil {
+			// only three grandchildren, the unused exponent is cheap
+			inputExp[i] = big1
+			continue
+		}
+		if randomizer != nil {
+			inputExp[i] = bigfft.Mul(inputExp[i], randomizer)
+		}
+	}
+	bases := g.fourfoldExp(task.base, inputExp, fourfoldExp)
+
+	ret := make([]proofTask, len(grandchildren))
+	for i, g := range grandchildren {
+		ret[i] = proofTask{base: bases[i], node: g}
+	}
+	return ret
+}
@@ -0,0 +1,101 @@
+package accumulator
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/jiajunxin/multiexp"
+)
+
+func checkProductTree(t *testing.T, tree *ProductTree, set []*big.Int) {
+	t.Helper()
+	if tree.Size() != len(set) {
+		t.Fatalf("tree size = %d, want %d", tree.Size(), len(set))
+	}
+	if tree.Root().Cmp(SetProductRecursiveFast(set)) != 0 {
+		t.Fatalf("tree root is not the product of the set")
+	}
+	expected := NewProductTree(set)
+	if tree.Height() != expected.Height() {
+		t.Fatalf("tree height = %d, want %d", tree.Height(), expected.Height())
+	}
+	for k := 0; k < tree.Height(); k++ {
+		level, want := tree.Level(k), expected.Level(k)
+		if len(level) != len(want) {
+			t.Fatalf("level %d has %d nodes, want %d", k, len(level), len(want))
+		}
+		for i := range level {
+			if level[i].Cmp(want[i]) != 0 {
+				t.Fatalf("node %d of level %d is wrong", i, k)
+			}
+		}
+	}
+}
+
+func checkProofs(t *testing.T, base, N *big.Int, set, proofs []*big.Int) {
+	t.Helper()
+	if len(proofs) != len(set) {
+		t.Fatalf("got %d proofs, want %d", len(proofs), len(set))
+	}
+	acc := AccumulateNew(base, SetProductRecursiveFast(set), N)
+	for i := range set {
+		if AccumulateNew(proofs[i], set[i], N).Cmp(acc) != 0 {
+			t.Fatalf("proof %d of a set of size %d is wrong", i, len(set))
+		}
+	}
+}
+
+func TestProductTree(t *testing.T) {
+	setup := TrustedSetup()
+	rep := GenRepresentatives(GenTestSet(40), HashToPrimeFromSha256)
+	for _, size := range []int{1, 2, 3, 4, 5, 6, 7, 9, 13, 16, 17, 23, 40} {
+		tree := NewProductTree(rep[:size])
+		checkProductTree(t, tree, rep[:size])
+		checkProofs(t, setup.G, setup.N, rep[:size], tree.ProveMembership(setup.G, setup.N))
+		checkProofs(t, setup.G, setup.N, rep[:size], tree.ProveMembershipParallel(setup.G, setup.N, 4))
+	}
+	if NewProductTree(nil).Root().Cmp(big1) != 0 {
+		t.Errorf("the root of an empty tree is not 1")
+	}
+}
+
+func TestProductTreeUpdate(t *testing.T) {
+	setup := TrustedSetup()
+	rep := GenRepresentatives(GenTestSet(60), HashToPrimeFromSha256)
+	set := make([]*big.Int, 23)
+	copy(set, rep)
+	tree := NewProductTree(set)
+
+	set[7] = rep[30]
+	tree.Update(7, rep[30])
+	checkProductTree(t, tree, set)
+
+	set[0], set[11], set[12], set[22] = rep[31], rep[32], rep[33], rep[34]
+	tree.UpdateLeaves([]int{0, 11, 12, 22}, []*big.Int{rep[31], rep[32], rep[33], rep[34]})
+	checkProductTree(t, tree, set)
+
+	for _, size := range []int{1, 8, 9} {
+		tree.Append(rep[40 : 40+size]...)
+		set = append(set, rep[40:40+size]...)
+		checkProductTree(t, tree, set)
+	}
+	checkProofs(t, setup.G, setup.N, set, tree.ProveMembership(setup.G, setup.N))
+
+	tree = NewProductTree(nil)
+	tree.Append(rep[0])
+	checkProductTree(t, tree, rep[:1])
+	tree.Append(rep[1:5]...)
+	checkProductTree(t, tree, rep[:5])
+}
+
+func TestProductTreeWithRandomizer(t *testing.T) {
+	setup := TrustedSetup()
+	rep := GenRepresentatives(GenTestSet(37), HashToPrimeFromSha256)
+	r := GenRandomizer()
+	base := AccumulateNew(setup.G, r, setup.N)
+	table := multiexp.NewPrecomputeTable(setup.G, setup.N, 37*1025/64+64)
+	checkProofs(t, base, setup.N, rep, ProveMembershipSingleThreadWithRandomizer(setup.G, r, setup.N, rep, table))
+	checkProofs(t, base, setup.N, rep, ProveMembershipParallelWithTableWithRandomizer(setup.G, r, setup.N, rep, 2, table))
+	checkProofs(t, base, setup.N, rep, NewProductTree(rep).ProveMembershipWithRandomizer(setup.G, r, setup.N, 0, nil))
+	checkProofs(t, setup.G, setup.N, rep, ProveMembershipParallelWithTable(setup.G, setup.N, rep, 2, table))
+}
@@ -0,0 +1,61 @@
+package accumulator
+
+import (
+	"sync"
+	"time"
+)
+
+// Progress receives the progress of a long pre-computation, e.g. of the membership proofs of a large set
+type Progress interface {
+	// Report is called after every completed batch of leaves with the number of completed leaves out of total
+	// and the estimated remaining time. The calls are serialized and completed never decreases.
+	Report(completed, total int, eta time.Duration)
+}
+
+// ProgressFunc adapts a function to Progress
+type ProgressFunc func(completed, total int, eta time.Duration)
+
+// Report calls f
+func (f ProgressFunc) Report(completed, total int, eta time.Duration) {
+	f(completed, total, eta)
+}
+
+// progressTracker counts the completed leaves and reports them to a Progress, which may be nil
+type progressTracker struct {
+	mu        sync.Mutex
+	progress  Progress
+	total     int
+	completed int
+	start     time.Time
+}
+
+func newProgressTracker(progress Progress, total int) *progressTracker {
+	return &progressTracker{
+		progress: progress,
+		total:    total,
+		start:    time.Now(),
+	}
+}
+
+// add records n more completed leaves, the ETA assumes the remaining leaves take as long as the completed ones
+func (p *progressTracker) add(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.completed += n
+	if p.progress == nil {
+		return
+	}
+	var eta time.Duration
+	if p.completed > 0 && p.completed < p.total {
+		elapsed := time.Since(p.start)
+		eta = time.Duration(float64(elapsed) * float64(p.total-p.completed) / float64(p.completed))
+	}
+	p.progress.Report(p.completed, p.total, eta)
+}
+
+// done returns true if all the leaves are completed
+func (p *progressTracker) done() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.completed == p.total
+}
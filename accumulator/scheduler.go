@@ -0,0 +1,388 @@
+package accumulator
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"math/bits"
+	"runtime"
+	"sync"
+
+	"github.com/jiajunxin/multiexp"
+	"github.com/jiajunxin/rsa_accumulator/group"
+)
+
+const (
+	// proofQueueFactor is the capacity of the task queue of a ProofScheduler per worker
+	proofQueueFactor = 4
+	// proofTaskMemoryFactor estimates the memory taken by a running task in multiples of the product of its node,
+	// i.e. the four exponents and the temporaries of the multiplications and the fourfold exponentiation
+	proofTaskMemoryFactor = 8
+	// bigIntOverhead is the memory in bytes taken by a big.Int besides its words, i.e. its header and a pointer to it
+	bigIntOverhead = 40
+)
+
+// ErrMemoryBudget is returned when the memory budget of a ProofScheduler is smaller than the memory
+// which a pre-computation takes at least, see ProofScheduler.MinMemoryBudget
+var ErrMemoryBudget = errors.New("memory budget is too small")
+
+// ProofScheduler pre-computes the membership proofs from a ProductTree on a fixed number of workers, in the RSA group
+// or in any other group, e.g. a class group. Every task is a subtree with its generator, running a task takes one
+// fourfold exponentiation and produces the tasks of the up to four grandchildren. The tasks wait in a bounded queue,
+// and a worker finding the queue full runs its new tasks itself, depth-first.
+// The memory budget bounds the memory of a run: the product tree, the proofs, the bases of the pending tasks,
+// and the running tasks, whose memory is estimated from the products of their nodes. The tree, the proofs and
+// the bases of the most tasks which can be pending are reserved up front, the running tasks wait for the rest.
+// The proofs are the same for any number of workers and any budget.
+type ProofScheduler struct {
+	numWorkers   int
+	memoryBudget int64
+}
+
+// NewProofScheduler returns a scheduler with numWorkers workers, or GOMAXPROCS workers if numWorkers <= 0.
+// The memory budget is in bytes, the memory is not bounded if memoryBudget <= 0. A pre-computation with a positive
+// budget smaller than MinMemoryBudget returns ErrMemoryBudget.
+func NewProofScheduler(numWorkers int, memoryBudget int64) *ProofScheduler {
+	if numWorkers <= 0 {
+		numWorkers = runtime.GOMAXPROCS(0)
+	}
+	return &ProofScheduler{
+		numWorkers:   numWorkers,
+		memoryBudget: memoryBudget,
+	}
+}
+
+// NumWorkers returns the number of workers
+func (s *ProofScheduler) NumWorkers() int {
+	return s.numWorkers
+}
+
+// MemoryBudget returns the memory budget in bytes, 0 or negative if the memory is not bounded
+func (s *ProofScheduler) MemoryBudget() int64 {
+	return s.memoryBudget
+}
+
+// ProveMembership pre-computes the all membership proofs of set, the same as ProveMembership.
+// It returns nil if the memory budget is too small, use ProveMembershipContext to get the error.
+func (s *ProofScheduler) ProveMembership(base, N *big.Int, set []*big.Int) []*big.Int {
+	return s.ProveMembershipTree(NewProductTree(set), base, N)
+}
+
+// ProveMembershipTree pre-computes the all membership proofs of the representatives in tree.
+// It returns nil if the memory budget is too small, use ProveMembershipTreeContext to get the error.
+func (s *ProofScheduler) ProveMembershipTree(tree *ProductTree, base, N *big.Int) []*big.Int {
+	proofs, _ := s.ProveMembershipTreeContext(context.Background(), tree, base, N, nil)
+	return proofs
+}
+
+// ProveMembershipContext is ProveMembership with cancellation and progress reporting, see ProveMembershipTreeContext
+func (s *ProofScheduler) ProveMembershipContext(ctx context.Context, base, N *big.Int, set []*big.Int,
+	progress Progress) ([]*big.Int, error) {
+	return s.ProveMembershipTreeContext(ctx, NewProductTree(set), base, N, progress)
+}
+
+// ProveMembershipTreeContext is ProveMembershipTree with cancellation and progress reporting. The context is checked
+// before every exponentiation, it returns ctx.Err() if the context is done before all the proofs are computed.
+// The progress, if not nil, receives the number of leaves whose proofs are computed.
+// It returns ErrMemoryBudget if the memory budget is smaller than MinMemoryBudget(tree, N).
+func (s *ProofScheduler) ProveMembershipTreeContext(ctx context.Context, tree *ProductTree, base, N *big.Int,
+	progress Progress) ([]*big.Int, error) {
+	proofs, err := s.prove(ctx, tree, group.NewRSAGroup(N), base, nil, multiexp.FourfoldExp, progress)
+	return elementInts(proofs), err
+}
+
+// GroupProveMembership pre-computes the all membership proofs of set in grp, the same as GroupProveMembership.
+// It returns nil if the memory budget is too small, use GroupProveMembershipTreeContext to get the error.
+func (s *ProofScheduler) GroupProveMembership(grp group.Group, base group.Element, set []*big.Int) []group.Element {
+	proofs, _ := s.GroupProveMembershipTreeContext(context.Background(), NewProductTree(set), grp, base, nil)
+	return proofs
+}
+
+// GroupProveMembershipTreeContext is ProveMembershipTreeContext in grp with base as the generator.
+// It returns ErrMemoryBudget if the memory budget is smaller than GroupMinMemoryBudget(tree, grp).
+func (s *ProofScheduler) GroupProveMembershipTreeContext(ctx context.Context, tree *ProductTree, grp group.Group,
+	base group.Element, progress Progress) ([]group.Element, error) {
+	return s.prove(ctx, tree, grp, base, nil, multiexp.FourfoldExp, progress)
+}
+
+// MinMemoryBudget returns the smallest memory budget in bytes which runs the pre-computation of the proofs of tree
+// modulo N: the memory reserved for the tree, the proofs and the pending tasks, and the memory of the largest task
+func (s *ProofScheduler) MinMemoryBudget(tree *ProductTree, N *big.Int) int64 {
+	return s.GroupMinMemoryBudget(tree, group.NewRSAGroup(N))
+}
+
+// GroupMinMemoryBudget is MinMemoryBudget for the pre-computation in grp
+func (s *ProofScheduler) GroupMinMemoryBudget(tree *ProductTree, grp group.Group) int64 {
+	if tree.Size() == 0 {
+		return 0
+	}
+	return s.reservedMemory(tree, grp) + taskWeight(tree, tree.rootNode())
+}
+
+// reservedMemory estimates the memory in bytes taken by a run besides the running tasks: the product tree,
+// the proofs, and the bases of the pending tasks
+func (s *ProofScheduler) reservedMemory(tree *ProductTree, grp group.Group) int64 {
+	element := elementBytes(grp)
+	return tree.bytes() + int64(tree.Size())*element + int64(s.maxPendingTasks(tree))*element
+}
+
+// maxPendingTasks bounds the number of tasks holding a base at the same time: the tasks in the queue, and the up to
+// four subtasks kept by every worker at every depth of its depth-first recursion, a task descends two levels
+func (s *ProofScheduler) maxPendingTasks(tree *ProductTree) int {
+	queued := 0
+	if s.numWorkers > 1 {
+		queued = proofQueueFactor * s.numWorkers
+	}
+	depth := (tree.Height()+1)/2 + 1
+	return queued + 4*depth*s.numWorkers
+}
+
+// intBytes estimates the memory in bytes taken by an integer of the size of x
+func intBytes(x *big.Int) int64 {
+	return int64(len(x.Bits()))*bits.UintSize/8 + bigIntOverhead
+}
+
+// elementBytes estimates the memory in bytes taken by an element of grp
+func elementBytes(grp group.Group) int64 {
+	switch g := grp.(type) {
+	case *group.RSAGroup:
+		return intBytes(g.N)
+	case *group.ClassGroup:
+		// A and B are below sqrt(|D|) and C is below |D|
+		return 2*intBytes(g.D) + bigIntOverhead
+	}
+	return int64(len(grp.Serialize(grp.Identity()))) + bigIntOverhead
+}
+
+// limitWorkers converts the limit of the functions which used at most O(2^limit) Goroutines into a number of workers,
+// there is no gain from more workers than GOMAXPROCS
+func limitWorkers(limit int) int {
+	if limit <= 0 {
+		return 1
+	}
+	numWorkers := runtime.GOMAXPROCS(0)
+	if limit < bits.UintSize-2 && 1<<limit < numWorkers {
+		numWorkers = 1 << limit
+	}
+	return numWorkers
+}
+
+// proofObserver is notified of every finished step of a proofRun, e.g. to checkpoint it.
+// It is called concurrently by the workers, the run stops at its first error.
+type proofObserver interface {
+	// expanded is called when the task has produced the subtasks
+	expanded(task proofTask, subtasks []proofTask) error
+	// finished is called when the proofs of the leaves under the task are computed
+	finished(task proofTask, proofs []group.Element) error
+}
+
+// proofRun is the state of one ProofScheduler.prove
+type proofRun struct {
+	ctx        context.Context
+	cancel     context.CancelFunc
+	tree       *ProductTree
+	grp        expGroup
+	randomizer *big.Int        // only for the root task
+	rootExp    fourfoldExpFunc // only for the root task
+	proofs     []group.Element
+	queue      chan proofTask
+	pending    sync.WaitGroup // the queued tasks not finished yet
+	budget     *memoryBudget
+	tracker    *progressTracker
+	observer   proofObserver
+	errOnce    sync.Once
+	err        error
+}
+
+// newRun returns the run of the pre-computation of tree, or ErrMemoryBudget if the memory budget is too small
+func (s *ProofScheduler) newRun(ctx context.Context, tree *ProductTree, grp group.Group, randomizer *big.Int,
+	rootExp fourfoldExpFunc, progress Progress) (*proofRun, error) {
+	var budget int64
+	if s.memoryBudget > 0 {
+		if s.memoryBudget < s.GroupMinMemoryBudget(tree, grp) {
+			return nil, ErrMemoryBudget
+		}
+		budget = s.memoryBudget - s.reservedMemory(tree, grp)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &proofRun{
+		ctx:        ctx,
+		cancel:     cancel,
+		tree:       tree,
+		grp:        newExpGroup(grp),
+		randomizer: randomizer,
+		rootExp:    rootExp,
+		proofs:     make([]group.Element, tree.Size()),
+		budget:     newMemoryBudget(budget),
+		tracker:    newProgressTracker(progress, tree.Size()),
+	}, nil
+}
+
+// prove runs the root task with randomizer and fourfoldExp, and then all the subtasks in grp.
+// fourfoldExp is only used in the RSA group. There is no proof to compute for an empty tree.
+func (s *ProofScheduler) prove(ctx context.Context, tree *ProductTree, grp group.Group, base group.Element,
+	randomizer *big.Int, fourfoldExp fourfoldExpFunc, progress Progress) ([]group.Element, error) {
+	if tree.Size() == 0 {
+		return nil, nil
+	}
+	r, err := s.newRun(ctx, tree, grp, randomizer, fourfoldExp, progress)
+	if err != nil {
+		return nil, err
+	}
+	return s.runTasks(r, []proofTask{tree.root(base)})
+}
+
+// runTasks runs the tasks and all their subtasks on the workers
+func (s *ProofScheduler) runTasks(r *proofRun, tasks []proofTask) ([]group.Element, error) {
+	defer r.cancel()
+	if s.numWorkers == 1 {
+		for _, task := range tasks {
+			r.runInline(task)
+		}
+		return r.result()
+	}
+
+	r.queue = make(chan proofTask, proofQueueFactor*s.numWorkers)
+	var workers sync.WaitGroup
+	for i := 0; i < s.numWorkers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for task := range r.queue {
+				r.run(task)
+				r.pending.Done()
+			}
+		}()
+	}
+	for _, task := range tasks {
+		r.pending.Add(1)
+		r.queue <- task
+	}
+	r.pending.Wait()
+	close(r.queue)
+	workers.Wait()
+	return r.result()
+}
+
+// result returns the proofs, or the error which stopped the run before all the proofs are computed
+func (r *proofRun) result() ([]group.Element, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if !r.tracker.done() {
+		return nil, r.ctx.Err()
+	}
+	return r.proofs, nil
+}
+
+// fail stops the run with err, only the first error is kept
+func (r *proofRun) fail(err error) {
+	r.errOnce.Do(func() {
+		r.err = err
+		r.cancel()
+	})
+}
+
+// run runs the task and queues its subtasks, the subtasks which do not fit into the queue run on this worker
+func (r *proofRun) run(task proofTask) {
+	for _, sub := range r.step(task) {
+		r.pending.Add(1)
+		select {
+		case r.queue <- sub:
+		default:
+			r.pending.Done()
+			r.run(sub)
+		}
+	}
+}
+
+// runInline runs the task and all its subtasks depth-first on this Goroutine
+func (r *proofRun) runInline(task proofTask) {
+	for _, sub := range r.step(task) {
+		r.runInline(sub)
+	}
+}
+
+// step runs one layer of the task within the memory budget, the budget is released before the subtasks run.
+// Nothing runs once the context is done.
+func (r *proofRun) step(task proofTask) []proofTask {
+	if r.ctx.Err() != nil {
+		return nil
+	}
+	randomizer, fourfoldExp := (*big.Int)(nil), fourfoldExpFunc(multiexp.FourfoldExp)
+	if task.node == r.tree.rootNode() {
+		randomizer, fourfoldExp = r.randomizer, r.rootExp
+	}
+	weight := taskWeight(r.tree, task.node)
+	r.budget.acquire(weight)
+	subtasks := r.tree.step(task, r.grp, randomizer, fourfoldExp, r.proofs)
+	r.budget.release(weight)
+	if len(subtasks) > 0 {
+		if r.observer != nil {
+			if err := r.observer.expanded(task, subtasks); err != nil {
+				r.fail(err)
+				return nil
+			}
+		}
+		return subtasks
+	}
+	start, end := r.tree.span(task.node)
+	if r.observer != nil {
+		if err := r.observer.finished(task, r.proofs[start:end]); err != nil {
+			r.fail(err)
+			return nil
+		}
+	}
+	r.tracker.add(end - start)
+	return nil
+}
+
+// taskWeight estimates the memory in bytes taken by running the task of the node
+func taskWeight(tree *ProductTree, n treeNode) int64 {
+	return int64(len(tree.product(n).Bits())) * bits.UintSize / 8 * proofTaskMemoryFactor
+}
+
+// memoryBudget is a weighted semaphore of bytes for the running tasks, it does not block if the limit is not positive
+type memoryBudget struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	limit int64
+	used  int64
+}
+
+func newMemoryBudget(limit int64) *memoryBudget {
+	b := &memoryBudget{limit: limit}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// capped returns the weight actually taken from the budget, a weight larger than the limit takes the whole budget
+func (b *memoryBudget) capped(weight int64) int64 {
+	if weight > b.limit {
+		return b.limit
+	}
+	return weight
+}
+
+func (b *memoryBudget) acquire(weight int64) {
+	if b.limit <= 0 {
+		return
+	}
+	weight = b.capped(weight)
+	b.mu.Lock()
+	for b.used+weight > b.limit {
+		b.cond.Wait()
+	}
+	b.used += weight
+	b.mu.Unlock()
+}
+
+func (b *memoryBudget) release(weight int64) {
+	if b.limit <= 0 {
+		return
+	}
+	b.mu.Lock()
+	b.used -= b.capped(weight)
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
@@ -0,0 +1,150 @@
+package accumulator
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestProofScheduler(t *testing.T) {
+	setup := TrustedSetup()
+	rep := GenRepresentatives(GenTestSet(45), HashToPrimeFromSha256)
+	tree := NewProductTree(rep)
+	expected := tree.ProveMembership(setup.G, setup.N)
+	checkProofs(t, setup.G, setup.N, rep, expected)
+	for _, numWorkers := range []int{1, 2, 3, 5, 8} {
+		minBudget := NewProofScheduler(numWorkers, 0).MinMemoryBudget(tree, setup.N)
+		for _, budget := range []int64{0, minBudget, minBudget + 4096, minBudget + 1<<20} {
+			s := NewProofScheduler(numWorkers, budget)
+			proofs := s.ProveMembershipTree(tree, setup.G, setup.N)
+			if len(proofs) != len(expected) {
+				t.Fatalf("%d workers with budget %d: got %d proofs, want %d", numWorkers, budget, len(proofs), len(expected))
+			}
+			for i := range proofs {
+				if proofs[i].Cmp(expected[i]) != 0 {
+					t.Fatalf("%d workers with budget %d: proof %d is different", numWorkers, budget, i)
+				}
+			}
+		}
+	}
+	// the tree and the proofs alone take more than a budget below the minimum
+	_, err := NewProofScheduler(2, 4096).ProveMembershipTreeContext(context.Background(), tree, setup.G, setup.N, nil)
+	if !errors.Is(err, ErrMemoryBudget) {
+		t.Errorf("a budget of 4096 bytes returns %v, want %v", err, ErrMemoryBudget)
+	}
+	s := NewProofScheduler(2, 0)
+	if s.MinMemoryBudget(tree, setup.N) <= tree.bytes()+int64(tree.Size())*intBytes(setup.N) {
+		t.Errorf("the minimum budget does not cover the tree, the proofs and the pending tasks")
+	}
+	_, err = NewProofScheduler(2, s.MinMemoryBudget(tree, setup.N)-1).ProveMembershipTreeContext(context.Background(),
+		tree, setup.G, setup.N, nil)
+	if !errors.Is(err, ErrMemoryBudget) {
+		t.Errorf("a budget below the minimum returns %v, want %v", err, ErrMemoryBudget)
+	}
+	if NewProofScheduler(0, 0).NumWorkers() <= 0 {
+		t.Errorf("the default scheduler has no worker")
+	}
+}
+
+func TestMemoryBudget(t *testing.T) {
+	b := newMemoryBudget(100)
+	b.acquire(60)
+	acquired := make(chan struct{})
+	go func() {
+		b.acquire(50)
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatalf("the budget is exceeded")
+	default:
+	}
+	b.release(60)
+	<-acquired
+	// a weight larger than the limit takes the whole budget
+	b.release(50)
+	b.acquire(1000)
+	if b.used != 100 {
+		t.Errorf("used = %d, want 100", b.used)
+	}
+	b.release(1000)
+	if b.used != 0 {
+		t.Errorf("used = %d, want 0", b.used)
+	}
+}
+
+func TestProofSchedulerContext(t *testing.T) {
+	setup := TrustedSetup()
+	rep := GenRepresentatives(GenTestSet(30), HashToPrimeFromSha256)
+	for _, numWorkers := range []int{1, 3} {
+		last := 0
+		progress := ProgressFunc(func(completed, total int, eta time.Duration) {
+			if total != len(rep) || completed <= last || completed > total || eta < 0 {
+				t.Errorf("invalid progress: %d of %d, ETA %v after %d", completed, total, eta, last)
+			}
+			last = completed
+		})
+		proofs, err := NewProofScheduler(numWorkers, 0).ProveMembershipContext(context.Background(), setup.G, setup.N, rep, progress)
+		if err != nil {
+			t.Fatalf("ProveMembershipContext returns error: %v", err)
+		}
+		checkProofs(t, setup.G, setup.N, rep, proofs)
+		if last != len(rep) {
+			t.Errorf("the last progress is %d, want %d", last, len(rep))
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := ProgressFunc(func(completed, total int, eta time.Duration) {
+		cancel()
+	})
+	proofs, err := NewProofScheduler(2, 0).ProveMembershipContext(ctx, setup.G, setup.N, rep, progress)
+	if !errors.Is(err, context.Canceled) || proofs != nil {
+		t.Errorf("ProveMembershipContext cancelled in progress returns %v, want %v", err, context.Canceled)
+	}
+	if _, _, err := AccAndProveContext(ctx, GenTestSet(8), HashToPrimeFromSha256, setup, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("AccAndProveContext with a cancelled context returns %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestAccAndProveContext(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenTestSet(20)
+	rep := GenRepresentatives(set, HashToPrimeFromSha256)
+	acc, proofs, err := AccAndProveContext(context.Background(), set, HashToPrimeFromSha256, setup, nil)
+	if err != nil {
+		t.Fatalf("AccAndProveContext returns error: %v", err)
+	}
+	checkProofs(t, setup.G, setup.N, rep, proofs)
+	acc2, proofs, err := AccAndProveParallelContext(context.Background(), set, HashToPrimeFromSha256, setup, 3, nil)
+	if err != nil {
+		t.Fatalf("AccAndProveParallelContext returns error: %v", err)
+	}
+	checkProofs(t, setup.G, setup.N, rep, proofs)
+	if acc.Cmp(acc2) != 0 || acc.Cmp(AccumulateNew(setup.G, SetProductRecursiveFast(rep), setup.N)) != 0 {
+		t.Errorf("the accumulators are not consistent")
+	}
+}
+
+func TestProveMembershipEmptySet(t *testing.T) {
+	setup := TrustedSetup()
+	ctx := context.Background()
+	if proofs, err := ProveMembershipContext(ctx, setup.G, setup.N, nil, nil); proofs != nil || err != nil {
+		t.Errorf("ProveMembershipContext of an empty set returns %v, %v", proofs, err)
+	}
+	if proofs, err := ProveMembershipParallelContext(ctx, setup.G, setup.N, nil, 2, nil); proofs != nil || err != nil {
+		t.Errorf("ProveMembershipParallelContext of an empty set returns %v, %v", proofs, err)
+	}
+	proofs, err := ProveMembershipParallelWithTableWithRandomizerContext(ctx, setup.G, big.NewInt(3), setup.N, nil, 2, nil, nil)
+	if proofs != nil || err != nil {
+		t.Errorf("ProveMembershipParallelWithTableWithRandomizerContext of an empty set returns %v, %v", proofs, err)
+	}
+	if proofs, err = NewProofScheduler(3, 0).ProveMembershipContext(ctx, setup.G, setup.N, nil, nil); proofs != nil || err != nil {
+		t.Errorf("ProofScheduler.ProveMembershipContext of an empty set returns %v, %v", proofs, err)
+	}
+	if proofs = ProveMembership(setup.G, setup.N, nil); proofs != nil {
+		t.Errorf("ProveMembership of an empty set returns %v", proofs)
+	}
+}
@@ -0,0 +1,580 @@
+package accumulator
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"strings"
+)
+
+const (
+	// SetupFormatVersion is the version of the on-disk format of Setup and Trapdoor.
+	// Version 2 records the encoder of the setup, version 3 records its parameter set,
+	// i.e. the name and the DI hash. Files of versions 1 and 2 can still be loaded.
+	SetupFormatVersion = 3
+	// MinSetupBitLength is the smallest bit length of N accepted by GenerateSetup, for test purposes only.
+	// Use at least RSABitLength bits in production.
+	MinSetupBitLength = 64
+
+	setupMagic    = "RSAS"
+	trapdoorMagic = "RSAT"
+)
+
+var (
+	// ErrInvalidSetup is returned when a setup is malformed
+	ErrInvalidSetup = errors.New("invalid setup")
+	// ErrFingerprintMismatch is returned when a loaded setup does not match its fingerprint
+	ErrFingerprintMismatch = errors.New("setup fingerprint mismatch")
+	// ErrUnsupportedVersion is returned when loading a setup or trapdoor of an unknown format version
+	ErrUnsupportedVersion = errors.New("unsupported setup format version")
+)
+
+// GenerateSetup generates a new hidden order group QR_N with a bitLen-bit N = p*q for safe primes p and q,
+// together with random generators G and H of QR_N, and returns the trapdoor of N.
+// The trapdoor must be destroyed, or kept only by the operator, before the setup is used.
+func GenerateSetup(bitLen int) (*Setup, *Trapdoor, error) {
+	return GenerateSetupWithParameters(ParametersForBitLength(bitLen))
+}
+
+// GenerateSetupWithParameters generates a new setup with the modulus size of params, see GenerateSetup
+func GenerateSetupWithParameters(params *ParameterSet) (*Setup, *Trapdoor, error) {
+	bitLen := params.ModulusBits
+	if bitLen < MinSetupBitLength {
+		return nil, nil, fmt.Errorf("bit length %d is smaller than %d", bitLen, MinSetupBitLength)
+	}
+	p, err := genSafePrime(bitLen / 2)
+	if err != nil {
+		return nil, nil, err
+	}
+	q, err := genSafePrime(bitLen - bitLen/2)
+	if err != nil {
+		return nil, nil, err
+	}
+	for p.Cmp(q) == 0 {
+		if q, err = genSafePrime(bitLen - bitLen/2); err != nil {
+			return nil, nil, err
+		}
+	}
+	trapdoor := NewTrapdoor(p, q)
+	setup := &Setup{
+		N:      new(big.Int).Mul(p, q),
+		Params: params,
+	}
+	if setup.G, err = genRanQR(setup.N); err != nil {
+		return nil, nil, err
+	}
+	// get a uniform random value randomNum in the QR_N, where the order of the group is p'q'
+	randomNum, err := crand.Int(crand.Reader, trapdoor.Order())
+	if err != nil {
+		return nil, nil, err
+	}
+	setup.H = new(big.Int).Exp(setup.G, randomNum, setup.N)
+	return setup, trapdoor, nil
+}
+
+// DeriveGenerator deterministically derives a generator of Z*_N from N, as the product of a SHA-256 hash chain of N
+func DeriveGenerator(N *big.Int) (*big.Int, error) {
+	buffer := make([]big.Int, 8)
+	buffer[0].Set(SHA256ToInt([]byte(N.String()))) //g1 should be 256 bit.
+	for i := 1; i < 8; i++ {
+		buffer[i].Set(SHA256ToInt(buffer[i-1].Bytes()))
+	}
+	prod := SetProduct(buffer)
+	prod.Mod(prod, N)
+	var gcd big.Int
+	gcd.GCD(nil, nil, N, prod)
+	if gcd.Cmp(big1) != 0 {
+		return nil, errors.New("generator and N are not co-prime")
+	}
+	return prod, nil
+}
+
+// GenerateUniversalHashParameters generates the parameters P, A and B of UniversalHashToInt,
+// where P is a 256-bit prime and A, B are random in [0, P)
+func GenerateUniversalHashParameters() (p, a, b *big.Int, err error) {
+	p = getPrime256()
+	if a, err = crand.Int(crand.Reader, p); err != nil {
+		return nil, nil, nil, err
+	}
+	if b, err = crand.Int(crand.Reader, p); err != nil {
+		return nil, nil, nil, err
+	}
+	return p, a, b, nil
+}
+
+// Validate returns an error if the setup is malformed, i.e. N is not an odd number larger than 3,
+// G, H are not co-prime elements in (1, N), N does not have the modulus size of Params,
+// or the encoder is not registered
+func (setup *Setup) Validate() error {
+	if setup == nil || setup.N == nil || setup.G == nil || setup.H == nil {
+		return ErrInvalidSetup
+	}
+	if setup.N.Cmp(big3) <= 0 || setup.N.Bit(0) == 0 {
+		return ErrInvalidSetup
+	}
+	if setup.Params != nil && setup.Params.ModulusBits != setup.N.BitLen() {
+		return ErrInvalidSetup
+	}
+	if setup.Encoder != "" {
+		if _, err := EncodeTypeByName(setup.Encoder); err != nil {
+			return err
+		}
+	}
+	var gcd big.Int
+	for _, v := range []*big.Int{setup.G, setup.H} {
+		if v.Cmp(big1) <= 0 || v.Cmp(setup.N) >= 0 {
+			return ErrInvalidSetup
+		}
+		if gcd.GCD(nil, nil, v, setup.N).Cmp(big1) != 0 {
+			return ErrInvalidSetup
+		}
+	}
+	return nil
+}
+
+// Fingerprint returns the SHA-256 hash of the format version, the length-prefixed N, G and H,
+// the name of the encoder and the parameter set, which identifies the setup
+func (setup *Setup) Fingerprint() []byte {
+	return setup.fingerprint(SetupFormatVersion)
+}
+
+// fingerprint returns the fingerprint of the setup in the format version, version 1 does not include the encoder
+// and version 2 does not include the parameter set
+func (setup *Setup) fingerprint(version uint16) []byte {
+	h := sha256.New()
+	h.Write([]byte(setupMagic))
+	writeVersion(h, version)
+	for _, v := range []*big.Int{setup.N, setup.G, setup.H} {
+		writeLengthPrefixed(h, v.Bytes())
+	}
+	if version >= 2 {
+		writeLengthPrefixed(h, []byte(setup.Encoder))
+	}
+	if version >= 3 {
+		writeParameters(h, setup.Parameters())
+	}
+	return h.Sum(nil)
+}
+
+// writeParameters writes the length-prefixed name, DI offset and number of DI blocks of params
+func writeParameters(w io.Writer, params *ParameterSet) {
+	writeLengthPrefixed(w, []byte(params.Name))
+	writeLengthPrefixed(w, params.diHash.delta.Bytes())
+	writeLengthPrefixed(w, big.NewInt(int64(params.diHash.blocks)).Bytes())
+}
+
+// decodeParameters returns the parameter set recorded in a setup of bitLen bits. It returns the named parameter set
+// if it is the same, so that a loaded setup shares it, or a new parameter set otherwise, e.g. with a custom DI hash.
+func decodeParameters(name string, bitLen int, delta *big.Int, blocks int) (*ParameterSet, error) {
+	diHash, err := NewDIHash(delta, blocks)
+	if err != nil {
+		return nil, ErrInvalidSetup
+	}
+	if named, err := ParameterSetByName(name); err == nil && named.ModulusBits == bitLen && named.diHash.equal(diHash) {
+		return named, nil
+	}
+	return NewParameterSetWithDIHash(name, bitLen, diHash), nil
+}
+
+type setupJSON struct {
+	Version     int    `json:"version"`
+	BitLength   int    `json:"bitLength"`
+	N           string `json:"n"`
+	G           string `json:"g"`
+	H           string `json:"h"`
+	Encoder     string `json:"encoder,omitempty"`
+	Params      string `json:"params,omitempty"`
+	DIDelta     string `json:"diDelta,omitempty"`
+	DIBlocks    int    `json:"diBlocks,omitempty"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// MarshalJSON encodes the setup with decimal N, G, H, the encoder, the parameter set with its decimal DI offset
+// and the hex fingerprint
+func (setup *Setup) MarshalJSON() ([]byte, error) {
+	if err := setup.Validate(); err != nil {
+		return nil, err
+	}
+	params := setup.Parameters()
+	return json.Marshal(&setupJSON{
+		Version:     SetupFormatVersion,
+		BitLength:   setup.N.BitLen(),
+		N:           setup.N.String(),
+		G:           setup.G.String(),
+		H:           setup.H.String(),
+		Encoder:     setup.Encoder,
+		Params:      params.Name,
+		DIDelta:     params.diHash.delta.String(),
+		DIBlocks:    params.diHash.blocks,
+		Fingerprint: hex.EncodeToString(setup.Fingerprint()),
+	})
+}
+
+// UnmarshalJSON decodes the setup generated by MarshalJSON and checks its fingerprint
+func (setup *Setup) UnmarshalJSON(data []byte) error {
+	var s setupJSON
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s.Version < 1 || s.Version > SetupFormatVersion || (s.Version == 1 && s.Encoder != "") ||
+		(s.Version < 3 && (s.Params != "" || s.DIDelta != "" || s.DIBlocks != 0)) {
+		return ErrUnsupportedVersion
+	}
+	decoded := Setup{Encoder: s.Encoder}
+	var ok bool
+	if decoded.N, ok = new(big.Int).SetString(s.N, 10); !ok {
+		return ErrInvalidSetup
+	}
+	if decoded.G, ok = new(big.Int).SetString(s.G, 10); !ok {
+		return ErrInvalidSetup
+	}
+	if decoded.H, ok = new(big.Int).SetString(s.H, 10); !ok {
+		return ErrInvalidSetup
+	}
+	if s.Version >= 3 {
+		delta, ok := new(big.Int).SetString(s.DIDelta, 10)
+		if !ok {
+			return ErrInvalidSetup
+		}
+		params, err := decodeParameters(s.Params, decoded.N.BitLen(), delta, s.DIBlocks)
+		if err != nil {
+			return err
+		}
+		decoded.Params = params
+	}
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	if s.BitLength != decoded.N.BitLen() || s.Fingerprint != hex.EncodeToString(decoded.fingerprint(uint16(s.Version))) {
+		return ErrFingerprintMismatch
+	}
+	if decoded.Params == nil {
+		// versions 1 and 2 only have the named parameter sets
+		decoded.Params = ParametersForBitLength(s.BitLength)
+	}
+	*setup = decoded
+	return nil
+}
+
+// MarshalBinary encodes the setup as "RSAS" || uint16 version || length-prefixed N, G, H, encoder,
+// parameter set name, DI offset, number of DI blocks || fingerprint
+func (setup *Setup) MarshalBinary() ([]byte, error) {
+	if err := setup.Validate(); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(setupMagic)
+	writeVersion(&buf, SetupFormatVersion)
+	for _, v := range []*big.Int{setup.N, setup.G, setup.H} {
+		writeLengthPrefixed(&buf, v.Bytes())
+	}
+	writeLengthPrefixed(&buf, []byte(setup.Encoder))
+	writeParameters(&buf, setup.Parameters())
+	buf.Write(setup.Fingerprint())
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the setup generated by MarshalBinary and checks its fingerprint
+func (setup *Setup) UnmarshalBinary(data []byte) error {
+	version, data, err := readVersion(data, setupMagic)
+	if err != nil {
+		return err
+	}
+	count := 7
+	switch version {
+	case 1:
+		// version 1 does not record the encoder
+		count = 3
+	case 2:
+		// version 2 does not record the parameter set
+		count = 4
+	}
+	fields, rest, err := readLengthPrefixed(data, count)
+	if err != nil {
+		return err
+	}
+	decoded := Setup{
+		N: new(big.Int).SetBytes(fields[0]),
+		G: new(big.Int).SetBytes(fields[1]),
+		H: new(big.Int).SetBytes(fields[2]),
+	}
+	if version >= 2 {
+		decoded.Encoder = string(fields[3])
+	}
+	if version >= 3 {
+		blocks := new(big.Int).SetBytes(fields[6])
+		if !blocks.IsInt64() || blocks.Int64() > math.MaxInt32 {
+			return ErrInvalidSetup
+		}
+		decoded.Params, err = decodeParameters(string(fields[4]), decoded.N.BitLen(),
+			new(big.Int).SetBytes(fields[5]), int(blocks.Int64()))
+		if err != nil {
+			return err
+		}
+	}
+	if err = decoded.Validate(); err != nil {
+		return err
+	}
+	if !bytes.Equal(rest, decoded.fingerprint(version)) {
+		return ErrFingerprintMismatch
+	}
+	if decoded.Params == nil {
+		// versions 1 and 2 only have the named parameter sets
+		decoded.Params = ParametersForBitLength(decoded.N.BitLen())
+	}
+	*setup = decoded
+	return nil
+}
+
+type trapdoorJSON struct {
+	Version int    `json:"version"`
+	P       string `json:"p"`
+	Q       string `json:"q"`
+}
+
+// MarshalJSON encodes the trapdoor with decimal P and Q
+func (t *Trapdoor) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&trapdoorJSON{
+		Version: SetupFormatVersion,
+		P:       t.P.String(),
+		Q:       t.Q.String(),
+	})
+}
+
+// UnmarshalJSON decodes the trapdoor generated by MarshalJSON
+func (t *Trapdoor) UnmarshalJSON(data []byte) error {
+	var s trapdoorJSON
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s.Version < 1 || s.Version > SetupFormatVersion {
+		return ErrUnsupportedVersion
+	}
+	p, ok := new(big.Int).SetString(s.P, 10)
+	if !ok {
+		return errors.New("invalid trapdoor")
+	}
+	q, ok := new(big.Int).SetString(s.Q, 10)
+	if !ok {
+		return errors.New("invalid trapdoor")
+	}
+	*t = *NewTrapdoor(p, q)
+	return nil
+}
+
+// MarshalBinary encodes the trapdoor as "RSAT" || uint16 version || length-prefixed P, Q
+func (t *Trapdoor) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(trapdoorMagic)
+	writeVersion(&buf, SetupFormatVersion)
+	writeLengthPrefixed(&buf, t.P.Bytes())
+	writeLengthPrefixed(&buf, t.Q.Bytes())
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the trapdoor generated by MarshalBinary
+func (t *Trapdoor) UnmarshalBinary(data []byte) error {
+	_, data, err := readVersion(data, trapdoorMagic)
+	if err != nil {
+		return err
+	}
+	fields, rest, err := readLengthPrefixed(data, 2)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return errors.New("invalid trapdoor, trailing bytes")
+	}
+	*t = *NewTrapdoor(new(big.Int).SetBytes(fields[0]), new(big.Int).SetBytes(fields[1]))
+	return nil
+}
+
+// Matches returns true if the trapdoor is the factorization of the modulus of setup
+func (t *Trapdoor) Matches(setup *Setup) bool {
+	return new(big.Int).Mul(t.P, t.Q).Cmp(setup.N) == 0
+}
+
+type accumulatorJSON struct {
+	Version  int      `json:"version"`
+	Setup    *Setup   `json:"setup"`
+	Encoder  string   `json:"encoder"`
+	Elements []string `json:"elements"`
+	Value    string   `json:"value"`
+}
+
+// MarshalJSON encodes the accumulator with its setup, the name of its encoder, the accumulated elements
+// and the decimal accumulator value
+func (acc *Accumulator) MarshalJSON() ([]byte, error) {
+	value, err := acc.rsaValue()
+	if err != nil {
+		return nil, err
+	}
+	name, err := acc.encodeType.Name()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&accumulatorJSON{
+		Version:  SetupFormatVersion,
+		Setup:    acc.setup,
+		Encoder:  name,
+		Elements: elementStrings(acc.elements),
+		Value:    value.String(),
+	})
+}
+
+// UnmarshalJSON decodes the accumulator generated by MarshalJSON. The representatives are generated again
+// with the recorded encoder, and the accumulator value must match them.
+func (acc *Accumulator) UnmarshalJSON(data []byte) error {
+	var s accumulatorJSON
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s.Version < 2 || s.Version > SetupFormatVersion {
+		return ErrUnsupportedVersion
+	}
+	if s.Setup == nil {
+		return ErrInvalidSetup
+	}
+	encodeType, err := EncodeTypeByName(s.Encoder)
+	if err != nil {
+		return err
+	}
+	value, ok := new(big.Int).SetString(s.Value, 10)
+	if !ok {
+		return errors.New("invalid accumulator value")
+	}
+	decoded, err := NewAccumulatorFromSet(s.Setup, encodeType, s.Elements)
+	if err != nil {
+		return err
+	}
+	if decoded.Value().Cmp(value) != 0 {
+		return errors.New("accumulator value does not match the elements")
+	}
+	*acc = *decoded
+	return nil
+}
+
+// SaveSetup writes the setup to the file at path, in JSON if path ends with ".json" and in binary otherwise
+func SaveSetup(path string, setup *Setup) error {
+	data, err := marshalByExtension(path, setup)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadSetup reads the setup written by SaveSetup, the format is detected from the content of the file
+func LoadSetup(path string) (*Setup, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	setup := new(Setup)
+	if err = unmarshalByContent(data, setup); err != nil {
+		return nil, err
+	}
+	return setup, nil
+}
+
+// SaveTrapdoor writes the trapdoor to the file at path, readable by the owner only,
+// in JSON if path ends with ".json" and in binary otherwise
+func SaveTrapdoor(path string, trapdoor *Trapdoor) error {
+	data, err := marshalByExtension(path, trapdoor)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// LoadTrapdoor reads the trapdoor written by SaveTrapdoor, the format is detected from the content of the file
+func LoadTrapdoor(path string) (*Trapdoor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	trapdoor := new(Trapdoor)
+	if err = unmarshalByContent(data, trapdoor); err != nil {
+		return nil, err
+	}
+	return trapdoor, nil
+}
+
+type binaryJSONMarshaler interface {
+	json.Marshaler
+	MarshalBinary() ([]byte, error)
+}
+
+type binaryJSONUnmarshaler interface {
+	json.Unmarshaler
+	UnmarshalBinary(data []byte) error
+}
+
+func marshalByExtension(path string, v binaryJSONMarshaler) ([]byte, error) {
+	if strings.HasSuffix(path, ".json") {
+		return json.MarshalIndent(v, "", "  ")
+	}
+	return v.MarshalBinary()
+}
+
+func unmarshalByContent(data []byte, v binaryJSONUnmarshaler) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return json.Unmarshal(trimmed, v)
+	}
+	return v.UnmarshalBinary(data)
+}
+
+// writeLengthPrefixed writes the uint32 big-endian length of data followed by data
+func writeLengthPrefixed(w io.Writer, data []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+	_, _ = w.Write(length[:])
+	_, _ = w.Write(data)
+}
+
+// writeVersion writes the uint16 big-endian format version
+func writeVersion(w io.Writer, version uint16) {
+	var buf [2]byte
+	binary.BigEndian.PutUint16(buf[:], version)
+	_, _ = w.Write(buf[:])
+}
+
+// readVersion checks the magic of data and returns the supported format version together with the remaining bytes
+func readVersion(data []byte, magic string) (uint16, []byte, error) {
+	if len(data) < len(magic)+2 || string(data[:len(magic)]) != magic {
+		return 0, nil, errors.New("invalid binary encoding, wrong magic")
+	}
+	data = data[len(magic):]
+	version := binary.BigEndian.Uint16(data[:2])
+	if version < 1 || version > SetupFormatVersion {
+		return 0, nil, ErrUnsupportedVersion
+	}
+	return version, data[2:], nil
+}
+
+// readLengthPrefixed reads count length-prefixed fields written by writeLengthPrefixed.
+// It returns the fields and the remaining bytes.
+func readLengthPrefixed(data []byte, count int) ([][]byte, []byte, error) {
+	ret := make([][]byte, count)
+	for i := range ret {
+		if len(data) < 4 {
+			return nil, nil, errors.New("invalid binary encoding, truncated")
+		}
+		length := binary.BigEndian.Uint32(data[:4])
+		data = data[4:]
+		if uint64(len(data)) < uint64(length) {
+			return nil, nil, errors.New("invalid binary encoding, truncated")
+		}
+		ret[i] = data[:length]
+		data = data[length:]
+	}
+	return ret, data, nil
+}
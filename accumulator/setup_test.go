@@ -0,0 +1,176 @@
+package accumulator
+
+import (
+	"encoding/json"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateSetup(t *testing.T) {
+	if _, _, err := GenerateSetup(MinSetupBitLength - 1); err == nil {
+		t.Errorf("GenerateSetup should reject a too small bit length")
+	}
+	for _, bitLen := range []int{128, 255} {
+		setup, trapdoor, err := GenerateSetup(bitLen)
+		if err != nil {
+			t.Fatalf("GenerateSetup returns error: %v", err)
+		}
+		if setup.N.BitLen() != bitLen {
+			t.Errorf("bit length of N = %d, want %d", setup.N.BitLen(), bitLen)
+		}
+		if !trapdoor.Matches(setup) {
+			t.Errorf("trapdoor does not match the setup")
+		}
+		if err = setup.Validate(); err != nil {
+			t.Errorf("generated setup is invalid: %v", err)
+		}
+		var temp big.Int
+		for _, v := range []*big.Int{setup.G, setup.H} {
+			if temp.Exp(v, trapdoor.Order(), setup.N).Cmp(big1) != 0 {
+				t.Errorf("generator is not in QR_N")
+			}
+		}
+	}
+}
+
+func TestSetupEncoding(t *testing.T) {
+	setup, trapdoor, err := GenerateSetup(128)
+	if err != nil {
+		t.Fatalf("GenerateSetup returns error: %v", err)
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"setup.json", "setup.bin"} {
+		path := filepath.Join(dir, name)
+		if err = SaveSetup(path, setup); err != nil {
+			t.Fatalf("SaveSetup returns error: %v", err)
+		}
+		loaded, err := LoadSetup(path)
+		if err != nil {
+			t.Fatalf("LoadSetup returns error: %v", err)
+		}
+		if loaded.N.Cmp(setup.N) != 0 || loaded.G.Cmp(setup.G) != 0 || loaded.H.Cmp(setup.H) != 0 {
+			t.Errorf("loaded setup is not the saved one")
+		}
+		path = filepath.Join(dir, "trapdoor."+name)
+		if err = SaveTrapdoor(path, trapdoor); err != nil {
+			t.Fatalf("SaveTrapdoor returns error: %v", err)
+		}
+		loadedTrapdoor, err := LoadTrapdoor(path)
+		if err != nil {
+			t.Fatalf("LoadTrapdoor returns error: %v", err)
+		}
+		if !loadedTrapdoor.Matches(setup) || loadedTrapdoor.Phi.Cmp(trapdoor.Phi) != 0 {
+			t.Errorf("loaded trapdoor is not the saved one")
+		}
+	}
+
+	// the hard-coded setup can be persisted as well
+	data, err := json.Marshal(TrustedSetup())
+	if err != nil {
+		t.Fatalf("MarshalJSON returns error: %v", err)
+	}
+	var decoded Setup
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("UnmarshalJSON returns error: %v", err)
+	}
+	if decoded.N.String() != N2048String {
+		t.Errorf("decoded N is not N2048String")
+	}
+	// tampering with G is detected by the fingerprint
+	tampered := strings.Replace(string(data), G2048String, H2048String, 1)
+	if err = json.Unmarshal([]byte(tampered), &decoded); err != ErrFingerprintMismatch {
+		t.Errorf("UnmarshalJSON should return ErrFingerprintMismatch, got %v", err)
+	}
+	bin, err := setup.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary returns error: %v", err)
+	}
+	bin[len(bin)-1] ^= 1
+	if err = decoded.UnmarshalBinary(bin); err != ErrFingerprintMismatch {
+		t.Errorf("UnmarshalBinary should return ErrFingerprintMismatch, got %v", err)
+	}
+	bin[5]++
+	if err = decoded.UnmarshalBinary(bin); err != ErrUnsupportedVersion {
+		t.Errorf("UnmarshalBinary should return ErrUnsupportedVersion, got %v", err)
+	}
+}
+
+func TestDeriveGenerator(t *testing.T) {
+	setup := TrustedSetup()
+	g, err := DeriveGenerator(setup.N)
+	if err != nil {
+		t.Fatalf("DeriveGenerator returns error: %v", err)
+	}
+	if g2, _ := DeriveGenerator(setup.N); g.Cmp(g2) != 0 {
+		t.Errorf("DeriveGenerator is not deterministic")
+	}
+}
+
+func TestSetupEncodingParameters(t *testing.T) {
+	diHash, err := NewDIHashWithOutputBits(1024)
+	if err != nil {
+		t.Fatalf("NewDIHashWithOutputBits returns error: %v", err)
+	}
+	setup := *TrustedSetup()
+	setup.Params = NewParameterSetWithDIHash("2048-di1024", 2048, diHash)
+	set := GenBenchSet(4)
+	rep, err := setup.GenRepresentatives(set, DIHashFromPoseidon)
+	if err != nil {
+		t.Fatalf("GenRepresentatives returns error: %v", err)
+	}
+
+	data, err := json.Marshal(&setup)
+	if err != nil {
+		t.Fatalf("MarshalJSON returns error: %v", err)
+	}
+	bin, err := setup.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary returns error: %v", err)
+	}
+	var fromJSON, fromBinary Setup
+	if err = json.Unmarshal(data, &fromJSON); err != nil {
+		t.Fatalf("UnmarshalJSON returns error: %v", err)
+	}
+	if err = fromBinary.UnmarshalBinary(bin); err != nil {
+		t.Fatalf("UnmarshalBinary returns error: %v", err)
+	}
+	for _, decoded := range []*Setup{&fromJSON, &fromBinary} {
+		params := decoded.Parameters()
+		if params.Name != "2048-di1024" || params.DIHash().OutputBits() != 1024 || params.DIHash().Blocks() != diHash.Blocks() {
+			t.Errorf("decoded parameter set is not the saved one")
+		}
+		decodedRep, err := decoded.GenRepresentatives(set, DIHashFromPoseidon)
+		if err != nil {
+			t.Fatalf("GenRepresentatives returns error: %v", err)
+		}
+		for i := range rep {
+			if decodedRep[i].Cmp(rep[i]) != 0 {
+				t.Errorf("representatives of the decoded setup are different")
+			}
+		}
+	}
+
+	// the parameter set is covered by the fingerprint
+	tampered := strings.Replace(string(data), `"diBlocks":4`, `"diBlocks":3`, 1)
+	if err = json.Unmarshal([]byte(tampered), new(Setup)); err != ErrFingerprintMismatch {
+		t.Errorf("UnmarshalJSON should return ErrFingerprintMismatch, got %v", err)
+	}
+	tampered = strings.Replace(string(data), `"params":"2048-di1024"`, `"params":"2048"`, 1)
+	if err = json.Unmarshal([]byte(tampered), new(Setup)); err != ErrFingerprintMismatch {
+		t.Errorf("UnmarshalJSON should return ErrFingerprintMismatch, got %v", err)
+	}
+
+	// a named parameter set is shared by the decoded setup
+	setup.Params = Params2048
+	if data, err = json.Marshal(&setup); err != nil {
+		t.Fatalf("MarshalJSON returns error: %v", err)
+	}
+	if err = json.Unmarshal(data, &fromJSON); err != nil {
+		t.Fatalf("UnmarshalJSON returns error: %v", err)
+	}
+	if fromJSON.Params != Params2048 {
+		t.Errorf("decoded setup should use Params2048")
+	}
+}
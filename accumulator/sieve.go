@@ -0,0 +1,95 @@
+package accumulator
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+const (
+	// sievePrimeLimit bounds the small primes used to sieve the candidates of the hash-to-prime search
+	sievePrimeLimit = 1 << 12
+	// wheelModulus is 2*3*5*7, the candidates not co-prime with it are rejected by a table lookup
+	wheelModulus = 210
+)
+
+// primeGroup is a group of small primes whose product fits in a uint64, so that a candidate is reduced
+// modulo the product once and then modulo every prime with machine words
+type primeGroup struct {
+	product uint64
+	primes  []uint64
+}
+
+var (
+	wheel       [wheelModulus]bool // wheel[r] is true if r is co-prime with wheelModulus
+	primeGroups []primeGroup       // the primes from 11 to sievePrimeLimit
+)
+
+func init() {
+	for r := range wheel {
+		wheel[r] = r%2 != 0 && r%3 != 0 && r%5 != 0 && r%7 != 0
+	}
+	composite := make([]bool, sievePrimeLimit)
+	group := primeGroup{product: 1}
+	for p := 2; p < sievePrimeLimit; p++ {
+		if composite[p] {
+			continue
+		}
+		for q := p * p; q < sievePrimeLimit; q += p {
+			composite[q] = true
+		}
+		if p <= 7 {
+			continue
+		}
+		if hi, _ := bits.Mul64(group.product, uint64(p)); hi != 0 {
+			primeGroups = append(primeGroups, group)
+			group = primeGroup{product: 1}
+		}
+		group.product *= uint64(p)
+		group.primes = append(group.primes, uint64(p))
+	}
+	primeGroups = append(primeGroups, group)
+}
+
+// modUint64 returns x mod m for a non-negative x
+func modUint64(x *big.Int, m uint64) uint64 {
+	words := x.Bits()
+	var rem uint64
+	for i := len(words) - 1; i >= 0; i-- {
+		if bits.UintSize == 64 {
+			_, rem = bits.Div64(rem, uint64(words[i]), m)
+			continue
+		}
+		// rem < m, so rem * 2^32 + word fits in the 128-bit dividend
+		_, rem = bits.Div64(rem>>32, rem<<32|uint64(words[i]), m)
+	}
+	return rem
+}
+
+// hasSmallFactor returns true if x > sievePrimeLimit is divisible by a prime smaller than sievePrimeLimit
+func hasSmallFactor(x *big.Int) bool {
+	if !wheel[modUint64(x, wheelModulus)] {
+		return true
+	}
+	for _, group := range primeGroups {
+		rem := modUint64(x, group.product)
+		for _, p := range group.primes {
+			if rem%p == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// probablyPrimeSieved returns the same as x.ProbablyPrime(securityParaHashToPrime) for a non-negative x, but most
+// composites are rejected by the wheel and the trial division before any Miller-Rabin round.
+// It never rejects a prime, so the outputs of the hash-to-prime search are exactly the same.
+func probablyPrimeSieved(x *big.Int) bool {
+	if x.Cmp(big.NewInt(sievePrimeLimit)) <= 0 {
+		return x.ProbablyPrime(securityParaHashToPrime)
+	}
+	if hasSmallFactor(x) {
+		return false
+	}
+	return x.ProbablyPrime(securityParaHashToPrime)
+}
@@ -0,0 +1,53 @@
+package accumulator
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"strconv"
+	"testing"
+)
+
+// hashToPrimeReference is HashToPrime without sieving, as it was implemented before
+func hashToPrimeReference(input []byte) *big.Int {
+	var ret big.Int
+	hashTemp := sha256.Sum256(input)
+	ret.SetBytes(hashTemp[:])
+	for !ret.ProbablyPrime(securityParaHashToPrime) {
+		hashTemp = sha256.Sum256(hashTemp[:])
+		ret.SetBytes(hashTemp[:])
+	}
+	return &ret
+}
+
+func TestHashToPrimeSieved(t *testing.T) {
+	for i := 0; i < 500; i++ {
+		input := []byte(strconv.Itoa(i))
+		if HashToPrime(input).Cmp(hashToPrimeReference(input)) != 0 {
+			t.Fatalf("sieved HashToPrime(%q) is not the same as before", input)
+		}
+	}
+}
+
+func TestProbablyPrimeSieved(t *testing.T) {
+	for i := int64(0); i < 3*sievePrimeLimit; i++ {
+		x := big.NewInt(i)
+		if probablyPrimeSieved(x) != x.ProbablyPrime(securityParaHashToPrime) {
+			t.Fatalf("probablyPrimeSieved(%d) is wrong", i)
+		}
+	}
+	x := new(big.Int).Lsh(big1, 255)
+	for i := 0; i < 2000; i++ {
+		x.Add(x, big1)
+		if probablyPrimeSieved(x) != x.ProbablyPrime(securityParaHashToPrime) {
+			t.Fatalf("probablyPrimeSieved(%s) is wrong", x)
+		}
+	}
+	// a product of two primes just below the sieve limit
+	p := big.NewInt(4093)
+	if probablyPrimeSieved(new(big.Int).Mul(p, p)) {
+		t.Errorf("4093^2 passes probablyPrimeSieved")
+	}
+	if modUint64(x, 1000003) != new(big.Int).Mod(x, big.NewInt(1000003)).Uint64() {
+		t.Errorf("modUint64 is wrong")
+	}
+}
@@ -0,0 +1,375 @@
+package accumulator
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/jiajunxin/rsa_accumulator/group"
+)
+
+var (
+	// ErrElementExists is returned when adding an element that is already accumulated
+	ErrElementExists = errors.New("element already accumulated")
+	// ErrElementNotFound is returned when deleting an element that is not accumulated
+	ErrElementNotFound = errors.New("element not accumulated")
+	// ErrNotRSASetup is returned by the functions of an Accumulator which need the RSA setup,
+	// when the accumulator is created by NewGroupAccumulator
+	ErrNotRSASetup = errors.New("accumulator has no RSA setup")
+)
+
+// Accumulator is a stateful accumulator in a hidden order group, the RSA group of a setup or e.g. a class group.
+// It owns the current accumulator value, the accumulated elements together with their representatives
+// and the encode type, so that the set can be changed incrementally instead of being rebuilt every time.
+type Accumulator struct {
+	setup      *Setup        // nil for an accumulator created by NewGroupAccumulator
+	params     *ParameterSet // the parameter set of the representatives
+	grp        group.Group
+	base       group.Element
+	encodeType EncodeType
+	value      group.Element
+	elements   []Element      // accumulated elements, in the order they were added
+	reps       []*big.Int     // reps[i] is the representative of elements[i]
+	index      map[string]int // string(element) -> position in elements
+}
+
+// NewAccumulator returns an empty accumulator in the RSA group of the setup, whose value is the generator G of the setup
+func NewAccumulator(setup *Setup, encodeType EncodeType) *Accumulator {
+	acc := NewGroupAccumulator(setup.Group(), new(big.Int).Set(setup.G), setup.Parameters(), encodeType)
+	acc.setup = setup
+	return acc
+}
+
+// NewGroupAccumulator returns an empty accumulator in grp, whose value is base, e.g. a class group accumulator
+// without trusted setup. The representatives are generated for params, Params2048 if params is nil.
+// It has no RSA setup, so the non-membership proofs, the trapdoor, the update messages and the JSON encoding
+// return ErrNotRSASetup, and Value and ProveMembership return nil unless grp is an RSA group.
+func NewGroupAccumulator(grp group.Group, base group.Element, params *ParameterSet, encodeType EncodeType) *Accumulator {
+	if params == nil {
+		params = Params2048
+	}
+	return &Accumulator{
+		params:     params,
+		grp:        grp,
+		base:       base,
+		encodeType: encodeType,
+		value:      base,
+		index:      make(map[string]int),
+	}
+}
+
+// NewGroupAccumulatorFromElements returns an accumulator in grp with all the elements accumulated,
+// see NewGroupAccumulator
+func NewGroupAccumulatorFromElements(grp group.Group, base group.Element, params *ParameterSet, encodeType EncodeType,
+	elements []Element) (*Accumulator, error) {
+	acc := NewGroupAccumulator(grp, base, params, encodeType)
+	if err := acc.AddElements(elements...); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+// NewAccumulatorFromElements returns an accumulator with all the elements accumulated
+func NewAccumulatorFromElements(setup *Setup, encodeType EncodeType, elements []Element) (*Accumulator, error) {
+	acc := NewAccumulator(setup, encodeType)
+	if err := acc.AddElements(elements...); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+// NewAccumulatorFromSet is NewAccumulatorFromElements for the bytes of the strings in set
+func NewAccumulatorFromSet(setup *Setup, encodeType EncodeType, set []string) (*Accumulator, error) {
+	return NewAccumulatorFromElements(setup, encodeType, StringElements(set))
+}
+
+// NewAccumulatorFromRecords returns an accumulator with the canonical encodings of all the records accumulated
+func NewAccumulatorFromRecords(setup *Setup, encodeType EncodeType, records []Record) (*Accumulator, error) {
+	acc := NewAccumulator(setup, encodeType)
+	if err := acc.AddRecords(records...); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+// Setup returns the setup of the accumulator, nil for an accumulator created by NewGroupAccumulator
+func (acc *Accumulator) Setup() *Setup {
+	return acc.setup
+}
+
+// Group returns the group of the accumulator
+func (acc *Accumulator) Group() group.Group {
+	return acc.grp
+}
+
+// Base returns the value of the empty accumulator, the generator G for an accumulator in the RSA group of a setup.
+// It is shared with the accumulator and must not be modified.
+func (acc *Accumulator) Base() group.Element {
+	return acc.base
+}
+
+// EncodeType returns the encode type used to generate the representatives
+func (acc *Accumulator) EncodeType() EncodeType {
+	return acc.encodeType
+}
+
+// Value returns a copy of the current accumulator value in the RSA group, nil for an accumulator in another group
+func (acc *Accumulator) Value() *big.Int {
+	x, ok := acc.value.(*big.Int)
+	if !ok {
+		return nil
+	}
+	return new(big.Int).Set(x)
+}
+
+// GroupValue returns the current accumulator value in the group of the accumulator.
+// It is shared with the accumulator and must not be modified.
+func (acc *Accumulator) GroupValue() group.Element {
+	return acc.value
+}
+
+// Size returns the number of accumulated elements
+func (acc *Accumulator) Size() int {
+	return len(acc.elements)
+}
+
+// ContainsElement returns true if the element is accumulated
+func (acc *Accumulator) ContainsElement(element Element) bool {
+	_, ok := acc.index[string(element)]
+	return ok
+}
+
+// Contains is ContainsElement for the bytes of the string
+func (acc *Accumulator) Contains(element string) bool {
+	_, ok := acc.index[element]
+	return ok
+}
+
+// AccumulatedElements returns a copy of the accumulated elements, in the order they were added
+func (acc *Accumulator) AccumulatedElements() []Element {
+	ret := make([]Element, len(acc.elements))
+	for i, v := range acc.elements {
+		ret[i] = append(Element(nil), v...)
+	}
+	return ret
+}
+
+// Elements returns the accumulated elements as strings, in the order they were added
+func (acc *Accumulator) Elements() []string {
+	return elementStrings(acc.elements)
+}
+
+// Representatives returns the representatives of the accumulated elements, in the same order as Elements
+func (acc *Accumulator) Representatives() []*big.Int {
+	ret := make([]*big.Int, len(acc.reps))
+	copy(ret, acc.reps)
+	return ret
+}
+
+// Representative returns the representative of an accumulated element
+func (acc *Accumulator) Representative(element string) (*big.Int, error) {
+	return acc.ElementRepresentative(Element(element))
+}
+
+// ElementRepresentative returns the representative of an accumulated element
+func (acc *Accumulator) ElementRepresentative(element Element) (*big.Int, error) {
+	idx, ok := acc.index[string(element)]
+	if !ok {
+		return nil, ErrElementNotFound
+	}
+	return acc.reps[idx], nil
+}
+
+// ProveMembership pre-computes the membership proofs of all the accumulated elements in the RSA group,
+// in the same order as Elements. It returns nil for an accumulator in another group, see GroupProveMembership.
+func (acc *Accumulator) ProveMembership() []*big.Int {
+	if _, ok := acc.grp.(*group.RSAGroup); !ok {
+		return nil
+	}
+	return elementInts(acc.GroupProveMembership())
+}
+
+// GroupProveMembership pre-computes the membership proofs of all the accumulated elements in the group of
+// the accumulator, in the same order as Elements. It takes the product tree and the fourfold exponentiations
+// as ProveMembership in any group, see ProductTree.GroupProveMembership.
+func (acc *Accumulator) GroupProveMembership() []group.Element {
+	return NewProductTree(acc.reps).GroupProveMembership(acc.grp, acc.base)
+}
+
+// AddElements accumulates new elements with one exponentiation by the product of their representatives.
+// The accumulator is not changed if any of the elements is already accumulated.
+func (acc *Accumulator) AddElements(elements ...Element) error {
+	if len(elements) == 0 {
+		return nil
+	}
+	if err := acc.checkAbsent(elements); err != nil {
+		return err
+	}
+	rep, err := acc.encode(elements)
+	if err != nil {
+		return err
+	}
+	acc.value = acc.grp.Exp(acc.value, SetProductRecursiveFast(rep))
+	acc.insert(elements, rep)
+	return nil
+}
+
+// Add is AddElements for the bytes of the strings
+func (acc *Accumulator) Add(elements ...string) error {
+	return acc.AddElements(StringElements(elements)...)
+}
+
+// AddRecords accumulates the canonical encodings of the records, it returns an error wrapping ErrInvalidElement
+// and leaves the accumulator unchanged if any of the records cannot be encoded
+func (acc *Accumulator) AddRecords(records ...Record) error {
+	elements, err := EncodeRecords(records)
+	if err != nil {
+		return err
+	}
+	return acc.AddElements(elements...)
+}
+
+// DeleteElements removes elements from the accumulator by recomputing the accumulator value
+// from the remaining set. The accumulator is not changed if any of the elements is not accumulated.
+func (acc *Accumulator) DeleteElements(elements ...Element) error {
+	if len(elements) == 0 {
+		return nil
+	}
+	if err := acc.checkPresent(elements); err != nil {
+		return err
+	}
+	acc.remove(elements)
+	acc.recompute()
+	return nil
+}
+
+// Delete is DeleteElements for the bytes of the strings
+func (acc *Accumulator) Delete(elements ...string) error {
+	return acc.DeleteElements(StringElements(elements)...)
+}
+
+// UpdateElements removes the elements in removed and accumulates the elements in inserted.
+// The accumulator value is recomputed only once for the whole update.
+// The accumulator is not changed if the update is invalid.
+func (acc *Accumulator) UpdateElements(removed, inserted []Element) error {
+	if len(removed) == 0 {
+		return acc.AddElements(inserted...)
+	}
+	if err := acc.checkPresent(removed); err != nil {
+		return err
+	}
+	// an element can be removed and inserted in the same update
+	removedSet := make(map[string]struct{}, len(removed))
+	for _, v := range removed {
+		removedSet[string(v)] = struct{}{}
+	}
+	seen := make(map[string]struct{}, len(inserted))
+	for _, v := range inserted {
+		if _, ok := seen[string(v)]; ok {
+			return ErrElementExists
+		}
+		seen[string(v)] = struct{}{}
+		if _, ok := removedSet[string(v)]; ok {
+			continue
+		}
+		if acc.ContainsElement(v) {
+			return ErrElementExists
+		}
+	}
+	rep, err := acc.encode(inserted)
+	if err != nil {
+		return err
+	}
+	acc.remove(removed)
+	acc.insert(inserted, rep)
+	acc.recompute()
+	return nil
+}
+
+// Update is UpdateElements for the bytes of the strings
+func (acc *Accumulator) Update(removed, inserted []string) error {
+	return acc.UpdateElements(StringElements(removed), StringElements(inserted))
+}
+
+// recompute sets the accumulator value to base^{product of all the representatives}
+func (acc *Accumulator) recompute() {
+	acc.value = acc.grp.Exp(acc.base, SetProductRecursiveFast(acc.reps))
+}
+
+// encode generates the representatives of the elements, with the setup if the accumulator has one
+func (acc *Accumulator) encode(elements []Element) ([]*big.Int, error) {
+	if acc.setup != nil {
+		return acc.setup.EncodeElements(elements, acc.encodeType)
+	}
+	return acc.params.EncodeElements(elements, acc.encodeType)
+}
+
+// rsaValue returns the accumulator value in the RSA group of the setup, or ErrNotRSASetup
+func (acc *Accumulator) rsaValue() (*big.Int, error) {
+	if acc.setup == nil {
+		return nil, ErrNotRSASetup
+	}
+	return acc.value.(*big.Int), nil
+}
+
+func (acc *Accumulator) insert(elements []Element, rep []*big.Int) {
+	for i, v := range elements {
+		// copy the element, so that the caller can reuse its buffer
+		v = append(Element(nil), v...)
+		acc.index[string(v)] = len(acc.elements)
+		acc.elements = append(acc.elements, v)
+		acc.reps = append(acc.reps, rep[i])
+	}
+}
+
+// remove deletes the elements from the set while keeping the order of the remaining ones
+func (acc *Accumulator) remove(elements []Element) {
+	for _, v := range elements {
+		delete(acc.index, string(v))
+	}
+	elementsLeft := acc.elements[:0]
+	repsLeft := acc.reps[:0]
+	for i, v := range acc.elements {
+		if _, ok := acc.index[string(v)]; !ok {
+			continue
+		}
+		acc.index[string(v)] = len(elementsLeft)
+		elementsLeft = append(elementsLeft, v)
+		repsLeft = append(repsLeft, acc.reps[i])
+	}
+	for i := len(repsLeft); i < len(acc.reps); i++ {
+		acc.elements[i] = nil
+		acc.reps[i] = nil
+	}
+	acc.elements = elementsLeft
+	acc.reps = repsLeft
+}
+
+// checkAbsent returns an error if any of the elements is accumulated or appears twice
+func (acc *Accumulator) checkAbsent(elements []Element) error {
+	seen := make(map[string]struct{}, len(elements))
+	for _, v := range elements {
+		if _, ok := seen[string(v)]; ok {
+			return ErrElementExists
+		}
+		seen[string(v)] = struct{}{}
+		if acc.ContainsElement(v) {
+			return ErrElementExists
+		}
+	}
+	return nil
+}
+
+// checkPresent returns an error if any of the elements is not accumulated or appears twice
+func (acc *Accumulator) checkPresent(elements []Element) error {
+	seen := make(map[string]struct{}, len(elements))
+	for _, v := range elements {
+		if _, ok := seen[string(v)]; ok {
+			return ErrElementNotFound
+		}
+		seen[string(v)] = struct{}{}
+		if !acc.ContainsElement(v) {
+			return ErrElementNotFound
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package accumulator
+
+import (
+	"testing"
+)
+
+func TestAccumulatorAdd(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(16)
+	acc := NewAccumulator(setup, HashToPrimeFromSha256)
+	if acc.Value().Cmp(setup.G) != 0 {
+		t.Errorf("empty accumulator should be the generator")
+	}
+	if err := acc.Add(set[:10]...); err != nil {
+		t.Errorf("Add returns error: %v", err)
+	}
+	if err := acc.Add(set[10:]...); err != nil {
+		t.Errorf("Add returns error: %v", err)
+	}
+	if acc.Size() != len(set) {
+		t.Errorf("Size() = %d, want %d", acc.Size(), len(set))
+	}
+	rep := GenRepresentatives(set, HashToPrimeFromSha256)
+	want := accumulateNew(setup.G, setup.N, rep)
+	if acc.Value().Cmp(want) != 0 {
+		t.Errorf("incremental Add is not consistent with accumulating the whole set")
+	}
+	if err := acc.Add(set[3]); err != ErrElementExists {
+		t.Errorf("adding an accumulated element should return ErrElementExists, got %v", err)
+	}
+	if acc.Value().Cmp(want) != 0 {
+		t.Errorf("failed Add should not change the accumulator")
+	}
+
+	proofs := acc.ProveMembership()
+	for i := range proofs {
+		if AccumulateNew(proofs[i], rep[i], setup.N).Cmp(want) != 0 {
+			t.Errorf("membership proof %d is not valid", i)
+		}
+	}
+}
+
+func TestAccumulatorDelete(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(12)
+	acc, err := NewAccumulatorFromSet(setup, HashToPrimeFromSha256, set)
+	if err != nil {
+		t.Fatalf("NewAccumulatorFromSet returns error: %v", err)
+	}
+	if err = acc.Delete(set[2], set[7]); err != nil {
+		t.Errorf("Delete returns error: %v", err)
+	}
+	if acc.Contains(set[2]) || acc.Contains(set[7]) || !acc.Contains(set[3]) {
+		t.Errorf("Contains is not consistent after Delete")
+	}
+	remaining := append(append(append([]string{}, set[:2]...), set[3:7]...), set[8:]...)
+	want := accumulateNew(setup.G, setup.N, GenRepresentatives(remaining, HashToPrimeFromSha256))
+	if acc.Value().Cmp(want) != 0 {
+		t.Errorf("Delete is not consistent with accumulating the remaining set")
+	}
+	elements := acc.Elements()
+	for i := range remaining {
+		if elements[i] != remaining[i] {
+			t.Errorf("Delete does not keep the order of the remaining elements")
+		}
+	}
+	if err = acc.Delete(set[2]); err != ErrElementNotFound {
+		t.Errorf("deleting a missing element should return ErrElementNotFound, got %v", err)
+	}
+}
+
+func TestAccumulatorUpdate(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(20)
+	acc, err := NewAccumulatorFromSet(setup, HashToPrimeFromSha256, set[:10])
+	if err != nil {
+		t.Fatalf("NewAccumulatorFromSet returns error: %v", err)
+	}
+	// set[4] is removed and inserted again in the same update
+	if err = acc.Update([]string{set[1], set[4]}, append([]string{set[4]}, set[10:]...)); err != nil {
+		t.Errorf("Update returns error: %v", err)
+	}
+	remaining := append(append([]string{set[0]}, set[2:4]...), set[5:]...)
+	remaining = append(remaining, set[4])
+	want := accumulateNew(setup.G, setup.N, GenRepresentatives(remaining, HashToPrimeFromSha256))
+	if acc.Value().Cmp(want) != 0 {
+		t.Errorf("Update is not consistent with accumulating the updated set")
+	}
+
+	// invalid updates should not change the accumulator
+	if err = acc.Update([]string{set[1]}, nil); err != ErrElementNotFound {
+		t.Errorf("removing a missing element should return ErrElementNotFound, got %v", err)
+	}
+	if err = acc.Update([]string{set[0]}, []string{set[2]}); err != ErrElementExists {
+		t.Errorf("inserting an accumulated element should return ErrElementExists, got %v", err)
+	}
+	if acc.Value().Cmp(want) != 0 || acc.Size() != len(remaining) {
+		t.Errorf("failed Update should not change the accumulator")
+	}
+}
@@ -0,0 +1,242 @@
+package accumulator
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/jiajunxin/multiexp"
+	"github.com/remyoudompheng/bigfft"
+)
+
+// witnessIndexBytes is the size of the index at the beginning of a witness record
+const witnessIndexBytes = 8
+
+// ErrInvalidWitness is returned when a witness does not fit into a witness record
+var ErrInvalidWitness = errors.New("invalid witness")
+
+// WitnessSink receives the membership witnesses one by one, as soon as they are computed
+type WitnessSink interface {
+	// WriteWitness receives the witness of the index-th representative, the pre-computation stops at the first error
+	WriteWitness(index int, witness *big.Int) error
+}
+
+// WitnessSinkFunc adapts a function to WitnessSink
+type WitnessSinkFunc func(index int, witness *big.Int) error
+
+// WriteWitness calls f
+func (f WitnessSinkFunc) WriteWitness(index int, witness *big.Int) error {
+	return f(index, witness)
+}
+
+// WitnessWriter is a WitnessSink writing the witnesses to an io.Writer as fixed-width records: the 8-byte big-endian
+// index of the witness, followed by the big-endian witness padded to the byte length of the modulus
+type WitnessWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewWitnessWriter returns a WitnessWriter for the witnesses modulo N
+func NewWitnessWriter(w io.Writer, N *big.Int) *WitnessWriter {
+	return &WitnessWriter{
+		w:   w,
+		buf: make([]byte, WitnessRecordSize(N)),
+	}
+}
+
+// WitnessRecordSize returns the size in bytes of a witness record for the modulus N
+func WitnessRecordSize(N *big.Int) int {
+	return witnessIndexBytes + (N.BitLen()+7)/8
+}
+
+// WriteWitness writes the record of the witness
+func (w *WitnessWriter) WriteWitness(index int, witness *big.Int) error {
+	if index < 0 || witness.Sign() < 0 || (witness.BitLen()+7)/8 > len(w.buf)-witnessIndexBytes {
+		return ErrInvalidWitness
+	}
+	binary.BigEndian.PutUint64(w.buf, uint64(index))
+	witness.FillBytes(w.buf[witnessIndexBytes:])
+	_, err := w.w.Write(w.buf)
+	return err
+}
+
+// WitnessReader reads the records written by a WitnessWriter
+type WitnessReader struct {
+	r   io.Reader
+	buf []byte
+}
+
+// NewWitnessReader returns a WitnessReader for the witnesses modulo N
+func NewWitnessReader(r io.Reader, N *big.Int) *WitnessReader {
+	return &WitnessReader{
+		r:   r,
+		buf: make([]byte, WitnessRecordSize(N)),
+	}
+}
+
+// ReadWitness reads the next record, it returns io.EOF if there is no record left
+// and io.ErrUnexpectedEOF if the last record is truncated
+func (r *WitnessReader) ReadWitness() (int, *big.Int, error) {
+	if _, err := io.ReadFull(r.r, r.buf); err != nil {
+		return 0, nil, err
+	}
+	index := binary.BigEndian.Uint64(r.buf)
+	return int(index), new(big.Int).SetBytes(r.buf[witnessIndexBytes:]), nil
+}
+
+// ProveMembershipStream is ProveMembership writing every witness to sink as soon as its leaf is finished,
+// in the order of set, instead of returning all of them. It does not build a ProductTree: the products of a subtree
+// are computed when the subtree is entered and released after its exponentiation, so besides the set only the bases
+// on the path to the current leaf are kept. The cost is that every representative is multiplied again at every
+// depth, O(n log^2 n) bit operations instead of the O(n log n) of a ProductTree, which is small next to the
+// exponentiations as long as the representatives are much shorter than the modulus.
+// It returns the first error of sink, or ctx.Err() if the context is done before all the witnesses are written.
+// The progress, if not nil, receives the number of written witnesses.
+func ProveMembershipStream(ctx context.Context, base, N *big.Int, set []*big.Int, sink WitnessSink,
+	progress Progress) error {
+	return ProveMembershipStreamWithRandomizer(ctx, base, nil, N, set, 0, nil, sink, progress)
+}
+
+// ProveMembershipStreamWithRandomizer is ProveMembershipStream with base^randomizer as the generator, and at most
+// min(2^limit, GOMAXPROCS) workers, the witnesses are the same as those of ProveMembershipParallelWithTableWithRandomizer.
+// The first layer of exponentiations uses the table precomputed for base, a nil randomizer or table is not used.
+// With more than one worker, the quarters of a subtree are proved concurrently and every worker keeps the bases
+// on its own path, the witnesses are written as soon as they are computed instead of in the order of set.
+// The calls to sink are serialized.
+func ProveMembershipStreamWithRandomizer(ctx context.Context, base, randomizer, N *big.Int, set []*big.Int, limit int,
+	table *multiexp.PreTable, sink WitnessSink, progress Progress) error {
+	if len(set) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	numWorkers := limitWorkers(limit)
+	r := &streamRun{
+		ctx:     ctx,
+		cancel:  cancel,
+		N:       N,
+		sink:    sink,
+		tracker: newProgressTracker(progress, len(set)),
+		workers: make(chan struct{}, numWorkers-1),
+	}
+	if numWorkers > 1 {
+		r.sink = &lockedSink{sink: sink}
+	}
+	r.prove(base, randomizer, tableFourfoldExp(table, limit), set, 0, true)
+	if r.err != nil {
+		return r.err
+	}
+	return ctx.Err()
+}
+
+// streamRun is the state of one ProveMembershipStreamWithRandomizer
+type streamRun struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	N       *big.Int
+	sink    WitnessSink
+	tracker *progressTracker
+	workers chan struct{} // a token for every worker besides the calling Goroutine
+	errOnce sync.Once
+	err     error
+}
+
+// fail stops the run with err, only the first error is kept
+func (r *streamRun) fail(err error) {
+	r.errOnce.Do(func() {
+		r.err = err
+		r.cancel()
+	})
+}
+
+// prove writes the witnesses of set, whose first representative is the offset-th one. The randomizer and
+// fourfoldExp are those of the root, the subtrees use multiexp.FourfoldExp.
+func (r *streamRun) prove(base, randomizer *big.Int, fourfoldExp fourfoldExpFunc, set []*big.Int, offset int,
+	root bool) {
+	if r.ctx.Err() != nil {
+		return
+	}
+	if len(set) <= 4 {
+		if randomizer != nil {
+			base = AccumulateNew(base, randomizer, r.N)
+		}
+		for i, witness := range handleSmallSet(base, r.N, set) {
+			if err := r.sink.WriteWitness(offset+i, witness); err != nil {
+				r.fail(err)
+				return
+			}
+		}
+		r.tracker.add(len(set))
+		return
+	}
+
+	bounds := [5]int{0, len(set) / 4, len(set) / 2, len(set) * 3 / 4, len(set)}
+	// the products of the root are computed in parallel, the other workers have nothing to do yet
+	bases := quarterBases(base, randomizer, r.N, set, bounds, fourfoldExp, root && cap(r.workers) > 0)
+	var wg sync.WaitGroup
+	for i := range bases {
+		quarterBase, quarter, quarterOffset := bases[i], set[bounds[i]:bounds[i+1]], offset+bounds[i]
+		bases[i] = nil
+		select {
+		case r.workers <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.prove(quarterBase, nil, multiexp.FourfoldExp, quarter, quarterOffset, false)
+				<-r.workers
+			}()
+		default:
+			r.prove(quarterBase, nil, multiexp.FourfoldExp, quarter, quarterOffset, false)
+		}
+	}
+	wg.Wait()
+}
+
+// lockedSink serializes the calls to a WitnessSink shared by several workers
+type lockedSink struct {
+	mu   sync.Mutex
+	sink WitnessSink
+}
+
+func (s *lockedSink) WriteWitness(index int, witness *big.Int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sink.WriteWitness(index, witness)
+}
+
+// quarterBases returns the bases of the four quarters of set split at bounds, i.e. base raised to the product of
+// the other three quarters and the randomizer, if not nil. The products are released when it returns.
+func quarterBases(base, randomizer, N *big.Int, set []*big.Int, bounds [5]int, fourfoldExp fourfoldExpFunc,
+	parallel bool) [4]*big.Int {
+	var quarters [4]*big.Int
+	var wg sync.WaitGroup
+	for i := range quarters {
+		if !parallel {
+			quarters[i] = SetProductRecursiveFast(set[bounds[i]:bounds[i+1]])
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			quarters[i] = SetProductRecursiveFast(set[bounds[i]:bounds[i+1]])
+		}(i)
+	}
+	wg.Wait()
+	leftProd := bigfft.Mul(quarters[2], quarters[3])
+	rightProd := bigfft.Mul(quarters[0], quarters[1])
+
+	var inputExp [4]*big.Int
+	inputExp[0] = bigfft.Mul(leftProd, quarters[1])
+	inputExp[1] = bigfft.Mul(leftProd, quarters[0])
+	inputExp[2] = bigfft.Mul(rightProd, quarters[3])
+	inputExp[3] = bigfft.Mul(rightProd, quarters[2])
+	if randomizer != nil {
+		for i := range inputExp {
+			inputExp[i] = bigfft.Mul(inputExp[i], randomizer)
+		}
+	}
+	return fourfoldExp(base, N, inputExp)
+}
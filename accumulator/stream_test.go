@@ -0,0 +1,125 @@
+package accumulator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/jiajunxin/multiexp"
+)
+
+func TestProveMembershipStream(t *testing.T) {
+	setup := TrustedSetup()
+	rep := GenRepresentatives(GenTestSet(27), HashToPrimeFromSha256)
+	expected := ProveMembership(setup.G, setup.N, rep)
+
+	var buf bytes.Buffer
+	last := 0
+	progress := ProgressFunc(func(completed, total int, eta time.Duration) {
+		last = completed
+	})
+	if err := ProveMembershipStream(context.Background(), setup.G, setup.N, rep, NewWitnessWriter(&buf, setup.N), progress); err != nil {
+		t.Fatalf("ProveMembershipStream returns error: %v", err)
+	}
+	if last != len(rep) {
+		t.Errorf("the last progress is %d, want %d", last, len(rep))
+	}
+	if buf.Len() != len(rep)*WitnessRecordSize(setup.N) {
+		t.Fatalf("%d bytes are written, want %d records of %d bytes", buf.Len(), len(rep), WitnessRecordSize(setup.N))
+	}
+	reader := NewWitnessReader(&buf, setup.N)
+	for i := range expected {
+		index, witness, err := reader.ReadWitness()
+		if err != nil {
+			t.Fatalf("ReadWitness returns error: %v", err)
+		}
+		if index != i || witness.Cmp(expected[i]) != 0 {
+			t.Fatalf("record %d has the witness of %d, or a wrong witness", i, index)
+		}
+	}
+	if _, _, err := reader.ReadWitness(); err != io.EOF {
+		t.Errorf("ReadWitness at the end returns %v, want io.EOF", err)
+	}
+}
+
+func TestProveMembershipStreamErrors(t *testing.T) {
+	setup := TrustedSetup()
+	rep := GenRepresentatives(GenTestSet(20), HashToPrimeFromSha256)
+
+	errSink := errors.New("sink is full")
+	written := 0
+	sink := WitnessSinkFunc(func(index int, witness *big.Int) error {
+		if written == 5 {
+			return errSink
+		}
+		written++
+		return nil
+	})
+	if err := ProveMembershipStream(context.Background(), setup.G, setup.N, rep, sink, nil); !errors.Is(err, errSink) {
+		t.Errorf("ProveMembershipStream returns %v, want the error of the sink", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sink = WitnessSinkFunc(func(index int, witness *big.Int) error {
+		cancel()
+		return nil
+	})
+	if err := ProveMembershipStream(ctx, setup.G, setup.N, rep, sink, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("ProveMembershipStream returns %v, want %v", err, context.Canceled)
+	}
+
+	var buf bytes.Buffer
+	w := NewWitnessWriter(&buf, big.NewInt(255))
+	if err := w.WriteWitness(0, big.NewInt(256)); !errors.Is(err, ErrInvalidWitness) {
+		t.Errorf("WriteWitness of a too large witness returns %v, want %v", err, ErrInvalidWitness)
+	}
+	if err := w.WriteWitness(1, big.NewInt(200)); err != nil {
+		t.Fatalf("WriteWitness returns error: %v", err)
+	}
+	buf.Truncate(buf.Len() - 1)
+	if _, _, err := NewWitnessReader(&buf, big.NewInt(255)).ReadWitness(); err != io.ErrUnexpectedEOF {
+		t.Errorf("ReadWitness of a truncated record returns %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestProveMembershipStreamWithRandomizer(t *testing.T) {
+	setup := TrustedSetup()
+	rep := GenRepresentatives(GenTestSet(45), HashToPrimeFromSha256)
+	r := GenRandomizer()
+	table := multiexp.NewPrecomputeTable(setup.G, setup.N, 45*1025/64+64)
+	expected := ProveMembershipParallelWithTableWithRandomizer(setup.G, r, setup.N, rep, 2, table)
+	for _, limit := range []int{0, 2} {
+		witnesses := make(map[int]*big.Int)
+		sink := WitnessSinkFunc(func(index int, witness *big.Int) error {
+			if _, ok := witnesses[index]; ok {
+				t.Errorf("witness %d is written twice", index)
+			}
+			witnesses[index] = witness
+			return nil
+		})
+		err := ProveMembershipStreamWithRandomizer(context.Background(), setup.G, r, setup.N, rep, limit, table, sink, nil)
+		if err != nil {
+			t.Fatalf("ProveMembershipStreamWithRandomizer returns error: %v", err)
+		}
+		if len(witnesses) != len(expected) {
+			t.Fatalf("limit %d: %d witnesses are written, want %d", limit, len(witnesses), len(expected))
+		}
+		for i := range expected {
+			if witnesses[i] == nil || witnesses[i].Cmp(expected[i]) != 0 {
+				t.Fatalf("limit %d: witness %d is different from ProveMembershipParallelWithTableWithRandomizer", limit, i)
+			}
+		}
+	}
+
+	errSink := errors.New("sink is full")
+	sink := WitnessSinkFunc(func(index int, witness *big.Int) error {
+		return errSink
+	})
+	if err := ProveMembershipStreamWithRandomizer(context.Background(), setup.G, r, setup.N, rep, 2, nil, sink, nil); !errors.Is(err, errSink) {
+		t.Errorf("ProveMembershipStreamWithRandomizer returns %v, want the error of the sink", err)
+	}
+}
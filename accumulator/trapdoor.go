@@ -0,0 +1,143 @@
+package accumulator
+
+import (
+	"errors"
+	"math/big"
+	"runtime"
+	"sync"
+)
+
+// Trapdoor is the factorization of the RSA modulus N = P * Q, together with Phi = (P-1)(Q-1).
+// It is known only to the operator who ran the setup, and allows to compute roots in QR_N directly,
+// e.g. deleting elements and generating membership witnesses with O(1) exponentiations each.
+// Anyone holding the trapdoor can forge membership proofs, so it must never leave the operator.
+type Trapdoor struct {
+	P   *big.Int
+	Q   *big.Int
+	Phi *big.Int
+}
+
+// NewTrapdoor returns the trapdoor of N = p * q
+func NewTrapdoor(p, q *big.Int) *Trapdoor {
+	var pMinus1, qMinus1 big.Int
+	pMinus1.Sub(p, big1)
+	qMinus1.Sub(q, big1)
+	return &Trapdoor{
+		P:   new(big.Int).Set(p),
+		Q:   new(big.Int).Set(q),
+		Phi: new(big.Int).Mul(&pMinus1, &qMinus1),
+	}
+}
+
+// Order returns the order of QR_N, which is Phi / 4, i.e. p'q' for safe primes P = 2p'+1 and Q = 2q'+1.
+// Exponents of elements in QR_N, such as G, H and the accumulator values, can be reduced modulo Order.
+func (t *Trapdoor) Order() *big.Int {
+	return getOrder(t.P, t.Q)
+}
+
+// TrustedSetupWithTrapdoor generates a new hidden order group QR_N of Params2048 with N = p*q for safe primes p and q,
+// and returns the setup together with its trapdoor
+func TrustedSetupWithTrapdoor() (*Setup, *Trapdoor) {
+	setup, trapdoor, err := GenerateSetupWithParameters(Params2048)
+	if err != nil {
+		panic(err)
+	}
+	return setup, trapdoor
+}
+
+// RootWithTrapdoor returns base^{1/x} mod N, base must be in QR_N and x must be co-prime with the order of QR_N
+func RootWithTrapdoor(trapdoor *Trapdoor, N, base, x *big.Int) (*big.Int, error) {
+	inverse := new(big.Int).ModInverse(x, trapdoor.Order())
+	if inverse == nil {
+		return nil, errors.New("representative is not invertible modulo the group order")
+	}
+	return inverse.Exp(base, inverse, N), nil
+}
+
+// AccumulateWithTrapdoor returns base^{product of set} mod N with the product reduced modulo the order of QR_N,
+// so the cost is one exponentiation of the size of N no matter how large the set is
+func AccumulateWithTrapdoor(trapdoor *Trapdoor, base, N *big.Int, set []*big.Int) *big.Int {
+	order := trapdoor.Order()
+	prod := new(big.Int).Set(big1)
+	for _, v := range set {
+		prod.Mul(prod, v)
+		prod.Mod(prod, order)
+	}
+	return prod.Exp(base, prod, N)
+}
+
+// ProveMembershipWithTrapdoor generates the membership witnesses of all the representatives in set for
+// the accumulator acc, with witness[i] = acc^{1/set[i]} mod N. Every witness takes a single exponentiation,
+// and the witnesses are computed by runtime.NumCPU() Goroutines.
+func ProveMembershipWithTrapdoor(trapdoor *Trapdoor, N, acc *big.Int, set []*big.Int) ([]*big.Int, error) {
+	ret := make([]*big.Int, len(set))
+	numWorkers := runtime.NumCPU()
+	if numWorkers > len(set) {
+		numWorkers = len(set)
+	}
+	errs := make([]error, numWorkers)
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := worker; j < len(set); j += numWorkers {
+				w, err := RootWithTrapdoor(trapdoor, N, acc, set[j])
+				if err != nil {
+					errs[worker] = err
+					return
+				}
+				ret[j] = w
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// ProveMembershipWithTrapdoor generates the membership witnesses of all the accumulated elements,
+// in the same order as Elements, with one exponentiation per element
+func (acc *Accumulator) ProveMembershipWithTrapdoor(trapdoor *Trapdoor) ([]*big.Int, error) {
+	value, err := acc.rsaValue()
+	if err != nil {
+		return nil, err
+	}
+	return ProveMembershipWithTrapdoor(trapdoor, acc.setup.N, value, acc.reps)
+}
+
+// DeleteElementsWithTrapdoor removes elements from the accumulator by raising the accumulator value to
+// the inverse of the product of their representatives modulo the order of QR_N.
+// The accumulator is not changed if any of the elements is not accumulated.
+func (acc *Accumulator) DeleteElementsWithTrapdoor(trapdoor *Trapdoor, elements ...Element) error {
+	if len(elements) == 0 {
+		return nil
+	}
+	current, err := acc.rsaValue()
+	if err != nil {
+		return err
+	}
+	if err = acc.checkPresent(elements); err != nil {
+		return err
+	}
+	rep := make([]*big.Int, len(elements))
+	for i, v := range elements {
+		rep[i] = acc.reps[acc.index[string(v)]]
+	}
+	value, err := RootWithTrapdoor(trapdoor, acc.setup.N, current, SetProductRecursiveFast(rep))
+	if err != nil {
+		return err
+	}
+	acc.value = value
+	acc.remove(elements)
+	return nil
+}
+
+// DeleteWithTrapdoor is DeleteElementsWithTrapdoor for the bytes of the strings
+func (acc *Accumulator) DeleteWithTrapdoor(trapdoor *Trapdoor, elements ...string) error {
+	return acc.DeleteElementsWithTrapdoor(trapdoor, StringElements(elements)...)
+}
@@ -0,0 +1,98 @@
+package accumulator
+
+import (
+	"math/big"
+	"testing"
+)
+
+// getToySetupWithTrapdoor returns a tiny hidden order group with its trapdoor, for test purpose only.
+// N = 1019 * 1187, both are safe primes, and the order of QR_N is 509 * 593
+func getToySetupWithTrapdoor() (*Setup, *Trapdoor) {
+	setup := &Setup{
+		N: big.NewInt(1019 * 1187),
+		G: big.NewInt(4),
+		H: big.NewInt(9),
+	}
+	return setup, NewTrapdoor(big.NewInt(1019), big.NewInt(1187))
+}
+
+func TestAccumulatorDeleteWithTrapdoor(t *testing.T) {
+	setup, trapdoor := getToySetupWithTrapdoor()
+	set := GenBenchSet(10)
+	acc1, err := NewAccumulatorFromSet(setup, HashToPrimeFromSha256, set)
+	if err != nil {
+		t.Fatalf("NewAccumulatorFromSet returns error: %v", err)
+	}
+	acc2, err := NewAccumulatorFromSet(setup, HashToPrimeFromSha256, set)
+	if err != nil {
+		t.Fatalf("NewAccumulatorFromSet returns error: %v", err)
+	}
+	if err = acc1.Delete(set[0], set[5]); err != nil {
+		t.Errorf("Delete returns error: %v", err)
+	}
+	if err = acc2.DeleteWithTrapdoor(trapdoor, set[0], set[5]); err != nil {
+		t.Errorf("DeleteWithTrapdoor returns error: %v", err)
+	}
+	if acc1.Value().Cmp(acc2.Value()) != 0 {
+		t.Errorf("DeleteWithTrapdoor is not consistent with Delete")
+	}
+	if acc2.Size() != len(set)-2 {
+		t.Errorf("Size() = %d, want %d", acc2.Size(), len(set)-2)
+	}
+}
+
+func TestTrapdoorOrder(t *testing.T) {
+	setup, trapdoor := getToySetupWithTrapdoor()
+	if trapdoor.Phi.Cmp(big.NewInt(1018*1186)) != 0 {
+		t.Errorf("Phi = %s, want %d", trapdoor.Phi.String(), 1018*1186)
+	}
+	var temp big.Int
+	temp.Exp(setup.G, trapdoor.Order(), setup.N)
+	if temp.Cmp(big1) != 0 {
+		t.Errorf("the order of G does not divide Order()")
+	}
+}
+
+func TestProveMembershipWithTrapdoor(t *testing.T) {
+	setup, trapdoor := getToySetupWithTrapdoor()
+	set := GenBenchSet(33)
+	for _, encodeType := range []EncodeType{HashToPrimeFromSha256, DIHashFromPoseidon} {
+		rep := GenRepresentatives(set, encodeType)
+		acc := AccumulateWithTrapdoor(trapdoor, setup.G, setup.N, rep)
+		if acc.Cmp(accumulateNew(setup.G, setup.N, rep)) != 0 {
+			t.Errorf("AccumulateWithTrapdoor is not consistent with accumulateNew")
+		}
+		witnesses, err := ProveMembershipWithTrapdoor(trapdoor, setup.N, acc, rep)
+		if err != nil {
+			t.Fatalf("ProveMembershipWithTrapdoor returns error: %v", err)
+		}
+		want := ProveMembership(setup.G, setup.N, rep)
+		for i := range want {
+			if witnesses[i].Cmp(want[i]) != 0 {
+				t.Errorf("witness %d is not consistent with ProveMembership", i)
+			}
+		}
+	}
+}
+
+func TestAccumulatorProveMembershipWithTrapdoor(t *testing.T) {
+	setup, trapdoor := getToySetupWithTrapdoor()
+	set := GenBenchSet(12)
+	acc, err := NewAccumulatorFromSet(setup, HashToPrimeFromSha256, set)
+	if err != nil {
+		t.Fatalf("NewAccumulatorFromSet returns error: %v", err)
+	}
+	if err = acc.DeleteWithTrapdoor(trapdoor, set[3], set[7]); err != nil {
+		t.Fatalf("DeleteWithTrapdoor returns error: %v", err)
+	}
+	witnesses, err := acc.ProveMembershipWithTrapdoor(trapdoor)
+	if err != nil {
+		t.Fatalf("ProveMembershipWithTrapdoor returns error: %v", err)
+	}
+	if !BatchVerifyMembershipWithRep(setup.N, acc.Value(), acc.Representatives(), witnesses) {
+		t.Errorf("membership witnesses generated with the trapdoor do not pass verification")
+	}
+	if err = acc.DeleteWithTrapdoor(trapdoor, set[3]); err != ErrElementNotFound {
+		t.Errorf("DeleteWithTrapdoor of a deleted element should return ErrElementNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package accumulator
+
+import (
+	crand "crypto/rand"
+	"math/big"
+
+	fiatshamir "github.com/jiajunxin/rsa_accumulator/fiat-shamir"
+)
+
+// VerifyElementMembership returns true if witness is a valid membership proof of element for the accumulator acc
+func VerifyElementMembership(setup *Setup, acc *big.Int, element Element, encodeType EncodeType, witness *big.Int) bool {
+	x, err := setup.EncodeElements([]Element{element}, encodeType)
+	if err != nil {
+		return false
+	}
+	return VerifyMembershipWithRep(setup.N, acc, x[0], witness)
+}
+
+// VerifyMembership is VerifyElementMembership for the bytes of the string
+func VerifyMembership(setup *Setup, acc *big.Int, element string, encodeType EncodeType, witness *big.Int) bool {
+	return VerifyElementMembership(setup, acc, Element(element), encodeType, witness)
+}
+
+// VerifyRecordMembership is VerifyElementMembership for the canonical encoding of the record,
+// it returns false if the record cannot be encoded
+func VerifyRecordMembership(setup *Setup, acc *big.Int, record Record, encodeType EncodeType, witness *big.Int) bool {
+	element, err := EncodeRecords([]Record{record})
+	if err != nil {
+		return false
+	}
+	return VerifyElementMembership(setup, acc, element[0], encodeType, witness)
+}
+
+// VerifyMembershipWithRep returns true if witness^x = acc mod N
+func VerifyMembershipWithRep(N, acc, x, witness *big.Int) bool {
+	if witness == nil || x == nil {
+		return false
+	}
+	var temp big.Int
+	temp.Exp(witness, x, N)
+	return temp.Cmp(acc) == 0
+}
+
+// VerifyMembershipWithNonce checks the membership proof of element accumulated with HashToPrimeWithNonceFromSha256,
+// where nonce is returned by HashToPrimeWithNonce, so that the representative is checked with a single hash
+func VerifyMembershipWithNonce(setup *Setup, acc *big.Int, element string, nonce uint32, witness *big.Int) bool {
+	if setup.CheckEncodeType(HashToPrimeWithNonceFromSha256) != nil {
+		return false
+	}
+	x := fiatshamir.HashWithNonce(ElementDomain, []byte(element), nonce, fiatshamir.Default)
+	if !VerifyHashToPrime([]byte(element), nonce, x) {
+		return false
+	}
+	return VerifyMembershipWithRep(setup.N, acc, x, witness)
+}
+
+// BatchVerifyElementMembership returns true if all the witnesses are valid membership proofs of the elements
+// for the accumulator acc. witnesses[i] is the membership proof of elements[i].
+func BatchVerifyElementMembership(setup *Setup, acc *big.Int, elements []Element, encodeType EncodeType,
+	witnesses []*big.Int) bool {
+	if len(elements) != len(witnesses) {
+		return false
+	}
+	rep, err := setup.EncodeElements(elements, encodeType)
+	if err != nil {
+		return false
+	}
+	return BatchVerifyMembershipWithRep(setup.N, acc, rep, witnesses)
+}
+
+// BatchVerifyMembership is BatchVerifyElementMembership for the bytes of the strings
+func BatchVerifyMembership(setup *Setup, acc *big.Int, elements []string, encodeType EncodeType, witnesses []*big.Int) bool {
+	return BatchVerifyElementMembership(setup, acc, StringElements(elements), encodeType, witnesses)
+}
+
+// BatchVerifyMembershipWithRep returns true if witnesses[i]^set[i] = ±acc mod N for all i.
+// It picks random securityPara-bit coefficients r_i and checks prod(witnesses[i]^{set[i]*r_i})^2 = acc^{2*sum(r_i)},
+// the left part is calculated with SimultaneousExp, so all the witnesses share one chain of squarings
+// instead of running len(set) full exponentiations.
+// Both sides are squared because -1 has order 2 in Z_N^*: without the squaring, N-witnesses[i] passes the check
+// whenever r_i is even. The squaring maps the check into the quadratic residues, which have no element of small order
+// if N is the product of two safe primes, so a witness with witnesses[i]^set[i] != ±acc passes the check with
+// probability at most 2^{-securityPara}. The check cannot tell a witness w from N-w, but with an odd representative
+// (N-w)^x = -acc, so either of them proves the membership; use VerifyMembershipWithRep to check the exact witness.
+func BatchVerifyMembershipWithRep(N, acc *big.Int, set, witnesses []*big.Int) bool {
+	if len(set) != len(witnesses) {
+		return false
+	}
+	if len(set) == 0 {
+		return true
+	}
+	if len(set) == 1 {
+		return VerifyMembershipWithRep(N, acc, set[0], witnesses[0])
+	}
+	bound := new(big.Int).Lsh(big1, securityPara)
+	exps := make([]*big.Int, len(set))
+	var sum big.Int
+	for i := range set {
+		if set[i] == nil || witnesses[i] == nil {
+			return false
+		}
+		r, err := crand.Int(crand.Reader, bound)
+		if err != nil {
+			panic(err)
+		}
+		sum.Add(&sum, r)
+		exps[i] = r.Mul(r, set[i])
+	}
+	lhs := SimultaneousExp(witnesses, exps, N)
+	lhs.Mul(lhs, lhs)
+	lhs.Mod(lhs, N)
+	var rhs big.Int
+	rhs.Exp(acc, sum.Lsh(&sum, 1), N)
+	return lhs.Cmp(&rhs) == 0
+}
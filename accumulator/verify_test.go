@@ -0,0 +1,75 @@
+package accumulator
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestVerifyMembership(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(17)
+	acc, proofs := AccAndProve(set, HashToPrimeFromSha256, setup)
+	for i := range set {
+		if !VerifyMembership(setup, acc, set[i], HashToPrimeFromSha256, proofs[i]) {
+			t.Errorf("valid membership proof %d does not pass verification", i)
+		}
+	}
+	if VerifyMembership(setup, acc, set[1], HashToPrimeFromSha256, proofs[0]) {
+		t.Errorf("membership proof of another element should not pass verification")
+	}
+	if VerifyMembership(setup, acc, set[0], HashToPrimeFromSha256, nil) {
+		t.Errorf("nil proof should not pass verification")
+	}
+}
+
+func TestBatchVerifyMembership(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(33)
+	for _, encodeType := range []EncodeType{HashToPrimeFromSha256, DIHashFromPoseidon} {
+		acc, proofs := AccAndProve(set, encodeType, setup)
+		if !BatchVerifyMembership(setup, acc, set, encodeType, proofs) {
+			t.Errorf("valid membership proofs do not pass batch verification")
+		}
+		if !BatchVerifyMembership(setup, acc, set[5:9], encodeType, proofs[5:9]) {
+			t.Errorf("valid membership proofs of a subset do not pass batch verification")
+		}
+		// swap two proofs
+		invalid := make([]*big.Int, len(proofs))
+		copy(invalid, proofs)
+		invalid[2], invalid[3] = invalid[3], invalid[2]
+		if BatchVerifyMembership(setup, acc, set, encodeType, invalid) {
+			t.Errorf("invalid membership proofs should not pass batch verification")
+		}
+		if BatchVerifyMembership(setup, acc, set, encodeType, proofs[1:]) {
+			t.Errorf("unbalanced input should not pass batch verification")
+		}
+	}
+}
+
+func TestBatchVerifyMembershipNegatedWitness(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(8)
+	rep := GenRepresentatives(set, HashToPrimeFromSha256)
+	acc, proofs := AccAndProve(set, HashToPrimeFromSha256, setup)
+	negated := make([]*big.Int, len(proofs))
+	copy(negated, proofs)
+	negated[3] = new(big.Int).Sub(setup.N, proofs[3])
+	if VerifyMembershipWithRep(setup.N, acc, rep[3], negated[3]) {
+		t.Errorf("negated witness should not pass verification")
+	}
+	// before the squaring, the negated witness passed the batch check with probability 1/2,
+	// now the check does not depend on the random coefficients
+	for i := 0; i < 16; i++ {
+		if !BatchVerifyMembershipWithRep(setup.N, acc, rep, negated) {
+			t.Fatalf("negated witness proves the membership up to its sign and should pass batch verification")
+		}
+	}
+	// a witness multiplied by any other element than ±1 is rejected
+	invalid := make([]*big.Int, len(proofs))
+	copy(invalid, proofs)
+	invalid[3] = new(big.Int).Lsh(proofs[3], 1)
+	invalid[3].Mod(invalid[3], setup.N)
+	if BatchVerifyMembershipWithRep(setup.N, acc, rep, invalid) {
+		t.Errorf("invalid membership proofs should not pass batch verification")
+	}
+}
@@ -0,0 +1,122 @@
+package accumulator
+
+import (
+	"errors"
+	"math/big"
+)
+
+// ErrElementDeleted is returned when updating the membership witness of an element that is deleted in the update
+var ErrElementDeleted = errors.New("element deleted in the update")
+
+// UpdateMessage describes the change of an accumulator from one epoch to the next,
+// i.e. Accumulator = old accumulator^{product of Added / product of Deleted}.
+// It is everything a client needs to refresh its membership witness without the full set.
+type UpdateMessage struct {
+	Added       []*big.Int // representatives of the added elements
+	Deleted     []*big.Int // representatives of the deleted elements
+	Accumulator *big.Int   // the new accumulator value
+}
+
+// UpdateMembershipWitness refreshes the membership witness of the representative x after the update msg.
+// Additions are applied as witness^{product of Added}. For deletions, let d be the product of Deleted and
+// a*x + b*d = 1 be the Bezout equation, the new witness is witness^{b * product of Added} * msg.Accumulator^a.
+// It works for the witnesses generated with a randomizer as well, e.g. by ProveMembershipParallelWithTableWithRandomizer,
+// as long as the new accumulator uses the same base and randomizer.
+func UpdateMembershipWitness(N, x, witness *big.Int, msg *UpdateMessage) (*big.Int, error) {
+	if msg == nil || msg.Accumulator == nil {
+		return nil, errors.New("invalid update message")
+	}
+	ret := new(big.Int).Set(witness)
+	if len(msg.Added) > 0 {
+		ret.Exp(ret, SetProductRecursiveFast(msg.Added), N)
+	}
+	if len(msg.Deleted) == 0 {
+		return ret, nil
+	}
+	for _, v := range msg.Deleted {
+		if v.Cmp(x) == 0 {
+			return nil, ErrElementDeleted
+		}
+	}
+	var a, b, gcd big.Int
+	gcd.GCD(&a, &b, x, SetProductRecursiveFast(msg.Deleted))
+	if gcd.Cmp(big1) != 0 {
+		return nil, ErrNotCoprime
+	}
+	if ret.Exp(ret, &b, N) == nil {
+		return nil, errors.New("witness is not invertible modulo N")
+	}
+	var temp big.Int
+	if temp.Exp(msg.Accumulator, &a, N) == nil {
+		return nil, errors.New("accumulator is not invertible modulo N")
+	}
+	ret.Mul(ret, &temp)
+	ret.Mod(ret, N)
+	return ret, nil
+}
+
+// UpdateMembershipWitnesses refreshes the membership witnesses of all the representatives in set after the update msg,
+// witnesses[i] is the witness of set[i]. The products of the update message are computed only once.
+func UpdateMembershipWitnesses(N *big.Int, set, witnesses []*big.Int, msg *UpdateMessage) ([]*big.Int, error) {
+	if len(set) != len(witnesses) {
+		return nil, errors.New("invalid input, unbalanced set and witnesses")
+	}
+	if msg == nil || msg.Accumulator == nil {
+		return nil, errors.New("invalid update message")
+	}
+	// fold the products into a message with a single added and deleted representative
+	folded := &UpdateMessage{Accumulator: msg.Accumulator}
+	if len(msg.Added) > 0 {
+		folded.Added = []*big.Int{SetProductRecursiveFast(msg.Added)}
+	}
+	deleted := make(map[string]struct{}, len(msg.Deleted))
+	if len(msg.Deleted) > 0 {
+		folded.Deleted = []*big.Int{SetProductRecursiveFast(msg.Deleted)}
+		for _, v := range msg.Deleted {
+			deleted[v.String()] = struct{}{}
+		}
+	}
+	ret := make([]*big.Int, len(set))
+	for i := range set {
+		if _, ok := deleted[set[i].String()]; ok {
+			return nil, ErrElementDeleted
+		}
+		w, err := UpdateMembershipWitness(N, set[i], witnesses[i], folded)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = w
+	}
+	return ret, nil
+}
+
+// UpdateElementsWithMessage works as UpdateElements and returns the update message for the clients
+// to refresh their witnesses
+func (acc *Accumulator) UpdateElementsWithMessage(removed, inserted []Element) (*UpdateMessage, error) {
+	if acc.setup == nil {
+		return nil, ErrNotRSASetup
+	}
+	deleted := make([]*big.Int, 0, len(removed))
+	for _, v := range removed {
+		if rep, err := acc.ElementRepresentative(v); err == nil {
+			deleted = append(deleted, rep)
+		}
+	}
+	if err := acc.UpdateElements(removed, inserted); err != nil {
+		return nil, err
+	}
+	added := make([]*big.Int, len(inserted))
+	for i, v := range inserted {
+		added[i] = acc.reps[acc.index[string(v)]]
+	}
+	return &UpdateMessage{
+		Added:       added,
+		Deleted:     deleted,
+		Accumulator: acc.Value(),
+	}, nil
+}
+
+// UpdateWithMessage is UpdateElementsWithMessage for the bytes of the strings
+func (acc *Accumulator) UpdateWithMessage(removed, inserted []string) (*UpdateMessage, error) {
+	return acc.UpdateElementsWithMessage(StringElements(removed), StringElements(inserted))
+}
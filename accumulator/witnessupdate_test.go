@@ -0,0 +1,78 @@
+package accumulator
+
+import (
+	"testing"
+)
+
+func TestUpdateMembershipWitness(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(24)
+	acc, err := NewAccumulatorFromSet(setup, HashToPrimeFromSha256, set[:16])
+	if err != nil {
+		t.Fatalf("NewAccumulatorFromSet returns error: %v", err)
+	}
+	rep := acc.Representatives()
+	witnesses := acc.ProveMembership()
+
+	// delete set[2] and set[9], re-insert set[5] and insert set[16:20]
+	msg, err := acc.UpdateWithMessage([]string{set[2], set[9], set[5]}, append([]string{set[5]}, set[16:20]...))
+	if err != nil {
+		t.Fatalf("UpdateWithMessage returns error: %v", err)
+	}
+	for i := range rep {
+		w, err := UpdateMembershipWitness(setup.N, rep[i], witnesses[i], msg)
+		switch i {
+		case 2, 5, 9:
+			if err != ErrElementDeleted {
+				t.Errorf("UpdateMembershipWitness of a deleted element should return ErrElementDeleted, got %v", err)
+			}
+		default:
+			if err != nil {
+				t.Fatalf("UpdateMembershipWitness returns error: %v", err)
+			}
+			if !VerifyMembershipWithRep(setup.N, acc.Value(), rep[i], w) {
+				t.Errorf("updated witness %d does not pass verification", i)
+			}
+		}
+	}
+
+	// a second epoch with only additions, updating all the witnesses at once
+	rep = acc.Representatives()
+	witnesses = acc.ProveMembership()
+	msg, err = acc.UpdateWithMessage(nil, set[20:])
+	if err != nil {
+		t.Fatalf("UpdateWithMessage returns error: %v", err)
+	}
+	updated, err := UpdateMembershipWitnesses(setup.N, rep, witnesses, msg)
+	if err != nil {
+		t.Fatalf("UpdateMembershipWitnesses returns error: %v", err)
+	}
+	if !BatchVerifyMembershipWithRep(setup.N, acc.Value(), rep, updated) {
+		t.Errorf("updated witnesses do not pass verification")
+	}
+}
+
+func TestUpdateMembershipWitnessWithRandomizer(t *testing.T) {
+	setup := TrustedSetup()
+	set := GenBenchSet(20)
+	rep := GenRepresentatives(set, HashToPrimeFromSha256)
+	base := AccumulateNew(setup.G, GenRandomizer(), setup.N)
+	witnesses := ProveMembership(base, setup.N, rep[:16])
+
+	// delete rep[0:3] and add rep[16:]
+	msg := &UpdateMessage{
+		Added:       rep[16:],
+		Deleted:     rep[:3],
+		Accumulator: accumulateNew(base, setup.N, rep[3:]),
+	}
+	updated, err := UpdateMembershipWitnesses(setup.N, rep[3:16], witnesses[3:], msg)
+	if err != nil {
+		t.Fatalf("UpdateMembershipWitnesses returns error: %v", err)
+	}
+	if !BatchVerifyMembershipWithRep(setup.N, msg.Accumulator, rep[3:16], updated) {
+		t.Errorf("updated witnesses do not pass verification")
+	}
+	if _, err = UpdateMembershipWitnesses(setup.N, rep[:16], witnesses, msg); err != ErrElementDeleted {
+		t.Errorf("UpdateMembershipWitnesses with a deleted element should return ErrElementDeleted, got %v", err)
+	}
+}
@@ -1,31 +1,38 @@
 package accumulator
 
 import (
+	"context"
 	crand "crypto/rand"
 	"fmt"
 	"math/big"
 	"time"
 )
 
-// GenRandomizer outputs random number uniformly between 0 to 2^2047
+// GenRandomizer outputs random number uniformly between 0 to 2^2047, the randomizer bound of Params2048
 func GenRandomizer() *big.Int {
-	ranNum, err := crand.Int(crand.Reader, Min2048)
+	return Params2048.GenRandomizer()
+}
+
+func genRandomizer(bound *big.Int) *big.Int {
+	ranNum, err := crand.Int(crand.Reader, bound)
 	if err != nil {
 		panic(err)
 	}
 	return ranNum
 }
 
-// ZKAccumulate generates one accumulator which is zero-knowledge
+// ZKAccumulate generates one accumulator which is zero-knowledge.
+// It panics if encodeType is not registered or not the encoder of the setup, or an element cannot be encoded,
+// use ZKAccumulateContext to get the error instead.
 func ZKAccumulate(set []string, encodeType EncodeType, setup *Setup) (*big.Int, []*big.Int) {
 	startingTime := time.Now().UTC()
-	rep := GenRepresentatives(set, encodeType)
+	rep := setup.mustGenRepresentatives(set, encodeType)
 	endingTime := time.Now().UTC()
 	var duration = endingTime.Sub(startingTime)
 	fmt.Printf("Running GenRepresentatives Takes [%.3f] Seconds \n",
 		duration.Seconds())
 
-	r := GenRandomizer()
+	r := setup.GenRandomizer()
 	base := AccumulateNew(setup.G, r, setup.N)
 
 	proofs := ProveMembership(base, setup.N, rep)
@@ -34,3 +41,23 @@ func ZKAccumulate(set []string, encodeType EncodeType, setup *Setup) (*big.Int,
 
 	return acc, proofs
 }
+
+// ZKAccumulateContext is ZKAccumulate with cancellation and progress reporting, without printing the timings.
+// The errors are the same as those of AccAndProveContext.
+func ZKAccumulateContext(ctx context.Context, set []string, encodeType EncodeType, setup *Setup,
+	progress Progress) (*big.Int, []*big.Int, error) {
+	rep, err := setup.GenRepresentativesParallel(ctx, set, encodeType, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	base := AccumulateNew(setup.G, setup.GenRandomizer(), setup.N)
+	if len(rep) == 0 {
+		return base, nil, nil
+	}
+	proofs, err := ProveMembershipContext(ctx, base, setup.N, rep, progress)
+	if err != nil {
+		return nil, nil, err
+	}
+	// we generate the accumulator by anyone of the membership proof raised to its power to save some calculation
+	return AccumulateNew(proofs[0], rep[0], setup.N), proofs, nil
+}
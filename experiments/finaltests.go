@@ -10,7 +10,6 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/poseidon"
 	"github.com/jiajunxin/multiexp"
 	"github.com/remyoudompheng/bigfft"
@@ -26,17 +25,26 @@ func TestMembershipVerify() {
 	setSize := 10
 	set := accumulator.GenBenchSet(setSize)
 	rep := accumulator.GenRepresentatives(set, accumulator.DIHashFromPoseidon)
-	_, proofs := accumulator.AccAndProve(set, accumulator.DIHashFromPoseidon, &setup)
+	acc, proofs := accumulator.AccAndProve(set, accumulator.DIHashFromPoseidon, &setup)
 	startingTime := time.Now().UTC()
 	repeatNum := 100
 	for i := 0; i < repeatNum; i++ {
 		// each proof raise to the power of the representative is the process to verify the membership
-		_ = accumulator.AccumulateNew(proofs[0], rep[0], setup.N)
+		if !accumulator.VerifyMembershipWithRep(setup.N, acc, rep[0], proofs[0]) {
+			panic("membership proof did not pass verification")
+		}
 	}
 	duration := time.Now().UTC().Sub(startingTime)
 	fmt.Printf("Verifying membership proof for 100 rounds Takes [%.3f] Seconds \n", duration.Seconds())
 	fmt.Printf("Verifying membership proof for each one Takes [%.3f] Seconds \n", duration.Seconds()/float64(repeatNum))
 	fmt.Println("Proof size = ", proofs[0].BitLen(), "bits")
+
+	startingTime = time.Now().UTC()
+	if !accumulator.BatchVerifyMembershipWithRep(setup.N, acc, rep, proofs) {
+		panic("membership proofs did not pass batch verification")
+	}
+	duration = time.Now().UTC().Sub(startingTime)
+	fmt.Printf("Batch verifying %d membership proofs Takes [%.3f] Seconds \n", setSize, duration.Seconds())
 }
 
 // TestBasicZKrsa test a naive case of zero-knowledge RSA accumulator
@@ -470,10 +478,13 @@ func isCircuitExist(testSetSize uint32) bool {
 
 // TestNotusMultiSwap tests the Notus system and zk-MultiSwap "almost" in single thread
 func TestNotusMultiSwap(setsize, updatedSetSize uint32) {
+	// the native representatives and the circuit use the DI hash of the same parameter set
+	setup := *accumulator.TrustedSetup()
+	diHash := setup.Parameters().DIHash()
 	if !isCircuitExist(updatedSetSize) {
 		fmt.Println("Circuit haven't been compiled for testSetSize = ", updatedSetSize, ". Start compiling.")
 		startingTime := time.Now().UTC()
-		zkmultiswap.SetupZkMultiswap(updatedSetSize)
+		zkmultiswap.SetupZkMultiswapWithDIHash(updatedSetSize, diHash)
 		duration := time.Now().UTC().Sub(startingTime)
 		fmt.Printf("Generating a SNARK circuit for set size = %d, takes [%.3f] Seconds \n", updatedSetSize, duration.Seconds())
 		runtime.GC()
@@ -501,18 +512,8 @@ func TestNotusMultiSwap(setsize, updatedSetSize uint32) {
 	ret.UpdatedSum = zkmultiswap.OriginalSum // UpdatedSum can be any valid positive numbers, but we are testing the case UpdatedSum = OriginalSum for simplicity
 
 	// get slice of elements removed and inserted
-	removeSet := make([]*big.Int, updatedSetSize)
-	insertSet := make([]*big.Int, updatedSetSize)
 	startingTime := time.Now().UTC()
-	for i := uint32(0); i < updatedSetSize; i++ {
-		var poseidonhash *fr.Element // this is the Poseidon part of the DI hash. We use this to build the hash chain. The original DI hash is to long to directly input into Poseidon hash
-		poseidonhash, removeSet[i] = accumulator.PoseidonAndDIHash(accumulator.ElementFromUint32(ret.UserID[i]), accumulator.ElementFromUint32(ret.OriginalBalances[i]),
-			accumulator.ElementFromUint32(ret.OriginalUpdEpoch[i]), accumulator.ElementFromString(ret.OriginalHashes[i].String()))
-		//fmt.Println("poseidonhash i = ", poseidonhash.String())
-
-		insertSet[i] = accumulator.DIHashPoseidon(accumulator.ElementFromUint32(ret.UserID[i]), accumulator.ElementFromUint32(ret.UpdatedBalances[i]),
-			accumulator.ElementFromUint32(ret.CurrentEpochNum), poseidonhash)
-	}
+	removeSet, insertSet := ret.DIRepresentatives(diHash)
 	prod1 := accumulator.SetProductRecursiveFast(removeSet)
 	prod2 := accumulator.SetProductRecursiveFast(insertSet)
 
@@ -539,12 +540,14 @@ func TestNotusMultiSwap(setsize, updatedSetSize uint32) {
 	fmt.Printf("Generate DI representatives Takes [%.3f] Seconds \n", duration.Seconds())
 
 	// get accumulators
-	setup := *accumulator.TrustedSetup()
 	maxLen := setsize * 2048 / bits.UintSize
 	table := multiexp.NewPrecomputeTable(setup.G, setup.N, int(maxLen))
 
 	unchangedSet := accumulator.GenBenchSet(int(setsize - updatedSetSize))
-	unchanged := accumulator.GenRepresentatives(unchangedSet, accumulator.DIHashFromPoseidon)
+	unchanged, err := setup.GenRepresentatives(unchangedSet, accumulator.DIHashFromPoseidon)
+	if err != nil {
+		panic(err)
+	}
 	startingTime = time.Now().UTC()
 	newSet1 := append(unchanged[:], insertSet...)
 	// limit = 0 indicates the ProveMembershipParallelWithTableWithRandomizer is running with single thread
@@ -586,11 +589,7 @@ func TestNotusMultiSwap(setsize, updatedSetSize uint32) {
 	ret.ChallengeL2 = *challengeL2
 	ret.RemainderR1 = *remainderR1
 	ret.RemainderR2 = *remainderR2
-	var deltaModL1, deltaModL2 big.Int
-	deltaModL1.Mod(accumulator.Min1024, challengeL1)
-	deltaModL2.Mod(accumulator.Min1024, challengeL2)
-	ret.DeltaModL1 = deltaModL1
-	ret.DeltaModL2 = deltaModL2
+	ret.SetDIHashModL(diHash)
 
 	if !ret.IsValid() {
 		panic("error in TestMultiSwap, the generated test set is invalid")
@@ -615,10 +614,13 @@ func TestNotusMultiSwap(setsize, updatedSetSize uint32) {
 
 // TestNotusParallel test Notus with at most 32 cores
 func TestNotusParallel(setsize, updatedSetSize uint32) {
+	// the native representatives and the circuit use the DI hash of the same parameter set
+	setup := *accumulator.TrustedSetup()
+	diHash := setup.Parameters().DIHash()
 	if !isCircuitExist(updatedSetSize) {
 		fmt.Println("Circuit haven't been compiled for testSetSize = ", updatedSetSize, ". Start compiling.")
 		startingTime := time.Now().UTC()
-		zkmultiswap.SetupZkMultiswap(updatedSetSize)
+		zkmultiswap.SetupZkMultiswapWithDIHash(updatedSetSize, diHash)
 		duration := time.Now().UTC().Sub(startingTime)
 		fmt.Printf("Generating a SNARK circuit for set size = %d, takes [%.3f] Seconds \n", updatedSetSize, duration.Seconds())
 		runtime.GC()
@@ -646,18 +648,8 @@ func TestNotusParallel(setsize, updatedSetSize uint32) {
 	ret.UpdatedSum = zkmultiswap.OriginalSum // UpdatedSum can be any valid positive numbers, but we are testing the case UpdatedSum = OriginalSum for simplicity
 
 	// get slice of elements removed and inserted
-	removeSet := make([]*big.Int, updatedSetSize)
-	insertSet := make([]*big.Int, updatedSetSize)
 	startingTime := time.Now().UTC()
-	for i := uint32(0); i < updatedSetSize; i++ {
-		var poseidonhash *fr.Element // this is the Poseidon part of the DI hash. We use this to build the hash chain. The original DI hash is to long to directly input into Poseidon hash
-		poseidonhash, removeSet[i] = accumulator.PoseidonAndDIHash(accumulator.ElementFromUint32(ret.UserID[i]), accumulator.ElementFromUint32(ret.OriginalBalances[i]),
-			accumulator.ElementFromUint32(ret.OriginalUpdEpoch[i]), accumulator.ElementFromString(ret.OriginalHashes[i].String()))
-		//fmt.Println("poseidonhash i = ", poseidonhash.String())
-
-		insertSet[i] = accumulator.DIHashPoseidon(accumulator.ElementFromUint32(ret.UserID[i]), accumulator.ElementFromUint32(ret.UpdatedBalances[i]),
-			accumulator.ElementFromUint32(ret.CurrentEpochNum), poseidonhash)
-	}
+	removeSet, insertSet := ret.DIRepresentatives(diHash)
 	prod1 := accumulator.SetProductParallel(removeSet, 4)
 	prod2 := accumulator.SetProductParallel(removeSet, 4)
 
@@ -684,12 +676,14 @@ func TestNotusParallel(setsize, updatedSetSize uint32) {
 	fmt.Printf("Generate DI representatives Takes [%.3f] Seconds \n", duration.Seconds())
 
 	// get accumulators
-	setup := *accumulator.TrustedSetup()
 	maxLen := setsize * 2048 / bits.UintSize
 	table := multiexp.NewPrecomputeTable(setup.G, setup.N, int(maxLen))
 
 	unchangedSet := accumulator.GenBenchSet(int(setsize - updatedSetSize))
-	unchanged := accumulator.GenRepresentatives(unchangedSet, accumulator.DIHashFromPoseidon)
+	unchanged, err := setup.GenRepresentatives(unchangedSet, accumulator.DIHashFromPoseidon)
+	if err != nil {
+		panic(err)
+	}
 	startingTime = time.Now().UTC()
 	newSet1 := append(unchanged[:], insertSet...)
 	// limit = 0 indicates the ProveMembershipParallelWithTableWithRandomizer is running with single thread
@@ -731,11 +725,7 @@ func TestNotusParallel(setsize, updatedSetSize uint32) {
 	ret.ChallengeL2 = *challengeL2
 	ret.RemainderR1 = *remainderR1
 	ret.RemainderR2 = *remainderR2
-	var deltaModL1, deltaModL2 big.Int
-	deltaModL1.Mod(accumulator.Min1024, challengeL1)
-	deltaModL2.Mod(accumulator.Min1024, challengeL2)
-	ret.DeltaModL1 = deltaModL1
-	ret.DeltaModL2 = deltaModL2
+	ret.SetDIHashModL(diHash)
 
 	if !ret.IsValid() {
 		panic("error in TestMultiSwap, the generated test set is invalid")
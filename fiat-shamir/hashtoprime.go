@@ -0,0 +1,103 @@
+package fiatshamir
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+)
+
+const (
+	// MaxHashToPrimeNonce bounds the nonces of HashToPrimeWithNonce. A candidate is prime with probability
+	// about 1/90, so the search fails with probability smaller than 2^{-1000}.
+	MaxHashToPrimeNonce = 1 << 16
+
+	// TranscriptDomain is the domain of the prime challenges of transcripts
+	TranscriptDomain = "rsa_accumulator/fiat-shamir/transcript"
+)
+
+// HashWithNonce returns the odd candidate SHA-256(len(domain) || domain || nonce || input) of the
+// nonce-based hash-to-prime, wrapped to length. The domain separates different uses of the hash.
+func HashWithNonce(domain string, input []byte, nonce uint32, length ChallengeLength) *big.Int {
+	h := sha256.New()
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], uint32(len(domain)))
+	h.Write(buf[:])
+	h.Write([]byte(domain))
+	binary.BigEndian.PutUint32(buf[:], nonce)
+	h.Write(buf[:])
+	h.Write(input)
+	ret := wrapNumber(h.Sum(nil), length)
+	return ret.SetBit(ret, 0, 1)
+}
+
+// HashToPrimeWithNonce returns the first prime HashWithNonce(domain, input, nonce, length) for nonce = 0, 1, 2...
+// together with the nonce, so that VerifyHashToPrime needs only one hash and one primality test.
+func HashToPrimeWithNonce(domain string, input []byte, length ChallengeLength) (*big.Int, uint32) {
+	for nonce := uint32(0); nonce < MaxHashToPrimeNonce; nonce++ {
+		ret := HashWithNonce(domain, input, nonce, length)
+		if ret.ProbablyPrime(securityParameter) {
+			return ret, nonce
+		}
+	}
+	// Should never reach here
+	panic("no prime found by HashToPrimeWithNonce")
+}
+
+// VerifyHashToPrime returns true if prime is HashWithNonce(domain, input, nonce, length) and it is a prime.
+// Note that it does not check that no smaller nonce leads to a prime, use VerifyHashToPrimeCanonical if
+// the prime must be unique for input, e.g. when proving non-membership.
+func VerifyHashToPrime(domain string, input []byte, nonce uint32, length ChallengeLength, prime *big.Int) bool {
+	if prime == nil || nonce >= MaxHashToPrimeNonce {
+		return false
+	}
+	if HashWithNonce(domain, input, nonce, length).Cmp(prime) != 0 {
+		return false
+	}
+	return prime.ProbablyPrime(securityParameter)
+}
+
+// VerifyHashToPrimeCanonical returns true if prime is the output of HashToPrimeWithNonce, i.e. it also checks
+// that the candidates of all the smaller nonces are composite
+func VerifyHashToPrimeCanonical(domain string, input []byte, nonce uint32, length ChallengeLength, prime *big.Int) bool {
+	if !VerifyHashToPrime(domain, input, nonce, length, prime) {
+		return false
+	}
+	for i := uint32(0); i < nonce; i++ {
+		if HashWithNonce(domain, input, i, length).ProbablyPrime(securityParameter) {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeInfo returns the length-prefixed concatenation of the info, so that different transcripts
+// always lead to different inputs
+func (transcript *Transcript) encodeInfo() []byte {
+	var ret []byte
+	var buf [4]byte
+	for _, v := range transcript.info {
+		binary.BigEndian.PutUint32(buf[:], uint32(len(v)))
+		ret = append(ret, buf[:]...)
+		ret = append(ret, v...)
+	}
+	return ret
+}
+
+// GetPrimeChallengeWithNonce returns a prime challenge together with its nonce, and appends the challenge
+// as part of the transcript. The verifier checks the challenge with VerifyPrimeChallengeAndAppend.
+func (transcript *Transcript) GetPrimeChallengeWithNonce() (*big.Int, uint32) {
+	ret, nonce := HashToPrimeWithNonce(TranscriptDomain, transcript.encodeInfo(), transcript.maxlength)
+	transcript.Append(ret.String())
+	return ret, nonce
+}
+
+// VerifyPrimeChallengeAndAppend returns true if challenge is the prime challenge of the transcript with nonce,
+// and appends the challenge as part of the transcript if so. Only the first nonce leading to a prime is accepted,
+// so the prover cannot pick the challenge among the other primes below MaxHashToPrimeNonce.
+func (transcript *Transcript) VerifyPrimeChallengeAndAppend(challenge *big.Int, nonce uint32) bool {
+	if !VerifyHashToPrimeCanonical(TranscriptDomain, transcript.encodeInfo(), nonce, transcript.maxlength, challenge) {
+		return false
+	}
+	transcript.Append(challenge.String())
+	return true
+}
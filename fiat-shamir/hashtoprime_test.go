@@ -0,0 +1,88 @@
+package fiatshamir
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestHashToPrimeWithNonce(t *testing.T) {
+	input := []byte("TestHashToPrimeWithNonce")
+	for _, length := range []ChallengeLength{Default, Max252} {
+		prime, nonce := HashToPrimeWithNonce("test", input, length)
+		if !prime.ProbablyPrime(securityParameter) {
+			t.Errorf("HashToPrimeWithNonce returns a composite number")
+		}
+		if length == Max252 && prime.BitLen() > bitLimit {
+			t.Errorf("prime has %d bits, larger than %d", prime.BitLen(), bitLimit)
+		}
+		if !VerifyHashToPrime("test", input, nonce, length, prime) {
+			t.Errorf("VerifyHashToPrime rejects the output of HashToPrimeWithNonce")
+		}
+		if !VerifyHashToPrimeCanonical("test", input, nonce, length, prime) {
+			t.Errorf("VerifyHashToPrimeCanonical rejects the output of HashToPrimeWithNonce")
+		}
+		if VerifyHashToPrime("another domain", input, nonce, length, prime) {
+			t.Errorf("VerifyHashToPrime accepts the prime in another domain")
+		}
+		if VerifyHashToPrime("test", input, nonce+1, length, prime) {
+			t.Errorf("VerifyHashToPrime accepts another nonce")
+		}
+		if VerifyHashToPrime("test", input, nonce, length, new(big.Int).Add(prime, big.NewInt(2))) {
+			t.Errorf("VerifyHashToPrime accepts another prime")
+		}
+	}
+	// find a later nonce leading to a prime, which is valid but not canonical
+	prime, nonce := HashToPrimeWithNonce("test", input, Default)
+	for i := nonce + 1; i < MaxHashToPrimeNonce; i++ {
+		if candidate := HashWithNonce("test", input, i, Default); candidate.ProbablyPrime(securityParameter) {
+			if !VerifyHashToPrime("test", input, i, Default, candidate) {
+				t.Errorf("VerifyHashToPrime rejects a valid nonce")
+			}
+			if VerifyHashToPrimeCanonical("test", input, i, Default, candidate) {
+				t.Errorf("VerifyHashToPrimeCanonical accepts a non-canonical nonce")
+			}
+			if candidate.Cmp(prime) == 0 {
+				t.Errorf("different nonces lead to the same prime")
+			}
+			break
+		}
+	}
+}
+
+func TestPrimeChallengeWithNonce(t *testing.T) {
+	testStrings := []string{"111", "aaa", "333"}
+	prover := InitTranscript(testStrings, Max252)
+	verifier := InitTranscript(testStrings, Max252)
+	challenge1, nonce1 := prover.GetPrimeChallengeWithNonce()
+	challenge2, nonce2 := prover.GetPrimeChallengeWithNonce()
+	if challenge1.Cmp(challenge2) == 0 {
+		t.Errorf("Updated transcript has old results")
+	}
+	if !verifier.VerifyPrimeChallengeAndAppend(challenge1, nonce1) {
+		t.Errorf("VerifyPrimeChallengeAndAppend rejects a valid challenge")
+	}
+	if verifier.VerifyPrimeChallengeAndAppend(challenge1, nonce1) {
+		t.Errorf("VerifyPrimeChallengeAndAppend accepts an old challenge")
+	}
+	if !verifier.VerifyPrimeChallengeAndAppend(challenge2, nonce2) {
+		t.Errorf("VerifyPrimeChallengeAndAppend rejects a valid challenge")
+	}
+	// a later prime of the same transcript is not accepted
+	info := verifier.encodeInfo()
+	_, canonical := HashToPrimeWithNonce(TranscriptDomain, info, Max252)
+	for nonce := canonical + 1; nonce < MaxHashToPrimeNonce; nonce++ {
+		candidate := HashWithNonce(TranscriptDomain, info, nonce, Max252)
+		if !candidate.ProbablyPrime(securityParameter) {
+			continue
+		}
+		if verifier.VerifyPrimeChallengeAndAppend(candidate, nonce) {
+			t.Errorf("VerifyPrimeChallengeAndAppend accepts a non-canonical nonce")
+		}
+		break
+	}
+	// the info is length-prefixed, so moving the boundary between the strings changes the challenge
+	shifted := InitTranscript([]string{"111a", "aa", "333"}, Max252)
+	if challenge, _ := shifted.GetPrimeChallengeWithNonce(); challenge.Cmp(challenge1) == 0 {
+		t.Errorf("different transcripts lead to the same challenge")
+	}
+}
@@ -0,0 +1,399 @@
+package group
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Form is the reduced positive definite binary quadratic form A*x^2 + B*x*y + C*y^2,
+// which represents an element of a class group
+type Form struct {
+	A *big.Int
+	B *big.Int
+	C *big.Int
+}
+
+// String returns the form as (A,B,C)
+func (f *Form) String() string {
+	return "(" + f.A.String() + "," + f.B.String() + "," + f.C.String() + ")"
+}
+
+// ClassGroup is the class group of the imaginary quadratic order of discriminant D, with D < 0 and D = 1 mod 4.
+// Computing the order of the group is believed to be hard for a large |D|, and D can be derived from a public seed,
+// so the group needs no trusted setup.
+// Elements are composed with NUCOMP and always kept reduced, so every class has a unique representation.
+type ClassGroup struct {
+	D *big.Int
+	l *big.Int // floor(|D/4|^{1/4}), the bound of the partial reduction in NUCOMP
+}
+
+// NewClassGroup returns the class group of discriminant d
+func NewClassGroup(d *big.Int) (*ClassGroup, error) {
+	if d.Sign() >= 0 {
+		return nil, errors.New("discriminant of an imaginary quadratic order must be negative")
+	}
+	if new(big.Int).Mod(d, big4).Cmp(big1) != 0 {
+		return nil, errors.New("discriminant must be 1 mod 4")
+	}
+	l := new(big.Int).Neg(d)
+	l.Rsh(l, 2)
+	l.Sqrt(l)
+	l.Sqrt(l)
+	return &ClassGroup{
+		D: new(big.Int).Set(d),
+		l: l,
+	}, nil
+}
+
+// NewClassGroupFromSeed returns the class group of the discriminant generated by GenerateDiscriminant
+func NewClassGroupFromSeed(seed []byte, bitLen int) *ClassGroup {
+	g, err := NewClassGroup(GenerateDiscriminant(seed, bitLen))
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// GenerateDiscriminant deterministically derives a discriminant D = -p from seed, where p is a bitLen-bit prime
+// and p = 7 mod 8. A prime discriminant makes the class number odd, and D = 1 mod 8 makes (2, 1, (1-D)/8) a form.
+func GenerateDiscriminant(seed []byte, bitLen int) *big.Int {
+	if bitLen < 8 {
+		panic("bit length of the discriminant is too small")
+	}
+	for counter := uint64(0); ; counter++ {
+		p := expandHash("ClassGroupDiscriminant", seed, counter, bitLen)
+		p.SetBit(p, bitLen-1, 1)
+		p.Or(p, big7)
+		if p.ProbablyPrime(20) {
+			return p.Neg(p)
+		}
+	}
+}
+
+// Generator returns the form (2, 1, (1-D)/8) if D = 1 mod 8, or the hash of "generator" otherwise
+func (g *ClassGroup) Generator() Element {
+	if new(big.Int).Mod(g.D, big8).Cmp(big1) != 0 {
+		return g.Hash([]byte("generator"))
+	}
+	c := new(big.Int).Sub(big1, g.D)
+	c.Rsh(c, 3)
+	return reduce(big.NewInt(2), big.NewInt(1), c)
+}
+
+// Identity returns the principal form (1, 1, (1-D)/4)
+func (g *ClassGroup) Identity() Element {
+	c := new(big.Int).Sub(big1, g.D)
+	c.Rsh(c, 2)
+	return &Form{
+		A: big.NewInt(1),
+		B: big.NewInt(1),
+		C: c,
+	}
+}
+
+// Mul returns the reduced composition of a and b, or nil if a or b is nil
+func (g *ClassGroup) Mul(a, b Element) Element {
+	f1, f2 := toForm(a), toForm(b)
+	if f1 == nil || f2 == nil {
+		return nil
+	}
+	return g.nucomp(f1, f2)
+}
+
+// Exp returns base^x with left-to-right square-and-multiply, or nil if base is nil
+func (g *ClassGroup) Exp(base Element, x *big.Int) Element {
+	f := toForm(base)
+	if f == nil {
+		return nil
+	}
+	if x.Sign() < 0 {
+		f = g.Inverse(f).(*Form)
+		x = new(big.Int).Neg(x)
+	}
+	ret := g.Identity().(*Form)
+	for i := x.BitLen() - 1; i >= 0; i-- {
+		ret = g.nucomp(ret, ret)
+		if x.Bit(i) == 1 {
+			ret = g.nucomp(ret, f)
+		}
+	}
+	return ret
+}
+
+// Inverse returns the reduced form of (A, -B, C), or nil if a is nil
+func (g *ClassGroup) Inverse(a Element) Element {
+	f := toForm(a)
+	if f == nil {
+		return nil
+	}
+	return reduce(f.A, new(big.Int).Neg(f.B), f.C)
+}
+
+// Equal returns true if a and b are the same reduced form, nil is not equal to any element
+func (g *ClassGroup) Equal(a, b Element) bool {
+	f1, f2 := toForm(a), toForm(b)
+	if f1 == nil || f2 == nil {
+		return false
+	}
+	return f1.A.Cmp(f2.A) == 0 && f1.B.Cmp(f2.B) == 0 && f1.C.Cmp(f2.C) == 0
+}
+
+// toForm returns the element as a *Form, both nil and a nil *Form give nil
+func toForm(a Element) *Form {
+	f, _ := a.(*Form)
+	return f
+}
+
+// elementSize returns the byte length of A and |B| in a serialized element, both are less than sqrt(|D|)
+func (g *ClassGroup) elementSize() int {
+	return g.D.BitLen()/16 + 1
+}
+
+// Serialize encodes a reduced form as A || sign of B || |B|, C is determined by A, B and D
+func (g *ClassGroup) Serialize(a Element) []byte {
+	f := a.(*Form)
+	size := g.elementSize()
+	ret := make([]byte, 2*size+1)
+	f.A.FillBytes(ret[:size])
+	if f.B.Sign() < 0 {
+		ret[size] = 1
+	}
+	new(big.Int).Abs(f.B).FillBytes(ret[size+1:])
+	return ret
+}
+
+// Deserialize decodes a reduced form from the bytes generated by Serialize
+func (g *ClassGroup) Deserialize(data []byte) (Element, error) {
+	size := g.elementSize()
+	if len(data) != 2*size+1 || data[size] > 1 {
+		return nil, errors.New("invalid encoding of class group element")
+	}
+	a := new(big.Int).SetBytes(data[:size])
+	b := new(big.Int).SetBytes(data[size+1:])
+	if data[size] == 1 {
+		b.Neg(b)
+	}
+	if a.Sign() <= 0 {
+		return nil, errors.New("invalid class group element, A must be positive")
+	}
+	// C = (B^2 - D) / 4A
+	c := new(big.Int).Mul(b, b)
+	c.Sub(c, g.D)
+	var fourA, r big.Int
+	fourA.Lsh(a, 2)
+	c.DivMod(c, &fourA, &r)
+	if r.Sign() != 0 {
+		return nil, errors.New("invalid class group element, wrong discriminant")
+	}
+	f := &Form{A: a, B: b, C: c}
+	if !isReduced(f) {
+		return nil, errors.New("invalid class group element, not reduced")
+	}
+	return f, nil
+}
+
+// Hash maps data to the reduced form of (a, b, (b^2-D)/4a), where a is the first prime derived from data
+// s.t. D is a square modulo a, and b is the odd square root of D modulo a
+func (g *ClassGroup) Hash(data []byte) Element {
+	bitLen := g.D.BitLen()/2 - 2
+	if bitLen < 3 {
+		bitLen = 3
+	}
+	var dModA, b, c big.Int
+	for counter := uint64(0); ; counter++ {
+		a := expandHash("ClassGroupHash", data, counter, bitLen)
+		a.SetBit(a, bitLen-1, 1)
+		a.SetBit(a, 0, 1)
+		if !a.ProbablyPrime(20) {
+			continue
+		}
+		dModA.Mod(g.D, a)
+		if big.Jacobi(&dModA, a) != 1 {
+			continue
+		}
+		b.ModSqrt(&dModA, a)
+		if b.Bit(0) == 0 {
+			b.Sub(a, &b)
+		}
+		c.Mul(&b, &b)
+		c.Sub(&c, g.D)
+		c.Quo(&c, new(big.Int).Lsh(a, 2))
+		return reduce(a, &b, &c)
+	}
+}
+
+// OrderBound returns sqrt(|D|) * log2(|D|), which is larger than the class number
+func (g *ClassGroup) OrderBound() *big.Int {
+	ret := new(big.Int).Neg(g.D)
+	ret.Sqrt(ret)
+	return ret.Mul(ret, big.NewInt(int64(g.D.BitLen())))
+}
+
+// String returns D in decimal
+func (g *ClassGroup) String() string {
+	return g.D.String()
+}
+
+// nucomp composes two forms with the NUCOMP algorithm in
+// "Computational aspects of NUCOMP" by Jacobson and van der Poorten.
+// The intermediate form is partially reduced during the computation, so its coefficients stay
+// of the size of sqrt(|D|) instead of |D| as in the classical composition.
+func (g *ClassGroup) nucomp(f1, f2 *Form) *Form {
+	if f1.A.Cmp(f2.A) < 0 {
+		f1, f2 = f2, f1
+	}
+	u1, v1, w1 := f1.A, f1.B, f1.C
+	u2, v2, w2 := f2.A, f2.B, f2.C
+
+	var s, m, temp big.Int
+	s.Add(v1, v2)
+	s.Quo(&s, big2)
+	m.Sub(v2, &s)
+
+	// b*u2 + c*u1 = F
+	var F, b, c big.Int
+	F.GCD(&b, &c, u2, u1)
+	var G, Bx, By, Cy, Dy big.Int
+	if temp.Mod(&s, &F).Sign() == 0 {
+		G.Set(&F)
+		Bx.Mul(&m, &b)
+	} else {
+		// x*F + y*s = G
+		var x, y, H, l big.Int
+		G.GCD(&x, &y, &F, &s)
+		H.Quo(&F, &G)
+		l.Mod(w1, &H)
+		l.Mul(&l, &b)
+		temp.Mod(w2, &H)
+		temp.Mul(&temp, &c)
+		l.Add(&l, &temp)
+		l.Mul(&l, &y)
+		l.Mod(&l, &H)
+		Bx.Quo(&m, &H)
+		Bx.Mul(&Bx, &b)
+		temp.Quo(u1, &G)
+		temp.Quo(&temp, &H)
+		temp.Mul(&temp, &l)
+		Bx.Add(&Bx, &temp)
+	}
+	By.Quo(u1, &G)
+	Cy.Quo(u2, &G)
+	Dy.Quo(&s, &G)
+
+	// partial extended Euclidean algorithm on (By, Bx mod By)
+	bx := new(big.Int).Mod(&Bx, &By)
+	by := new(big.Int).Set(&By)
+	x, y := big.NewInt(1), big.NewInt(0)
+	z := 0
+	var q, t big.Int
+	for by.CmpAbs(g.l) > 0 && bx.Sign() != 0 {
+		q.DivMod(by, bx, &t)
+		by, bx = bx, by.Set(&t)
+		t.Mul(&q, x)
+		t.Sub(y, &t)
+		y, x = x, y.Set(&t)
+		z++
+	}
+	if z%2 == 1 {
+		by.Neg(by)
+		y.Neg(y)
+	}
+
+	var u3, v3, w3 big.Int
+	if z == 0 {
+		var Q1, cx, dx big.Int
+		Q1.Mul(&Cy, bx)
+		cx.Sub(&Q1, &m)
+		cx.Quo(&cx, &By)
+		dx.Mul(bx, &Dy)
+		dx.Sub(&dx, w2)
+		dx.Quo(&dx, &By)
+		u3.Mul(by, &Cy)
+		w3.Mul(bx, &cx)
+		temp.Mul(&G, &dx)
+		w3.Sub(&w3, &temp)
+		v3.Lsh(&Q1, 1)
+		v3.Sub(v2, &v3)
+		return reduce(&u3, &v3, &w3)
+	}
+
+	var ax, ay, cx, cy, dx, dy, Q1, Q2, Q3, Q4 big.Int
+	ax.Mul(&G, x)
+	ay.Mul(&G, y)
+	cx.Mul(&Cy, bx)
+	temp.Mul(&m, x)
+	cx.Sub(&cx, &temp)
+	cx.Quo(&cx, &By)
+	Q1.Mul(by, &cx)
+	Q2.Add(&Q1, &m)
+	dx.Mul(&Dy, bx)
+	temp.Mul(w2, x)
+	dx.Sub(&dx, &temp)
+	dx.Quo(&dx, &By)
+	Q3.Mul(y, &dx)
+	Q4.Add(&Q3, &Dy)
+	dy.Quo(&Q4, x)
+	if bx.Sign() != 0 {
+		cy.Quo(&Q2, bx)
+	} else {
+		cy.Mul(&cx, &dy)
+		cy.Sub(&cy, w1)
+		cy.Quo(&cy, &dx)
+	}
+	u3.Mul(by, &cy)
+	temp.Mul(&ay, &dy)
+	u3.Sub(&u3, &temp)
+	w3.Mul(bx, &cx)
+	temp.Mul(&ax, &dx)
+	w3.Sub(&w3, &temp)
+	v3.Add(&Q3, &Q4)
+	v3.Mul(&v3, &G)
+	v3.Sub(&v3, &Q1)
+	v3.Sub(&v3, &Q2)
+	return reduce(&u3, &v3, &w3)
+}
+
+// normalize returns the equivalent form of (a, b, c) with -a < b <= a
+func normalize(a, b, c *big.Int) (*big.Int, *big.Int, *big.Int) {
+	var negA big.Int
+	negA.Neg(a)
+	if b.Cmp(&negA) > 0 && b.Cmp(a) <= 0 {
+		return a, b, c
+	}
+	// r = floor((a-b) / 2a), (a, b, c) -> (a, b + 2ar, ar^2 + br + c)
+	var r, twoA, temp big.Int
+	twoA.Lsh(a, 1)
+	r.Sub(a, b)
+	r.Div(&r, &twoA)
+	newC := new(big.Int).Mul(a, &r)
+	newC.Add(newC, b)
+	newC.Mul(newC, &r)
+	newC.Add(newC, c)
+	temp.Mul(&twoA, &r)
+	newB := new(big.Int).Add(b, &temp)
+	return a, newB, newC
+}
+
+// reduce returns the unique reduced form equivalent to (a, b, c), with -a < b <= a <= c and b >= 0 if a = c
+func reduce(a, b, c *big.Int) *Form {
+	a, b, c = normalize(new(big.Int).Set(a), new(big.Int).Set(b), new(big.Int).Set(c))
+	for a.Cmp(c) > 0 || (a.Cmp(c) == 0 && b.Sign() < 0) {
+		// (a, b, c) -> (c, -b, a)
+		a, b, c = normalize(c, b.Neg(b), a)
+	}
+	return &Form{A: a, B: b, C: c}
+}
+
+// isReduced returns true if -A < B <= A <= C and B >= 0 when A = C
+func isReduced(f *Form) bool {
+	if f.B.CmpAbs(f.A) > 0 || new(big.Int).Neg(f.A).Cmp(f.B) == 0 {
+		return false
+	}
+	switch f.A.Cmp(f.C) {
+	case 1:
+		return false
+	case 0:
+		return f.B.Sign() >= 0
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+// Package group defines the hidden order groups that the proofs, the pre-computation and the accumulator work in,
+// e.g. the Accumulator, the ProductTree and the ProofScheduler of the accumulator package. It provides the RSA group
+// Z_N^* and the class group of an imaginary quadratic order, the latter needs no trusted setup since nobody knows
+// its order.
+package group
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+)
+
+var (
+	big1 = big.NewInt(1)
+	big2 = big.NewInt(2)
+	big4 = big.NewInt(4)
+	big7 = big.NewInt(7)
+	big8 = big.NewInt(8)
+)
+
+// Element is an element of a Group. The string of an element is used in Fiat-Shamir transcripts.
+type Element interface {
+	String() string
+}
+
+// Group is a hidden order group
+type Group interface {
+	// Identity returns the identity element
+	Identity() Element
+	// Mul returns a * b, or nil if a or b is nil
+	Mul(a, b Element) Element
+	// Exp returns base^x, x can be negative. It returns nil if base is nil, or if x is negative
+	// and base is not invertible.
+	Exp(base Element, x *big.Int) Element
+	// Inverse returns a^{-1}, or nil if a is nil or not invertible
+	Inverse(a Element) Element
+	// Equal returns true if a and b are the same element, nil is not equal to any element
+	Equal(a, b Element) bool
+	// Serialize encodes an element into bytes
+	Serialize(a Element) []byte
+	// Deserialize decodes an element from the bytes generated by Serialize
+	Deserialize(data []byte) (Element, error)
+	// Hash maps arbitrary bytes to an element of the group
+	Hash(data []byte) Element
+	// OrderBound returns an upper bound of the order of the group
+	OrderBound() *big.Int
+	// String describes the group, it is used in Fiat-Shamir transcripts
+	String() string
+}
+
+// MultiExp returns base^x for every exponent x in exps. The exponentiations share one chain of squarings of base,
+// which saves about half of the group operations of separate exponentiations. A non-positive exponent is computed by g.Exp.
+func MultiExp(g Group, base Element, exps []*big.Int) []Element {
+	ret := make([]Element, len(exps))
+	maxBitLen := 0
+	for i, x := range exps {
+		if x.Sign() <= 0 {
+			ret[i] = g.Exp(base, x)
+		} else if x.BitLen() > maxBitLen {
+			maxBitLen = x.BitLen()
+		}
+	}
+	// power = base^{2^k}, it is multiplied into the results of the exponents with bit k set
+	power := base
+	for k := 0; k < maxBitLen; k++ {
+		if k > 0 {
+			power = g.Mul(power, power)
+		}
+		for i, x := range exps {
+			if x.Sign() <= 0 || x.Bit(k) == 0 {
+				continue
+			}
+			if ret[i] == nil {
+				ret[i] = power
+			} else {
+				ret[i] = g.Mul(ret[i], power)
+			}
+		}
+	}
+	return ret
+}
+
+// expandHash returns a bitLen-bit integer derived from SHA-256(domain || data || counter || block index)
+func expandHash(domain string, data []byte, counter uint64, bitLen int) *big.Int {
+	numBytes := (bitLen + 7) / 8
+	buf := make([]byte, 0, numBytes+sha256.Size)
+	var block [8]byte
+	for i := uint64(0); len(buf) < numBytes; i++ {
+		h := sha256.New()
+		h.Write([]byte(domain))
+		h.Write(data)
+		binary.BigEndian.PutUint64(block[:], counter)
+		h.Write(block[:])
+		binary.BigEndian.PutUint64(block[:], i)
+		h.Write(block[:])
+		buf = h.Sum(buf)
+	}
+	ret := new(big.Int).SetBytes(buf[:numBytes])
+	if extra := numBytes*8 - bitLen; extra > 0 {
+		ret.Rsh(ret, uint(extra))
+	}
+	return ret
+}
@@ -0,0 +1,202 @@
+package group
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strconv"
+	"testing"
+)
+
+// toyRSAGroup returns the RSA group of N = 1019 * 1187, for test purpose only
+func toyRSAGroup() *RSAGroup {
+	return NewRSAGroup(big.NewInt(1019 * 1187))
+}
+
+// solveMod returns (s, t) s.t. all the solutions of a*x = b mod m are x = s + t*n
+func solveMod(a, b, m *big.Int) (*big.Int, *big.Int) {
+	var g, d big.Int
+	g.GCD(&d, nil, a, m)
+	q, r := new(big.Int).DivMod(b, &g, new(big.Int))
+	if r.Sign() != 0 {
+		panic("no solution")
+	}
+	q.Mul(q, &d)
+	q.Mod(q, m)
+	return q, new(big.Int).Quo(m, &g)
+}
+
+// compose is the classical composition of binary quadratic forms, as a reference for nucomp
+func compose(f1, f2 *Form) *Form {
+	a1, b1, c1 := f1.A, f1.B, f1.C
+	a2, b2 := f2.A, f2.B
+	g := new(big.Int).Add(b1, b2)
+	g.Quo(g, big2)
+	h := new(big.Int).Sub(b2, b1)
+	h.Quo(h, big2)
+	w := new(big.Int).GCD(nil, nil, a1, a2)
+	w.GCD(nil, nil, w, g)
+	s := new(big.Int).Quo(a1, w)
+	t := new(big.Int).Quo(a2, w)
+	u := new(big.Int).Quo(g, w)
+
+	tu := new(big.Int).Mul(t, u)
+	st := new(big.Int).Mul(s, t)
+	hu := new(big.Int).Mul(h, u)
+	temp := new(big.Int).Mul(s, c1)
+	kTemp, cf := solveMod(tu, new(big.Int).Add(hu, temp), st)
+	temp.Mul(t, kTemp)
+	n, _ := solveMod(new(big.Int).Mul(t, cf), temp.Sub(h, temp), s)
+	k := new(big.Int).Mul(cf, n)
+	k.Add(k, kTemp)
+	l := new(big.Int).Mul(t, k)
+	l.Sub(l, h)
+	l.Quo(l, s)
+	m := new(big.Int).Mul(tu, k)
+	m.Sub(m, hu)
+	m.Sub(m, new(big.Int).Mul(c1, s))
+	m.Quo(m, st)
+
+	b3 := new(big.Int).Mul(w, u)
+	b3.Sub(b3, new(big.Int).Mul(k, t))
+	b3.Sub(b3, new(big.Int).Mul(l, s))
+	c3 := new(big.Int).Mul(k, l)
+	c3.Sub(c3, new(big.Int).Mul(w, m))
+	return reduce(st, b3, c3)
+}
+
+func discriminant(f *Form) *big.Int {
+	ret := new(big.Int).Mul(f.B, f.B)
+	temp := new(big.Int).Mul(f.A, f.C)
+	temp.Lsh(temp, 2)
+	return ret.Sub(ret, temp)
+}
+
+func TestNUCOMP(t *testing.T) {
+	for _, bitLen := range []int{64, 256, 1024} {
+		g := NewClassGroupFromSeed([]byte("TestNUCOMP"), bitLen)
+		forms := make([]*Form, 20)
+		for i := range forms {
+			forms[i] = g.Hash([]byte(strconv.Itoa(i))).(*Form)
+		}
+		// squares and small multiples exercise the non-co-prime branches
+		forms = append(forms, g.Mul(forms[0], forms[0]).(*Form), g.Generator().(*Form), g.Identity().(*Form))
+		for i := range forms {
+			if discriminant(forms[i]).Cmp(g.D) != 0 || !isReduced(forms[i]) {
+				t.Fatalf("invalid form %s", forms[i].String())
+			}
+			for j := range forms {
+				got := g.Mul(forms[i], forms[j]).(*Form)
+				want := compose(forms[i], forms[j])
+				if !g.Equal(got, want) {
+					t.Fatalf("nucomp(%s, %s) = %s, want %s", forms[i], forms[j], got, want)
+				}
+				if discriminant(got).Cmp(g.D) != 0 || !isReduced(got) {
+					t.Errorf("nucomp returns an invalid form %s", got.String())
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateDiscriminant(t *testing.T) {
+	d := GenerateDiscriminant([]byte("seed"), 512)
+	if d.Cmp(GenerateDiscriminant([]byte("seed"), 512)) != 0 {
+		t.Errorf("GenerateDiscriminant is not deterministic")
+	}
+	p := new(big.Int).Neg(d)
+	if p.BitLen() != 512 || !p.ProbablyPrime(20) || p.Bit(0)&p.Bit(1)&p.Bit(2) != 1 {
+		t.Errorf("-D is not a 512-bit prime with -D = 7 mod 8")
+	}
+	if _, err := NewClassGroup(big.NewInt(-21)); err == nil {
+		t.Errorf("NewClassGroup should reject a discriminant that is not 1 mod 4")
+	}
+}
+
+func TestGroups(t *testing.T) {
+	groups := []Group{
+		toyRSAGroup(),
+		NewRSAGroup(new(big.Int).Mul(big.NewInt(1000003), big.NewInt(999983))),
+		NewClassGroupFromSeed([]byte("TestGroups"), 128),
+		NewClassGroupFromSeed([]byte("TestGroups"), 512),
+	}
+	for _, grp := range groups {
+		x := grp.Hash([]byte("x"))
+		y := grp.Hash([]byte("y"))
+		if grp.Equal(x, y) {
+			t.Errorf("Hash returns the same element for different inputs")
+		}
+		if !grp.Equal(grp.Mul(x, grp.Identity()), x) {
+			t.Errorf("x * 1 != x in group %s", grp.String())
+		}
+		if !grp.Equal(grp.Mul(x, grp.Inverse(x)), grp.Identity()) {
+			t.Errorf("x * x^{-1} != 1 in group %s", grp.String())
+		}
+		if !grp.Equal(grp.Mul(x, y), grp.Mul(y, x)) {
+			t.Errorf("x * y != y * x in group %s", grp.String())
+		}
+		a, _ := rand.Int(rand.Reader, big.NewInt(1<<40))
+		b, _ := rand.Int(rand.Reader, big.NewInt(1<<40))
+		ab := new(big.Int).Add(a, b)
+		if !grp.Equal(grp.Mul(grp.Exp(x, a), grp.Exp(x, b)), grp.Exp(x, ab)) {
+			t.Errorf("x^a * x^b != x^{a+b} in group %s", grp.String())
+		}
+		ab.Mul(a, b)
+		if !grp.Equal(grp.Exp(grp.Exp(x, a), b), grp.Exp(x, ab)) {
+			t.Errorf("(x^a)^b != x^{ab} in group %s", grp.String())
+		}
+		if !grp.Equal(grp.Exp(x, new(big.Int).Neg(a)), grp.Inverse(grp.Exp(x, a))) {
+			t.Errorf("x^{-a} != (x^a)^{-1} in group %s", grp.String())
+		}
+		if !grp.Equal(grp.Exp(x, new(big.Int)), grp.Identity()) {
+			t.Errorf("x^0 != 1 in group %s", grp.String())
+		}
+		exps := []*big.Int{a, b, ab, big.NewInt(1), new(big.Int), new(big.Int).Neg(a)}
+		for i, v := range MultiExp(grp, x, exps) {
+			if !grp.Equal(v, grp.Exp(x, exps[i])) {
+				t.Errorf("MultiExp is not consistent with Exp for exponent %d in group %s", i, grp.String())
+			}
+		}
+		z, err := grp.Deserialize(grp.Serialize(x))
+		if err != nil {
+			t.Fatalf("Deserialize returns error: %v", err)
+		}
+		if !grp.Equal(x, z) {
+			t.Errorf("Deserialize is not consistent with Serialize in group %s", grp.String())
+		}
+		if _, err = grp.Deserialize(grp.Serialize(x)[1:]); err == nil {
+			t.Errorf("Deserialize should reject a truncated encoding")
+		}
+		if grp.Mul(x, nil) != nil || grp.Exp(nil, a) != nil || grp.Inverse(nil) != nil || grp.Equal(nil, nil) {
+			t.Errorf("nil is not propagated in group %s", grp.String())
+		}
+	}
+}
+
+func TestRSAGroupNonUnit(t *testing.T) {
+	g := NewRSAGroup(new(big.Int).Mul(big.NewInt(1000003), big.NewInt(999983)))
+	// a multiple of a prime factor of N is not invertible
+	for _, a := range []Element{big.NewInt(1000003), new(big.Int), (*big.Int)(nil)} {
+		// compare with nil Elements, a nil *big.Int would not be equal to nil
+		if g.Inverse(a) != nil {
+			t.Errorf("Inverse of a non-unit should be nil")
+		}
+		if g.Exp(a, big.NewInt(-3)) != nil {
+			t.Errorf("negative power of a non-unit should be nil")
+		}
+		if g.Mul(g.Exp(a, big.NewInt(-3)), g.Identity()) != nil {
+			t.Errorf("product with nil should be nil")
+		}
+	}
+}
+
+func TestRSAGroupHash(t *testing.T) {
+	g := toyRSAGroup()
+	// the order of QR_N is 509 * 593
+	order := big.NewInt(509 * 593)
+	for i := 0; i < 10; i++ {
+		x := g.Hash([]byte(strconv.Itoa(i)))
+		if !g.Equal(g.Exp(x, order), g.Identity()) {
+			t.Errorf("Hash does not return a quadratic residue")
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package group
+
+import (
+	"errors"
+	"math/big"
+)
+
+// RSAGroup is the multiplicative group Z_N^* for an RSA modulus N, its elements are *big.Int in [0, N).
+// The order of the group is hidden as long as the factorization of N is unknown.
+type RSAGroup struct {
+	N *big.Int
+}
+
+// NewRSAGroup returns the RSA group of modulus n
+func NewRSAGroup(n *big.Int) *RSAGroup {
+	return &RSAGroup{N: n}
+}
+
+// Identity returns 1
+func (g *RSAGroup) Identity() Element {
+	return big.NewInt(1)
+}
+
+// Mul returns a * b mod N, or nil if a or b is nil
+func (g *RSAGroup) Mul(a, b Element) Element {
+	x, y := toInt(a), toInt(b)
+	if x == nil || y == nil {
+		return nil
+	}
+	ret := new(big.Int).Mul(x, y)
+	return ret.Mod(ret, g.N)
+}
+
+// Exp returns base^x mod N. For a negative x, it returns nil if base is not invertible.
+func (g *RSAGroup) Exp(base Element, x *big.Int) Element {
+	b := toInt(base)
+	if b == nil {
+		return nil
+	}
+	ret := new(big.Int).Exp(b, x, g.N)
+	if ret == nil {
+		// not a nil *big.Int, which is a non-nil Element
+		return nil
+	}
+	return ret
+}
+
+// Inverse returns a^{-1} mod N, or nil if a is not invertible
+func (g *RSAGroup) Inverse(a Element) Element {
+	x := toInt(a)
+	if x == nil {
+		return nil
+	}
+	ret := new(big.Int).ModInverse(x, g.N)
+	if ret == nil {
+		return nil
+	}
+	return ret
+}
+
+// Equal returns true if a = b, nil is not equal to any element
+func (g *RSAGroup) Equal(a, b Element) bool {
+	x, y := toInt(a), toInt(b)
+	if x == nil || y == nil {
+		return false
+	}
+	return x.Cmp(y) == 0
+}
+
+// toInt returns the element as a *big.Int, both nil and a nil *big.Int give nil
+func toInt(a Element) *big.Int {
+	x, _ := a.(*big.Int)
+	return x
+}
+
+// Serialize encodes an element into big-endian bytes of the same length as N
+func (g *RSAGroup) Serialize(a Element) []byte {
+	return a.(*big.Int).FillBytes(make([]byte, (g.N.BitLen()+7)/8))
+}
+
+// Deserialize decodes an element from the bytes generated by Serialize
+func (g *RSAGroup) Deserialize(data []byte) (Element, error) {
+	if len(data) != (g.N.BitLen()+7)/8 {
+		return nil, errors.New("invalid length of RSA group element")
+	}
+	ret := new(big.Int).SetBytes(data)
+	if ret.Cmp(g.N) >= 0 {
+		return nil, errors.New("RSA group element is not reduced modulo N")
+	}
+	return ret, nil
+}
+
+// Hash maps data to a quadratic residue modulo N
+func (g *RSAGroup) Hash(data []byte) Element {
+	// 128 extra bits make the result statistically close to uniform modulo N
+	ret := expandHash("RSAGroupHash", data, 0, g.N.BitLen()+128)
+	ret.Mod(ret, g.N)
+	ret.Mul(ret, ret)
+	return ret.Mod(ret, g.N)
+}
+
+// OrderBound returns N
+func (g *RSAGroup) OrderBound() *big.Int {
+	return g.N
+}
+
+// String returns N in decimal
+func (g *RSAGroup) String() string {
+	return g.N.String()
+}
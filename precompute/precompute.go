@@ -3,63 +3,73 @@ package precompute
 import (
 	"context"
 	"math/big"
+
+	"github.com/jiajunxin/rsa_accumulator/group"
 )
 
 // const byteChunkSize = 125000
 
-// Table is the precomputing table
+// Table is the precomputing table of a fixed base g,
+// table[i] = g^{2^{8*byteChunkSize*i}} in the group
 type Table struct {
-	g             *big.Int
-	n             *big.Int
+	grp           group.Group
 	byteChunkSize int
-	table         []*big.Int
+	table         []group.Element
 }
 
-// NewTable creates a new precomputing table
+// NewTable creates a new precomputing table in the RSA group of modulus n
 func NewTable(g, n, elementUpperBound *big.Int, numElements uint64, byteChunkSize int) *Table {
+	return NewGroupTable(group.NewRSAGroup(n), g, elementUpperBound, numElements, byteChunkSize)
+}
+
+// NewGroupTable creates a new precomputing table of the base g in grp
+func NewGroupTable(grp group.Group, g group.Element, elementUpperBound *big.Int, numElements uint64, byteChunkSize int) *Table {
 	t := &Table{
-		g:             g,
-		n:             n,
+		grp:           grp,
 		byteChunkSize: byteChunkSize,
 	}
 	maxBitLen := elementUpperBound.BitLen() * int(numElements)
 	numByteChunks := maxBitLen / (t.byteChunkSize * 8)
-	t.table = make([]*big.Int, numByteChunks)
-	t.table[0] = new(big.Int).Set(g)
+	t.table = make([]group.Element, numByteChunks)
+	t.table[0] = g
 	opt := new(big.Int).Lsh(big1, uint(t.byteChunkSize*8))
 	for i := 1; i < numByteChunks; i++ {
-		t.table[i] = new(big.Int).Exp(t.table[i-1], opt, n)
+		t.table[i] = grp.Exp(t.table[i-1], opt)
 	}
 	return t
 }
 
-// Compute computes the result of base^x mod n with specified number of goroutines
+// Compute computes the result of base^x mod n with specified number of goroutines,
+// the table must be created in an RSA group, e.g. by NewTable
 func (t *Table) Compute(x *big.Int, numRoutine int) *big.Int {
+	return t.ComputeElement(x, numRoutine).(*big.Int)
+}
+
+// ComputeContext is Compute with cancellation, it returns ctx.Err() if ctx is done before the result is computed
+func (t *Table) ComputeContext(ctx context.Context, x *big.Int, numRoutine int) (*big.Int, error) {
+	res, err := t.ComputeElementContext(ctx, x, numRoutine)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*big.Int), nil
+}
+
+// ComputeElement computes the result of base^x in the group of the table with specified number of goroutines
+func (t *Table) ComputeElement(x *big.Int, numRoutine int) group.Element {
+	res, _ := t.ComputeElementContext(context.Background(), x, numRoutine)
+	return res
+}
+
+// ComputeElementContext is ComputeElement with cancellation, the context is checked before every exponentiation
+// of a chunk. It returns ctx.Err() if ctx is done before the result is computed.
+func (t *Table) ComputeElementContext(ctx context.Context, x *big.Int, numRoutine int) (group.Element, error) {
 	xBytes := x.Bytes()
-	inputChan := make(chan input, numRoutine<<2)
-	outputChan := make(chan *big.Int, numRoutine)
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	for i := 0; i < numRoutine; i++ {
-		go t.routineCompute(ctx, t.n, xBytes, inputChan, outputChan)
+	counter := len(xBytes) / t.byteChunkSize
+	if len(xBytes)%t.byteChunkSize != 0 {
+		counter++
 	}
-	resChan := make(chan *big.Int)
-	go func() {
-		res := big.NewInt(1)
-		counter := len(xBytes) / t.byteChunkSize
-		if len(xBytes)%t.byteChunkSize != 0 {
-			counter++
-		}
-		for out := range outputChan {
-			res.Mul(res, out)
-			res.Mod(res, t.n)
-			counter--
-			if counter == 0 {
-				resChan <- res
-				return
-			}
-		}
-	}()
+	inputChan := make(chan input, counter)
+	outputChan := make(chan group.Element, counter)
 	for i := len(xBytes); i > 0; i -= t.byteChunkSize {
 		right := i
 		left := right - t.byteChunkSize
@@ -73,7 +83,23 @@ func (t *Table) Compute(x *big.Int, numRoutine int) *big.Int {
 			tableIdx: idx,
 		}
 	}
-	return <-resChan
+	close(inputChan)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for i := 0; i < numRoutine; i++ {
+		go t.routineCompute(ctx, xBytes, inputChan, outputChan)
+	}
+	res := t.grp.Identity()
+	for ; counter > 0; counter-- {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case out := <-outputChan:
+			res = t.grp.Mul(res, out)
+		}
+	}
+	return res, nil
 }
 
 type input struct {
@@ -82,19 +108,15 @@ type input struct {
 	tableIdx int
 }
 
-func (t *Table) routineCompute(ctx context.Context, n *big.Int, xBytes []byte,
-	inputChan chan input, resChan chan *big.Int) {
+// routineCompute computes the chunks from inputChan until there is no chunk left or ctx is done
+func (t *Table) routineCompute(ctx context.Context, xBytes []byte,
+	inputChan <-chan input, resChan chan<- group.Element) {
 	opt := new(big.Int)
-	for {
-		select {
-		case <-ctx.Done():
+	for in := range inputChan {
+		if ctx.Err() != nil {
 			return
-		case in := <-inputChan:
-			opt.SetBytes(xBytes[in.left:in.right])
-			res := new(big.Int).Exp(t.table[in.tableIdx], opt, n)
-			resChan <- res
-		default:
-			continue
 		}
+		opt.SetBytes(xBytes[in.left:in.right])
+		resChan <- t.grp.Exp(t.table[in.tableIdx], opt)
 	}
 }
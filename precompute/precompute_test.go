@@ -1,12 +1,15 @@
 package precompute
 
 import (
+	"context"
+	"errors"
 	"math/big"
 	"reflect"
 	"sync"
 	"testing"
 
 	"github.com/jiajunxin/rsa_accumulator/accumulator"
+	"github.com/jiajunxin/rsa_accumulator/group"
 )
 
 const (
@@ -147,6 +150,37 @@ func TestTable_Compute(t1 *testing.T) {
 	}
 }
 
+func TestTable_ComputeElement(t *testing.T) {
+	grp := group.NewClassGroupFromSeed([]byte("precompute"), 256)
+	g := grp.Generator()
+	elemUpperBound := big.NewInt(1 << 16)
+	x := new(big.Int).Lsh(big.NewInt(12345), 300)
+	x.Add(x, big.NewInt(6789))
+	table := NewGroupTable(grp, g, elemUpperBound, 32, smallByteChunkSize)
+	if got := table.ComputeElement(x, 4); !grp.Equal(got, grp.Exp(g, x)) {
+		t.Errorf("ComputeElement() = %v, want %v", got, grp.Exp(g, x))
+	}
+}
+
+func TestTable_ComputeContext(t *testing.T) {
+	grp := group.NewClassGroupFromSeed([]byte("precompute"), 256)
+	g := grp.Generator()
+	x := new(big.Int).Lsh(big.NewInt(12345), 300)
+	table := NewGroupTable(grp, g, big.NewInt(1<<16), 32, smallByteChunkSize)
+	got, err := table.ComputeElementContext(context.Background(), x, 2)
+	if err != nil {
+		t.Fatalf("ComputeElementContext() returns error: %v", err)
+	}
+	if !grp.Equal(got, grp.Exp(g, x)) {
+		t.Errorf("ComputeElementContext() = %v, want %v", got, grp.Exp(g, x))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := table.ComputeElementContext(ctx, x, 2); !errors.Is(err, context.Canceled) {
+		t.Errorf("ComputeElementContext() with a cancelled context returns %v, want %v", err, context.Canceled)
+	}
+}
+
 func accumulate(setup *accumulator.Setup, reps []*big.Int) *big.Int {
 	acc := new(big.Int).Set(setup.G)
 	for _, v := range reps {
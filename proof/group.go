@@ -0,0 +1,242 @@
+package proof
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+
+	fiatshamir "github.com/jiajunxin/rsa_accumulator/fiat-shamir"
+	"github.com/jiajunxin/rsa_accumulator/group"
+)
+
+// GroupPublicParameters holds the public parameters of the proofs in a generic hidden order group
+type GroupPublicParameters struct {
+	Group group.Group
+	G     group.Element
+	H     group.Element
+}
+
+// NewGroupPublicParameters generates a new public parameter configuration in grp
+func NewGroupPublicParameters(grp group.Group, g, h group.Element) *GroupPublicParameters {
+	return &GroupPublicParameters{
+		Group: grp,
+		G:     g,
+		H:     h,
+	}
+}
+
+// toGroup returns the public parameters in the RSA group of modulus N
+func (pp *PublicParameters) toGroup() *GroupPublicParameters {
+	return NewGroupPublicParameters(group.NewRSAGroup(pp.N), pp.G, pp.H)
+}
+
+// GroupMultiExp computes g^x * h^r in grp
+func GroupMultiExp(grp group.Group, g group.Element, x *big.Int, h group.Element, r *big.Int) group.Element {
+	return grp.Mul(grp.Exp(g, x), grp.Exp(h, r))
+}
+
+// GroupPoKEStarProof contains the proofs for PoKE in a generic group
+type GroupPoKEStarProof struct {
+	Q group.Element
+	R *big.Int
+}
+
+// GroupPoKEStarProve proves knowledge of x s.t. g^x = C in pp.Group
+func GroupPoKEStarProve(pp *GroupPublicParameters, C group.Element, x *big.Int) (*GroupPoKEStarProof, error) {
+	grp := pp.Group
+	if !grp.Equal(grp.Exp(pp.G, x), C) {
+		return nil, errors.New("PoKEStar inputs a invalid statement")
+	}
+
+	var q, l big.Int
+	r := new(big.Int)
+	transcript := fiatshamir.InitTranscript([]string{"PoKEStar", pp.G.String(), grp.String(), C.String()}, fiatshamir.Max252)
+	l.Set(transcript.GetPrimeChallengeUsingTranscript())
+	q.DivMod(x, &l, r)
+	return &GroupPoKEStarProof{
+		Q: grp.Exp(pp.G, &q),
+		R: r,
+	}, nil
+}
+
+// GroupPoKEStarVerify checks the proof, returns true if everything is good
+func GroupPoKEStarVerify(pp *GroupPublicParameters, C group.Element, proof *GroupPoKEStarProof) bool {
+	if proof == nil {
+		return false
+	}
+	var l big.Int
+	transcript := fiatshamir.InitTranscript([]string{"PoKEStar", pp.G.String(), pp.Group.String(), C.String()}, fiatshamir.Max252)
+	l.Set(transcript.GetPrimeChallengeUsingTranscript())
+	return pp.Group.Equal(GroupMultiExp(pp.Group, proof.Q, &l, pp.G, proof.R), C)
+}
+
+// GroupPoKE2Proof contains the proofs for PoKE2 in a generic group
+type GroupPoKE2Proof struct {
+	Z group.Element
+	Q group.Element
+	R *big.Int
+}
+
+// poke2Generator returns the auxiliary generator of PoKE2 for the statement u^x = w, which is hashed from the
+// statement so that the prover knows no discrete logarithm of it
+func poke2Generator(grp group.Group, u, w group.Element) group.Element {
+	return grp.Hash([]byte(strings.Join([]string{"PoKE2", grp.String(), u.String(), w.String()}, ",")))
+}
+
+// GroupPoKE2Prove proves knowledge of x s.t. u^x = w in grp. Unlike PoKE*, the base u can be chosen by the prover,
+// e.g. an accumulator. x can be negative.
+func GroupPoKE2Prove(grp group.Group, u, w group.Element, x *big.Int) (*GroupPoKE2Proof, error) {
+	if !grp.Equal(grp.Exp(u, x), w) {
+		return nil, errors.New("PoKE2 inputs a invalid statement")
+	}
+
+	g := poke2Generator(grp, u, w)
+	z := grp.Exp(g, x)
+	var q, l, alpha big.Int
+	r := new(big.Int)
+	transcript := fiatshamir.InitTranscript([]string{"PoKE2", grp.String(), u.String(), w.String(), z.String()}, fiatshamir.Max252)
+	l.Set(transcript.GetPrimeChallengeUsingTranscript())
+	alpha.Set(transcript.GetIntChallengeUsingTranscript())
+	q.DivMod(x, &l, r)
+	return &GroupPoKE2Proof{
+		Z: z,
+		Q: grp.Exp(grp.Mul(u, grp.Exp(g, &alpha)), &q),
+		R: r,
+	}, nil
+}
+
+// GroupPoKE2Verify checks the proof, returns true if everything is good
+func GroupPoKE2Verify(grp group.Group, u, w group.Element, proof *GroupPoKE2Proof) bool {
+	if proof == nil || proof.Z == nil || proof.Q == nil || proof.R == nil {
+		return false
+	}
+	var l, alpha big.Int
+	transcript := fiatshamir.InitTranscript([]string{"PoKE2", grp.String(), u.String(), w.String(), proof.Z.String()}, fiatshamir.Max252)
+	l.Set(transcript.GetPrimeChallengeUsingTranscript())
+	alpha.Set(transcript.GetIntChallengeUsingTranscript())
+	if proof.R.Sign() < 0 || proof.R.Cmp(&l) >= 0 {
+		return false
+	}
+	g := poke2Generator(grp, u, w)
+	lhs := GroupMultiExp(grp, proof.Q, &l, grp.Mul(u, grp.Exp(g, &alpha)), proof.R)
+	return grp.Equal(lhs, grp.Mul(w, grp.Exp(proof.Z, &alpha)))
+}
+
+// GroupPoEProof contains the proofs for PoE in a generic group
+type GroupPoEProof struct {
+	Q group.Element
+}
+
+// GroupPoEProve proves base^x = C in grp
+func GroupPoEProve(grp group.Group, base, C group.Element, x *big.Int) (*GroupPoEProof, error) {
+	if !grp.Equal(grp.Exp(base, x), C) {
+		return nil, errors.New("PoKEStar inputs a invalid statement")
+	}
+
+	var q, l big.Int
+	transcript := fiatshamir.InitTranscript([]string{"PoE", base.String(), grp.String(), C.String(), x.String()}, fiatshamir.Max252)
+	l.Set(transcript.GetPrimeChallengeUsingTranscript())
+	q.Div(x, &l)
+	return &GroupPoEProof{
+		Q: grp.Exp(base, &q),
+	}, nil
+}
+
+// GroupPoEVerify checks the proof, returns true if everything is good
+func GroupPoEVerify(grp group.Group, base, C group.Element, x *big.Int, proof *GroupPoEProof) bool {
+	if proof == nil {
+		return false
+	}
+	var l, r big.Int
+	transcript := fiatshamir.InitTranscript([]string{"PoE", base.String(), grp.String(), C.String(), x.String()}, fiatshamir.Max252)
+	l.Set(transcript.GetPrimeChallengeUsingTranscript())
+	r.Mod(x, &l)
+	return grp.Equal(GroupMultiExp(grp, proof.Q, &l, base, &r), C)
+}
+
+// GroupZKPoKEProof contains the proofs for ZKPoKE in a generic group
+type GroupZKPoKEProof struct {
+	Z    group.Element
+	Ag   group.Element
+	Au   group.Element
+	Qg   group.Element
+	Qu   group.Element
+	Rx   *big.Int
+	Rrho *big.Int
+}
+
+// GroupZKPoKEProve proves in zero-knowledge of knowledge x s.t. u^x = w in pp.Group.
+// The random coins are sampled from [0, OrderBound * 2^{2*securityParam-2}).
+func GroupZKPoKEProve(pp *GroupPublicParameters, u group.Element, x *big.Int, w group.Element) (*GroupZKPoKEProof, error) {
+	grp := pp.Group
+	if !grp.Equal(grp.Exp(u, x), w) {
+		return nil, errors.New("ZKPoKEProve inputs a invalid statement")
+	}
+
+	b := new(big.Int).Set(grp.OrderBound())
+	lsh := 2*securityParam - 2
+	b.Lsh(b, uint(lsh))
+	k, err := rand.Int(rand.Reader, b)
+	if err != nil {
+		return nil, err
+	}
+	rhox, err := rand.Int(rand.Reader, b)
+	if err != nil {
+		return nil, err
+	}
+	rhok, err := rand.Int(rand.Reader, b)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret GroupZKPoKEProof
+	ret.Z = GroupMultiExp(grp, pp.G, x, pp.H, rhox)
+	ret.Ag = GroupMultiExp(grp, pp.G, k, pp.H, rhok)
+	ret.Au = grp.Exp(u, k)
+
+	var c, l big.Int
+	transcript := fiatshamir.InitTranscript([]string{"ZKPoKE", pp.G.String(), pp.H.String(),
+		grp.String(), u.String(), w.String(), ret.Z.String(), ret.Ag.String(), ret.Au.String()}, fiatshamir.Max252)
+	c.Set(transcript.GetIntChallengeUsingTranscript())
+	l.Set(transcript.GetPrimeChallengeUsingTranscript())
+
+	var sx, srho big.Int //sx = k+ cx, srho = rhok + c*rhox
+	sx.Mul(&c, x)
+	sx.Add(&sx, k)
+	srho.Mul(&c, rhox)
+	srho.Add(&srho, rhok)
+
+	var qx, qrho big.Int // qx*l + rx = sx, qrho*l + rrho = srho
+	ret.Rx = new(big.Int)
+	ret.Rrho = new(big.Int)
+	qx.DivMod(&sx, &l, ret.Rx)
+	qrho.DivMod(&srho, &l, ret.Rrho)
+
+	ret.Qg = GroupMultiExp(grp, pp.G, &qx, pp.H, &qrho)
+	ret.Qu = grp.Exp(u, &qx)
+	return &ret, nil
+}
+
+// GroupZKPoKEVerify checks the proof, returns true if everything is good
+func GroupZKPoKEVerify(pp *GroupPublicParameters, u, w group.Element, proof *GroupZKPoKEProof) bool {
+	if proof == nil {
+		return false
+	}
+	grp := pp.Group
+	var c, l big.Int
+	transcript := fiatshamir.InitTranscript([]string{"ZKPoKE", pp.G.String(), pp.H.String(),
+		grp.String(), u.String(), w.String(), proof.Z.String(), proof.Ag.String(), proof.Au.String()}, fiatshamir.Max252)
+	c.Set(transcript.GetIntChallengeUsingTranscript())
+	l.Set(transcript.GetPrimeChallengeUsingTranscript())
+
+	// checking the fist condition
+	lhs := grp.Mul(grp.Exp(proof.Qg, &l), GroupMultiExp(grp, pp.G, proof.Rx, pp.H, proof.Rrho))
+	rhs := grp.Mul(grp.Exp(proof.Z, &c), proof.Ag)
+	if !grp.Equal(lhs, rhs) {
+		return false
+	}
+	lhs = GroupMultiExp(grp, proof.Qu, &l, u, proof.Rx)
+	rhs = grp.Mul(grp.Exp(w, &c), proof.Au)
+	return grp.Equal(lhs, rhs)
+}
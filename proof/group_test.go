@@ -0,0 +1,157 @@
+package proof
+
+import (
+	"math/big"
+	"testing"
+
+	fiatshamir "github.com/jiajunxin/rsa_accumulator/fiat-shamir"
+	"github.com/jiajunxin/rsa_accumulator/group"
+)
+
+// getTestGroups returns the public parameters in the RSA group of the RSA-2048 challenge number and in a class group
+func getTestGroups() []*GroupPublicParameters {
+	n, _ := new(big.Int).SetString("25195908475657893494027183240048398571429282126204032027777137836043662020707595556264018525880784406918290641249515082189298559149176184502808489120072844992687392807287776735971418347270261896375014971824691165077613379859095700097330459748808428401797429100642458691817195118746121515172654632282216869987549182422433637259085141865462043576798423387184774447920739934236584823824281198163815010674810451660377306056201619676256133844143603833904414952634432190114657544454178424020924616515723350778707749817125772467962926386356373289912154831438167899885040445364023527381951378636564391212010397122822120720357", 10)
+	rsaGroup := group.NewRSAGroup(n)
+	classGroup := group.NewClassGroupFromSeed([]byte("proof"), 512)
+	return []*GroupPublicParameters{
+		NewGroupPublicParameters(rsaGroup, rsaGroup.Hash([]byte("g")), rsaGroup.Hash([]byte("h"))),
+		NewGroupPublicParameters(classGroup, classGroup.Generator(), classGroup.Hash([]byte("h"))),
+	}
+}
+
+func TestGroupProofs(t *testing.T) {
+	x, _ := new(big.Int).SetString("123456789012345678901234567890123456789012345678901234567890123456789", 10)
+	for _, pp := range getTestGroups() {
+		grp := pp.Group
+		C := grp.Exp(pp.G, x)
+		wrong := grp.Mul(C, pp.G)
+
+		poke, err := GroupPoKEStarProve(pp, C, x)
+		if err != nil {
+			t.Fatalf("GroupPoKEStarProve returns error: %v", err)
+		}
+		if !GroupPoKEStarVerify(pp, C, poke) {
+			t.Errorf("valid PoKE* proof does not pass verification in group %s", grp.String())
+		}
+		if GroupPoKEStarVerify(pp, wrong, poke) {
+			t.Errorf("PoKE* proof of another statement should not pass verification")
+		}
+
+		poe, err := GroupPoEProve(grp, pp.G, C, x)
+		if err != nil {
+			t.Fatalf("GroupPoEProve returns error: %v", err)
+		}
+		if !GroupPoEVerify(grp, pp.G, C, x, poe) {
+			t.Errorf("valid PoE proof does not pass verification in group %s", grp.String())
+		}
+		if GroupPoEVerify(grp, pp.G, wrong, x, poe) {
+			t.Errorf("PoE proof of another statement should not pass verification")
+		}
+		if _, err = GroupPoEProve(grp, pp.G, wrong, x); err == nil {
+			t.Errorf("GroupPoEProve should fail for an invalid statement")
+		}
+
+		u := grp.Hash([]byte("u"))
+		w := grp.Exp(u, x)
+		negX := new(big.Int).Neg(x)
+		poke2, err := GroupPoKE2Prove(grp, u, grp.Exp(u, negX), negX)
+		if err != nil {
+			t.Fatalf("GroupPoKE2Prove returns error: %v", err)
+		}
+		if !GroupPoKE2Verify(grp, u, grp.Exp(u, negX), poke2) {
+			t.Errorf("valid PoKE2 proof does not pass verification in group %s", grp.String())
+		}
+		if GroupPoKE2Verify(grp, u, w, poke2) {
+			t.Errorf("PoKE2 proof of another statement should not pass verification")
+		}
+
+		zkpoke, err := GroupZKPoKEProve(pp, u, x, w)
+		if err != nil {
+			t.Fatalf("GroupZKPoKEProve returns error: %v", err)
+		}
+		if !GroupZKPoKEVerify(pp, u, w, zkpoke) {
+			t.Errorf("valid ZKPoKE proof does not pass verification in group %s", grp.String())
+		}
+		if GroupZKPoKEVerify(pp, u, grp.Mul(w, u), zkpoke) {
+			t.Errorf("ZKPoKE proof of another statement should not pass verification")
+		}
+	}
+}
+
+func TestRSAProofsConsistentWithGroupProofs(t *testing.T) {
+	pp := getTestGroups()[0]
+	rsaPP := NewPublicParameters(pp.Group.(*group.RSAGroup).N, pp.G.(*big.Int), pp.H.(*big.Int))
+	x := big.NewInt(987654321)
+	C := new(big.Int).Exp(rsaPP.G, x, rsaPP.N)
+
+	poke, err := PoKEStarProve(rsaPP, C, x)
+	if err != nil {
+		t.Fatalf("PoKEStarProve returns error: %v", err)
+	}
+	if !GroupPoKEStarVerify(pp, C, &GroupPoKEStarProof{Q: poke.Q, R: poke.R}) {
+		t.Errorf("PoKE* proof in the RSA group is not consistent with the generic one")
+	}
+	poe, err := PoEProve(rsaPP.G, rsaPP.N, C, x)
+	if err != nil {
+		t.Fatalf("PoEProve returns error: %v", err)
+	}
+	if !GroupPoEVerify(pp.Group, pp.G, C, x, &GroupPoEProof{Q: poe.Q}) {
+		t.Errorf("PoE proof in the RSA group is not consistent with the generic one")
+	}
+	zkpoke, err := ZKPoKEProve(rsaPP, rsaPP.G, x, C)
+	if err != nil {
+		t.Fatalf("ZKPoKEProve returns error: %v", err)
+	}
+	if !ZKPoKEVerify(rsaPP, rsaPP.G, C, zkpoke) {
+		t.Errorf("valid ZKPoKE proof does not pass verification")
+	}
+	poke2, err := PoKE2Prove(rsaPP.N, rsaPP.G, C, x)
+	if err != nil {
+		t.Fatalf("PoKE2Prove returns error: %v", err)
+	}
+	if !GroupPoKE2Verify(pp.Group, pp.G, C, &GroupPoKE2Proof{Z: poke2.Z, Q: poke2.Q, R: poke2.R}) {
+		t.Errorf("PoKE2 proof in the RSA group is not consistent with the generic one")
+	}
+}
+
+// TestPoKE2ProverChosenBase forges PoKE* and PoKE2 proofs of u^{c/d} = w, where u = g^d and w = g^c are chosen by
+// the prover and d does not divide c, so no integer exponent exists
+func TestPoKE2ProverChosenBase(t *testing.T) {
+	pp := getTestGroups()[0]
+	grp := pp.Group
+	c, d := big.NewInt(1000003), big.NewInt(999983)
+	u, w := grp.Exp(pp.G, d), grp.Exp(pp.G, c)
+
+	// PoKE* with the base u: Q^l * u^r = w holds for r = c/d mod l and Q = g^{(c-d*r)/l}
+	var l, r, q big.Int
+	transcript := fiatshamir.InitTranscript([]string{"PoKEStar", u.String(), grp.String(), w.String()}, fiatshamir.Max252)
+	l.Set(transcript.GetPrimeChallengeUsingTranscript())
+	r.ModInverse(d, &l)
+	r.Mul(&r, c)
+	r.Mod(&r, &l)
+	q.Mul(d, &r)
+	q.Sub(c, &q)
+	q.Div(&q, &l)
+	if !GroupPoKEStarVerify(NewGroupPublicParameters(grp, u, nil), w, &GroupPoKEStarProof{Q: grp.Exp(pp.G, &q), R: &r}) {
+		t.Fatalf("PoKE* with a prover-chosen base is expected to be forgeable")
+	}
+
+	// the same for PoKE2 needs z = g'^r for the auxiliary generator g', but l and r depend on z
+	g := poke2Generator(grp, u, w)
+	z := grp.Exp(g, &r)
+	var alpha big.Int
+	transcript = fiatshamir.InitTranscript([]string{"PoKE2", grp.String(), u.String(), w.String(), z.String()}, fiatshamir.Max252)
+	l.Set(transcript.GetPrimeChallengeUsingTranscript())
+	alpha.Set(transcript.GetIntChallengeUsingTranscript())
+	r.ModInverse(d, &l)
+	r.Mul(&r, c)
+	r.Mod(&r, &l)
+	q.Mul(d, &r)
+	q.Sub(c, &q)
+	q.Div(&q, &l)
+	// Q = (u * g'^alpha)^q with the discrete logarithm of g' unknown, use g^q as the best guess
+	forged := &GroupPoKE2Proof{Z: z, Q: grp.Exp(pp.G, &q), R: new(big.Int).Set(&r)}
+	if GroupPoKE2Verify(grp, u, w, forged) {
+		t.Errorf("forged PoKE2 proof should not pass verification")
+	}
+}
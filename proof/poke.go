@@ -5,11 +5,9 @@
 package proof
 
 import (
-	"crypto/rand"
-	"errors"
 	"math/big"
 
-	fiatshamir "github.com/jiajunxin/rsa_accumulator/fiat-shamir"
+	"github.com/jiajunxin/rsa_accumulator/group"
 )
 
 // MultiExp computes g^x * h^r mod n
@@ -30,20 +28,14 @@ type PoKEStarProof struct {
 
 // PoKEStarProve proves knowledge of x s.t.  g^x = C
 func PoKEStarProve(pp *PublicParameters, C, x *big.Int) (*PoKEStarProof, error) {
-	var ret PoKEStarProof
-	ret.Q = new(big.Int)
-	ret.R = new(big.Int)
-	var temp, q, l big.Int
-	temp.Exp(pp.G, x, pp.N)
-	if temp.Cmp(C) != 0 {
-		return nil, errors.New("PoKEStar inputs a invalid statement")
+	proof, err := GroupPoKEStarProve(pp.toGroup(), C, x)
+	if err != nil {
+		return nil, err
 	}
-
-	transcript := fiatshamir.InitTranscript([]string{"PoKEStar", pp.G.String(), pp.N.String(), C.String()}, fiatshamir.Max252)
-	l.Set(transcript.GetPrimeChallengeUsingTranscript())
-	q.DivMod(x, &l, ret.R)
-	ret.Q.Exp(pp.G, &q, pp.N)
-	return &ret, nil
+	return &PoKEStarProof{
+		Q: proof.Q.(*big.Int),
+		R: proof.R,
+	}, nil
 }
 
 // PoKEStarVerify checks the proof, returns true if everything is good
@@ -51,12 +43,35 @@ func PoKEStarVerify(pp *PublicParameters, C *big.Int, proof *PoKEStarProof) bool
 	if proof == nil {
 		return false
 	}
-	var temp, l big.Int
-	transcript := fiatshamir.InitTranscript([]string{"PoKEStar", pp.G.String(), pp.N.String(), C.String()}, fiatshamir.Max252)
-	l.Set(transcript.GetPrimeChallengeUsingTranscript())
+	return GroupPoKEStarVerify(pp.toGroup(), C, &GroupPoKEStarProof{Q: proof.Q, R: proof.R})
+}
+
+// PoKE2Proof contains the proofs for PoKE2
+type PoKE2Proof struct {
+	Z *big.Int
+	Q *big.Int
+	R *big.Int
+}
+
+// PoKE2Prove proves knowledge of x s.t. u^x = w mod n, u needs not be a trusted generator
+func PoKE2Prove(n, u, w, x *big.Int) (*PoKE2Proof, error) {
+	proof, err := GroupPoKE2Prove(group.NewRSAGroup(n), u, w, x)
+	if err != nil {
+		return nil, err
+	}
+	return &PoKE2Proof{
+		Z: proof.Z.(*big.Int),
+		Q: proof.Q.(*big.Int),
+		R: proof.R,
+	}, nil
+}
 
-	temp.Set(MultiExp(proof.Q, &l, pp.G, proof.R, pp.N))
-	return temp.Cmp(C) == 0
+// PoKE2Verify checks the proof, returns true if everything is good
+func PoKE2Verify(n, u, w *big.Int, proof *PoKE2Proof) bool {
+	if proof == nil || proof.Z == nil || proof.Q == nil {
+		return false
+	}
+	return GroupPoKE2Verify(group.NewRSAGroup(n), u, w, &GroupPoKE2Proof{Z: proof.Z, Q: proof.Q, R: proof.R})
 }
 
 // ZKPoKEProof contains the proofs for ZKPoKE
@@ -72,54 +87,19 @@ type ZKPoKEProof struct {
 
 // ZKPoKEProve proves in zero-knowledge of knowledge x s.t. u^x =w mod N
 func ZKPoKEProve(pp *PublicParameters, u, x, w *big.Int) (*ZKPoKEProof, error) {
-	var ret ZKPoKEProof
-	var temp, c, l big.Int
-	temp.Exp(u, x, pp.N)
-	if temp.Cmp(w) != 0 {
-		return nil, errors.New("ZKPoKEProve inputs a invalid statement")
-	}
-
-	b := new(big.Int).Set(pp.N)
-	lsh := 2*securityParam - 2
-	b.Lsh(b, uint(lsh))
-	k, err := rand.Int(rand.Reader, b)
-	if err != nil {
-		return nil, err
-	}
-	rhox, err := rand.Int(rand.Reader, b)
-	if err != nil {
-		return nil, err
-	}
-	rhok, err := rand.Int(rand.Reader, b)
+	proof, err := GroupZKPoKEProve(pp.toGroup(), u, x, w)
 	if err != nil {
 		return nil, err
 	}
-
-	ret.z = new(big.Int).Set(MultiExp(pp.G, x, pp.H, rhox, pp.N))
-	ret.Ag = new(big.Int).Set(MultiExp(pp.G, k, pp.H, rhok, pp.N))
-	ret.Au = new(big.Int).Exp(u, k, pp.N)
-
-	transcript := fiatshamir.InitTranscript([]string{"ZKPoKE", pp.G.String(), pp.H.String(),
-		pp.N.String(), u.String(), w.String(), ret.z.String(), ret.Ag.String(), ret.Au.String()}, fiatshamir.Max252)
-	c.Set(transcript.GetIntChallengeUsingTranscript())
-	l.Set(transcript.GetPrimeChallengeUsingTranscript())
-
-	var sx, srho big.Int //sx = k+ cx, srho = rhok + c*rhox
-	sx.Mul(&c, x)
-	sx.Add(&sx, k)
-	srho.Mul(&c, rhox)
-	srho.Add(&srho, rhok)
-
-	var qx, rx, qrho, rrho big.Int // qx*l + rx = sx, qrho*l + rrho = srho
-	qx.DivMod(&sx, &l, &rx)
-	qrho.DivMod(&srho, &l, &rrho)
-
-	ret.Qg = new(big.Int).Set(MultiExp(pp.G, &qx, pp.H, &qrho, pp.N))
-	ret.Qu = new(big.Int).Exp(u, &qx, pp.N)
-	ret.rx = new(big.Int).Set(&rx)
-	ret.rrho = new(big.Int).Set(&rrho)
-
-	return &ret, nil
+	return &ZKPoKEProof{
+		z:    proof.Z.(*big.Int),
+		Ag:   proof.Ag.(*big.Int),
+		Au:   proof.Au.(*big.Int),
+		Qg:   proof.Qg.(*big.Int),
+		Qu:   proof.Qu.(*big.Int),
+		rx:   proof.Rx,
+		rrho: proof.Rrho,
+	}, nil
 }
 
 // ZKPoKEVerify checks the proof, returns true if everything is good
@@ -127,29 +107,15 @@ func ZKPoKEVerify(pp *PublicParameters, u, w *big.Int, proof *ZKPoKEProof) bool
 	if proof == nil {
 		return false
 	}
-	var c, l big.Int
-	transcript := fiatshamir.InitTranscript([]string{"ZKPoKE", pp.G.String(), pp.H.String(),
-		pp.N.String(), u.String(), w.String(), proof.z.String(), proof.Ag.String(), proof.Au.String()}, fiatshamir.Max252)
-	c.Set(transcript.GetIntChallengeUsingTranscript())
-	l.Set(transcript.GetPrimeChallengeUsingTranscript())
-
-	var lhs, rhs big.Int
-	// checking the fist condition
-	lhs.Exp(proof.Qg, &l, pp.N)
-	lhs.Mul(&lhs, MultiExp(pp.G, proof.rx, pp.H, proof.rrho, pp.N))
-	lhs.Mod(&lhs, pp.N)
-
-	rhs.Exp(proof.z, &c, pp.N)
-	rhs.Mul(&rhs, proof.Ag)
-	rhs.Mod(&rhs, pp.N)
-	if lhs.Cmp(&rhs) != 0 {
-		return false
-	}
-	lhs.Set(MultiExp(proof.Qu, &l, u, proof.rx, pp.N))
-	rhs.Exp(w, &c, pp.N)
-	rhs.Mul(&rhs, proof.Au)
-	rhs.Mod(&rhs, pp.N)
-	return lhs.Cmp(&rhs) == 0
+	return GroupZKPoKEVerify(pp.toGroup(), u, w, &GroupZKPoKEProof{
+		Z:    proof.z,
+		Ag:   proof.Ag,
+		Au:   proof.Au,
+		Qg:   proof.Qg,
+		Qu:   proof.Qu,
+		Rx:   proof.rx,
+		Rrho: proof.rrho,
+	})
 }
 
 // PoEProof contains the proofs for PoE
@@ -159,19 +125,13 @@ type PoEProof struct {
 
 // PoEProve proves g^x = C
 func PoEProve(base, mod, C, x *big.Int) (*PoEProof, error) {
-	var ret PoEProof
-	ret.Q = new(big.Int)
-	var temp, q, l big.Int
-	temp.Exp(base, x, mod)
-	if temp.Cmp(C) != 0 {
-		return nil, errors.New("PoKEStar inputs a invalid statement")
+	proof, err := GroupPoEProve(group.NewRSAGroup(mod), base, C, x)
+	if err != nil {
+		return nil, err
 	}
-
-	transcript := fiatshamir.InitTranscript([]string{"PoE", base.String(), mod.String(), C.String(), x.String()}, fiatshamir.Max252)
-	l.Set(transcript.GetPrimeChallengeUsingTranscript())
-	q.Div(x, &l)
-	ret.Q.Exp(base, &q, mod)
-	return &ret, nil
+	return &PoEProof{
+		Q: proof.Q.(*big.Int),
+	}, nil
 }
 
 // PoEVerify checks the proof, returns true if everything is good
@@ -179,10 +139,5 @@ func PoEVerify(base, mod, C, x *big.Int, proof *PoEProof) bool {
 	if proof == nil {
 		return false
 	}
-	var temp, l, r big.Int
-	transcript := fiatshamir.InitTranscript([]string{"PoE", base.String(), mod.String(), C.String(), x.String()}, fiatshamir.Max252)
-	l.Set(transcript.GetPrimeChallengeUsingTranscript())
-	r.Mod(x, &l)
-	temp.Set(MultiExp(proof.Q, &l, base, &r, mod))
-	return temp.Cmp(C) == 0
+	return GroupPoEVerify(group.NewRSAGroup(mod), base, C, x, &GroupPoEProof{Q: proof.Q})
 }
@@ -1,8 +1,12 @@
 package zkmultiswap
 
 import (
+	"math/big"
+
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/std/hash/poseidon"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
 )
 
 // Circuit is the Zk-MultiSwap circuit for gnark.
@@ -16,11 +20,15 @@ type Circuit struct {
 	RemainderR1     frontend.Variable `gnark:",public"` // a remainder R1
 	RemainderR2     frontend.Variable `gnark:",public"` // a remainder R2
 	CurrentEpochNum frontend.Variable `gnark:",public"` // current epoch number
-	// Delta (2^1024) should be able to fixed as public parameters, however, gnark still cannot support big Int for now
+	// Delta (2^1023 by default) should be able to fixed as public parameters, however, gnark still cannot support big Int for now
 	// we the the following two public input to replace the Delta
 	// This because Delta + Hash(x) mod L = (Delta mod L + Hash(x) mod L) mod L
-	DeltaModL1 frontend.Variable `gnark:",public"` // 2^1024 mod L1
-	DeltaModL2 frontend.Variable `gnark:",public"` // 2^1024 mod L2
+	DeltaModL1 frontend.Variable `gnark:",public"` // Delta mod L1
+	DeltaModL2 frontend.Variable `gnark:",public"` // Delta mod L2
+	// A DI hash with more than one Poseidon block needs 2^{254*j} mod L for its j-th block, j = 1, ..., Blocks-1.
+	// Both are empty for the default DI hash with a single block.
+	DIShiftsModL1 []frontend.Variable `gnark:",public"` // 2^{254*j} mod L1
+	DIShiftsModL2 []frontend.Variable `gnark:",public"` // 2^{254*j} mod L2
 	//------------------------------private witness below--------------------------------------
 	Randomizer1      frontend.Variable   // Used to randomize the removed set
 	Randomizer2      frontend.Variable   // Used to randomize the inserted set
@@ -39,6 +47,11 @@ func (circuit Circuit) Define(api frontend.API) error {
 	api.ToBinary(circuit.Randomizer2, BitLength)
 	api.AssertIsLess(circuit.DeltaModL1, circuit.ChallengeL1)
 	api.AssertIsLess(circuit.DeltaModL2, circuit.ChallengeL2)
+	api.AssertIsEqual(len(circuit.DIShiftsModL1), len(circuit.DIShiftsModL2))
+	for i := range circuit.DIShiftsModL1 {
+		api.AssertIsLess(circuit.DIShiftsModL1[i], circuit.ChallengeL1)
+		api.AssertIsLess(circuit.DIShiftsModL2[i], circuit.ChallengeL2)
+	}
 
 	api.AssertIsEqual(len(circuit.UserID), len(circuit.OriginalBalances))
 	api.AssertIsEqual(len(circuit.UserID), len(circuit.OriginalHashes))
@@ -70,14 +83,16 @@ func (circuit Circuit) Define(api frontend.API) error {
 	tempSum = api.Sub(tempSum, circuit.OriginalBalances[0])
 	tempSum = api.Add(tempSum, circuit.UpdatedBalances[0])
 	for i := 0; i < len(circuit.UserID); i++ {
-		tempHash0 := poseidon.Poseidon(api, circuit.UserID[i], circuit.OriginalBalances[i], circuit.OriginalUpdEpoch[i], circuit.OriginalHashes[i])
+		original := circuit.OriginalRecord(i)
+		tempHash0 := original.PoseidonHash(api)
 		//api.Println(tempHash0)
-		tempHash1 := api.Add(tempHash0, circuit.DeltaModL1)
+		tempHash1 := diHashModL(api, tempHash0, circuit.DeltaModL1, circuit.DIShiftsModL1, circuit.ChallengeL1)
 		remainder1 = api.MulModP(remainder1, tempHash1, circuit.ChallengeL1)
 
 		// Check HashChain
-		tempHash2 := poseidon.Poseidon(api, circuit.UserID[i], circuit.UpdatedBalances[i], circuit.CurrentEpochNum, tempHash0)
-		tempHash2 = api.Add(tempHash2, circuit.DeltaModL2)
+		updated := original.NextWithHash(tempHash0, circuit.UpdatedBalances[i], circuit.CurrentEpochNum)
+		tempHash2 := updated.PoseidonHash(api)
+		tempHash2 = diHashModL(api, tempHash2, circuit.DeltaModL2, circuit.DIShiftsModL2, circuit.ChallengeL2)
 		remainder2 = api.MulModP(remainder2, tempHash2, circuit.ChallengeL2)
 
 		tempSum = api.Sub(tempSum, circuit.OriginalBalances[i])
@@ -98,8 +113,37 @@ func (circuit Circuit) Define(api frontend.API) error {
 	return nil
 }
 
-// InitCircuitWithSize init a circuit with challenges, OriginalHashes and CurrentEpochNum value 1, all other values 0. Use for test purpose only.
+// OriginalRecord returns the record of the i-th user before the update
+func (circuit Circuit) OriginalRecord(i int) UserRecordVar {
+	return UserRecordVar{
+		UserID:   circuit.UserID[i],
+		Balance:  circuit.OriginalBalances[i],
+		Epoch:    circuit.OriginalUpdEpoch[i],
+		PrevHash: circuit.OriginalHashes[i],
+	}
+}
+
+// diHashModL returns the DI hash with the first Poseidon block firstBlock, modulo challengeL up to a multiple of challengeL.
+// The j-th block is Poseidon(j, firstBlock), as accumulator.DIHash computes it.
+func diHashModL(api frontend.API, firstBlock, deltaModL frontend.Variable, shiftsModL []frontend.Variable,
+	challengeL frontend.Variable) frontend.Variable {
+	ret := api.Add(firstBlock, deltaModL)
+	for j := range shiftsModL {
+		block := poseidon.Poseidon(api, j+1, firstBlock)
+		ret = api.Add(ret, api.MulModP(block, shiftsModL[j], challengeL))
+	}
+	return ret
+}
+
+// InitCircuitWithSize init a circuit with challenges, OriginalHashes and CurrentEpochNum value 1, all other values 0,
+// for the DI hash of the default parameter set. Use for test purpose only.
 func InitCircuitWithSize(size uint32) *Circuit {
+	return InitCircuitWithDIHash(size, accumulator.Params2048.DIHash())
+}
+
+// InitCircuitWithDIHash init a circuit with challenges, OriginalHashes and CurrentEpochNum value 1, all other values 0,
+// for the DI hash diHash. Use for test purpose only.
+func InitCircuitWithDIHash(size uint32, diHash *accumulator.DIHash) *Circuit {
 	var circuit Circuit
 	circuit.ChallengeL1 = 1
 	circuit.ChallengeL2 = 1
@@ -112,6 +156,12 @@ func InitCircuitWithSize(size uint32) *Circuit {
 	circuit.UpdatedSum = 1
 	circuit.Randomizer1 = 1
 	circuit.Randomizer2 = 1
+	circuit.DIShiftsModL1 = make([]frontend.Variable, diHash.Blocks()-1)
+	circuit.DIShiftsModL2 = make([]frontend.Variable, diHash.Blocks()-1)
+	for i := range circuit.DIShiftsModL1 {
+		circuit.DIShiftsModL1[i] = 0
+		circuit.DIShiftsModL2[i] = 0
+	}
 
 	circuit.UserID = make([]frontend.Variable, size)
 	circuit.OriginalBalances = make([]frontend.Variable, size)
@@ -146,6 +196,7 @@ func AssignCircuit(input *UpdateSet32) *Circuit {
 	circuit.UpdatedSum = input.UpdatedSum
 	circuit.Randomizer1 = input.Randomizer1
 	circuit.Randomizer2 = input.Randomizer2
+	circuit.DIShiftsModL1, circuit.DIShiftsModL2 = assignShifts(input.DIShiftsModL1, input.DIShiftsModL2)
 
 	circuit.UserID = make([]frontend.Variable, size)
 	circuit.OriginalBalances = make([]frontend.Variable, size)
@@ -172,6 +223,19 @@ func AssignCircuitHelper(input *PublicInfo) *Circuit {
 	circuit.CurrentEpochNum = input.CurrentEpochNum
 	circuit.DeltaModL1 = input.DeltaModL1
 	circuit.DeltaModL2 = input.DeltaModL2
+	circuit.DIShiftsModL1, circuit.DIShiftsModL2 = assignShifts(input.DIShiftsModL1, input.DIShiftsModL2)
 
 	return circuit
 }
+
+func assignShifts(shiftsModL1, shiftsModL2 []big.Int) ([]frontend.Variable, []frontend.Variable) {
+	ret1 := make([]frontend.Variable, len(shiftsModL1))
+	ret2 := make([]frontend.Variable, len(shiftsModL2))
+	for i := range shiftsModL1 {
+		ret1[i] = shiftsModL1[i]
+	}
+	for i := range shiftsModL2 {
+		ret2[i] = shiftsModL2[i]
+	}
+	return ret1, ret2
+}
@@ -10,7 +10,6 @@ import (
 	"time"
 
 	"github.com/consensys/gnark-crypto/ecc"
-	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/poseidon"
 	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/frontend"
@@ -42,6 +41,8 @@ type UpdateSet32 struct {
 	CurrentEpochNum  uint32
 	DeltaModL1       big.Int
 	DeltaModL2       big.Int
+	DIShiftsModL1    []big.Int
+	DIShiftsModL2    []big.Int
 	Randomizer1      big.Int
 	Randomizer2      big.Int
 	OriginalSum      uint32
@@ -62,6 +63,8 @@ type PublicInfo struct {
 	CurrentEpochNum uint32
 	DeltaModL1      big.Int
 	DeltaModL2      big.Int
+	DIShiftsModL1   []big.Int
+	DIShiftsModL2   []big.Int
 }
 
 // IsValid returns true only if the input is valid for multiSwap
@@ -81,9 +84,27 @@ func (input *UpdateSet32) IsValid() bool {
 	if len(input.UserID) != len(input.UpdatedBalances) {
 		return false
 	}
+	if len(input.DIShiftsModL1) != len(input.DIShiftsModL2) {
+		return false
+	}
 	return true
 }
 
+// SetDIHashModL sets DeltaModL1, DeltaModL2 and the shifts of the Poseidon blocks of diHash modulo the challenges,
+// the challenges must be set before
+func (input *UpdateSet32) SetDIHashModL(diHash *accumulator.DIHash) {
+	input.DeltaModL1 = *diHash.DeltaModL(&input.ChallengeL1)
+	input.DeltaModL2 = *diHash.DeltaModL(&input.ChallengeL2)
+	shifts1 := diHash.ShiftsModL(&input.ChallengeL1)
+	shifts2 := diHash.ShiftsModL(&input.ChallengeL2)
+	input.DIShiftsModL1 = make([]big.Int, len(shifts1))
+	input.DIShiftsModL2 = make([]big.Int, len(shifts2))
+	for i := range shifts1 {
+		input.DIShiftsModL1[i] = *shifts1[i]
+		input.DIShiftsModL2[i] = *shifts2[i]
+	}
+}
+
 func getRandomAcc(setup *accumulator.Setup) *big.Int {
 	var ret big.Int
 	rand := accumulator.GenRandomizer()
@@ -120,18 +141,8 @@ func GenTestSet(setsize uint32, setup *accumulator.Setup) *UpdateSet32 {
 	ret.UpdatedSum = OriginalSum // UpdatedSum can be any valid positive numbers, but we are testing the case UpdatedSum = OriginalSum for simplicity
 
 	// get slice of elements removed and inserted
-	removeSet := make([]*big.Int, setsize)
-	insertSet := make([]*big.Int, setsize)
-
-	var poseidonhash *fr.Element // this is the Poseidon part of the DI hash. We use this to build the hash chain. The original DI hash is to long to directly input into Poseidon hash
-	for i := uint32(0); i < setsize; i++ {
-		poseidonhash, removeSet[i] = accumulator.PoseidonAndDIHash(accumulator.ElementFromUint32(ret.UserID[i]), accumulator.ElementFromUint32(ret.OriginalBalances[i]),
-			accumulator.ElementFromUint32(ret.OriginalUpdEpoch[i]), accumulator.ElementFromString(ret.OriginalHashes[i].String()))
-		//fmt.Println("poseidonhash i = ", poseidonhash.String())
-
-		insertSet[i] = accumulator.DIHashPoseidon(accumulator.ElementFromUint32(ret.UserID[i]), accumulator.ElementFromUint32(ret.UpdatedBalances[i]),
-			accumulator.ElementFromUint32(ret.CurrentEpochNum), poseidonhash)
-	}
+	diHash := setup.Parameters().DIHash()
+	removeSet, insertSet := ret.DIRepresentatives(diHash)
 	prod1 := accumulator.SetProductRecursiveFast(removeSet)
 	prod2 := accumulator.SetProductRecursiveFast(insertSet)
 
@@ -171,11 +182,7 @@ func GenTestSet(setsize uint32, setup *accumulator.Setup) *UpdateSet32 {
 	ret.ChallengeL2 = *challengeL2
 	ret.RemainderR1 = *remainderR1
 	ret.RemainderR2 = *remainderR2
-	var deltaModL1, deltaModL2 big.Int
-	deltaModL1.Mod(accumulator.Min1024, challengeL1)
-	deltaModL2.Mod(accumulator.Min1024, challengeL2)
-	ret.DeltaModL1 = deltaModL1
-	ret.DeltaModL2 = deltaModL2
+	ret.SetDIHashModL(diHash)
 
 	if !ret.IsValid() {
 		panic("error in GenTestSet, the generated test set is invalid")
@@ -193,6 +200,8 @@ func (input *UpdateSet32) PublicPart() *PublicInfo {
 	ret.CurrentEpochNum = input.CurrentEpochNum
 	ret.DeltaModL1 = input.DeltaModL1
 	ret.DeltaModL2 = input.DeltaModL2
+	ret.DIShiftsModL1 = input.DIShiftsModL1
+	ret.DIShiftsModL2 = input.DIShiftsModL2
 	return &ret
 }
 
@@ -0,0 +1,117 @@
+package zkmultiswap
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/poseidon"
+	"github.com/consensys/gnark/frontend"
+	gnarkposeidon "github.com/consensys/gnark/std/hash/poseidon"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+)
+
+// UserRecord is the leaf of a user in the accumulator. PrevHash is the Poseidon hash of the previous record of the user,
+// which links all the records of the user into a hash chain. The canonical field encoding of a record is
+// (UserID, Balance, Epoch, PrevHash) in this order, both natively and in the circuit with UserRecordVar.
+type UserRecord struct {
+	UserID   uint32
+	Balance  uint32
+	Epoch    uint32
+	PrevHash big.Int
+}
+
+// Elements returns the canonical field encoding of the record
+func (r *UserRecord) Elements() []*fr.Element {
+	return []*fr.Element{
+		accumulator.ElementFromUint32(r.UserID),
+		accumulator.ElementFromUint32(r.Balance),
+		accumulator.ElementFromUint32(r.Epoch),
+		accumulator.ElementFromBigInt(&r.PrevHash),
+	}
+}
+
+// PoseidonHash returns the Poseidon hash of the record, i.e. the PrevHash of the next record of the user
+func (r *UserRecord) PoseidonHash() *fr.Element {
+	return poseidon.Poseidon(r.Elements()...)
+}
+
+// DIHash returns the representative of the record in the accumulator with diHash
+func (r *UserRecord) DIHash(diHash *accumulator.DIHash) *big.Int {
+	return diHash.Hash(r.Elements()...)
+}
+
+// PoseidonAndDIHash returns the Poseidon hash of the record together with its DI hash, the Poseidon hash is computed once
+func (r *UserRecord) PoseidonAndDIHash(diHash *accumulator.DIHash) (*fr.Element, *big.Int) {
+	return diHash.PoseidonAndHash(r.Elements()...)
+}
+
+// Next returns the record of the user updated to balance at epoch, linked to r by the hash chain
+func (r *UserRecord) Next(balance, epoch uint32) *UserRecord {
+	return r.NextWithHash(r.PoseidonHash(), balance, epoch)
+}
+
+// NextWithHash is Next with the Poseidon hash of r computed before, e.g. by PoseidonAndDIHash
+func (r *UserRecord) NextWithHash(hash *fr.Element, balance, epoch uint32) *UserRecord {
+	ret := &UserRecord{
+		UserID:  r.UserID,
+		Balance: balance,
+		Epoch:   epoch,
+	}
+	hash.ToBigIntRegular(&ret.PrevHash)
+	return ret
+}
+
+// UserRecordVar is the gadget of UserRecord in the circuit, with the same canonical field encoding
+type UserRecordVar struct {
+	UserID   frontend.Variable
+	Balance  frontend.Variable
+	Epoch    frontend.Variable
+	PrevHash frontend.Variable
+}
+
+// Variables returns the canonical field encoding of the record
+func (r UserRecordVar) Variables() []frontend.Variable {
+	return []frontend.Variable{r.UserID, r.Balance, r.Epoch, r.PrevHash}
+}
+
+// PoseidonHash returns the Poseidon hash of the record in the circuit, the same as UserRecord.PoseidonHash
+func (r UserRecordVar) PoseidonHash(api frontend.API) frontend.Variable {
+	return gnarkposeidon.Poseidon(api, r.Variables()...)
+}
+
+// NextWithHash returns the record of the user updated to balance at epoch, given the Poseidon hash of r,
+// the same as UserRecord.NextWithHash
+func (r UserRecordVar) NextWithHash(hash, balance, epoch frontend.Variable) UserRecordVar {
+	return UserRecordVar{
+		UserID:   r.UserID,
+		Balance:  balance,
+		Epoch:    epoch,
+		PrevHash: hash,
+	}
+}
+
+// OriginalRecord returns the record of the i-th user before the update
+func (input *UpdateSet32) OriginalRecord(i int) *UserRecord {
+	return &UserRecord{
+		UserID:   input.UserID[i],
+		Balance:  input.OriginalBalances[i],
+		Epoch:    input.OriginalUpdEpoch[i],
+		PrevHash: input.OriginalHashes[i],
+	}
+}
+
+// DIRepresentatives returns the DI hashes of the records removed from and inserted into the accumulator by the update,
+// the inserted record of every user is linked to the removed one by the hash chain
+func (input *UpdateSet32) DIRepresentatives(diHash *accumulator.DIHash) ([]*big.Int, []*big.Int) {
+	removeSet := make([]*big.Int, len(input.UserID))
+	insertSet := make([]*big.Int, len(input.UserID))
+	for i := range input.UserID {
+		original := input.OriginalRecord(i)
+		// the Poseidon hash builds the hash chain, the DI hash is too long to be hashed again
+		hash, rep := original.PoseidonAndDIHash(diHash)
+		removeSet[i] = rep
+		insertSet[i] = original.NextWithHash(hash, input.UpdatedBalances[i], input.CurrentEpochNum).DIHash(diHash)
+	}
+	return removeSet, insertSet
+}
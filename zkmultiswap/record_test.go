@@ -0,0 +1,64 @@
+package zkmultiswap
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
+)
+
+// recordCircuit checks the gadget against the native hashes of a record and of its next record
+type recordCircuit struct {
+	Record   UserRecordVar
+	Balance  frontend.Variable
+	Epoch    frontend.Variable
+	Hash     frontend.Variable `gnark:",public"`
+	NextHash frontend.Variable `gnark:",public"`
+}
+
+func (circuit recordCircuit) Define(api frontend.API) error {
+	hash := circuit.Record.PoseidonHash(api)
+	api.AssertIsEqual(hash, circuit.Hash)
+	next := circuit.Record.NextWithHash(hash, circuit.Balance, circuit.Epoch)
+	api.AssertIsEqual(next.PoseidonHash(api), circuit.NextHash)
+	return nil
+}
+
+func TestUserRecord(t *testing.T) {
+	record := UserRecord{UserID: 7, Balance: 100, Epoch: 3}
+	record.PrevHash.SetInt64(12345)
+	next := record.Next(80, 4)
+	if next.UserID != record.UserID || next.Balance != 80 || next.Epoch != 4 {
+		t.Errorf("Next does not update the balance and the epoch")
+	}
+	var hash, nextHash big.Int
+	record.PoseidonHash().ToBigIntRegular(&hash)
+	next.PoseidonHash().ToBigIntRegular(&nextHash)
+	if next.PrevHash.Cmp(&hash) != 0 {
+		t.Errorf("Next is not linked to the record by the hash chain")
+	}
+	poseidonHash, diHash := record.PoseidonAndDIHash(accumulator.Params2048.DIHash())
+	if !poseidonHash.Equal(record.PoseidonHash()) || diHash.Cmp(record.DIHash(accumulator.Params2048.DIHash())) != 0 {
+		t.Errorf("PoseidonAndDIHash is not consistent with PoseidonHash and DIHash")
+	}
+	if diHash.Cmp(accumulator.DIHashPoseidon(record.Elements()...)) != 0 {
+		t.Errorf("DIHash is not the DI hash of the canonical encoding")
+	}
+
+	assert := test.NewAssert(t)
+	var circuit recordCircuit
+	witness := recordCircuit{
+		Record:   UserRecordVar{UserID: record.UserID, Balance: record.Balance, Epoch: record.Epoch, PrevHash: record.PrevHash},
+		Balance:  next.Balance,
+		Epoch:    next.Epoch,
+		Hash:     hash,
+		NextHash: nextHash,
+	}
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254))
+	witness.Epoch = 5
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BN254))
+}
@@ -14,6 +14,8 @@ import (
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
+
+	"github.com/jiajunxin/rsa_accumulator/accumulator"
 )
 
 // LoadVerifyingKey load the verification key from the filepath
@@ -34,8 +36,13 @@ func LoadVerifyingKey(filepath string) (verifyingKey groth16.VerifyingKey, err e
 // SetupZkMultiswap generates the circuit and public/verification keys with Groth16
 // "keyPathPrefix".pk* are for public keys, "keyPathPrefix".ccs* are for r1cs, "keyPathPrefix".vk,save is for verification keys
 func SetupZkMultiswap(size uint32) {
+	SetupZkMultiswapWithDIHash(size, accumulator.Params2048.DIHash())
+}
+
+// SetupZkMultiswapWithDIHash is SetupZkMultiswap for the DI hash diHash, e.g. of a parameter set with longer DI hash outputs
+func SetupZkMultiswapWithDIHash(size uint32, diHash *accumulator.DIHash) {
 	// compiles our circuit into a R1CS
-	circuit := InitCircuitWithSize(size)
+	circuit := InitCircuitWithDIHash(size, diHash)
 	fmt.Println("Start Compiling")
 	r1cs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, circuit) //, frontend.IgnoreUnconstrainedInputs()
 	if err != nil {
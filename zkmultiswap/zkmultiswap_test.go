@@ -105,3 +105,28 @@ func TestZkMultiSwapFailCases(t *testing.T) {
 	witness.RemainderR2 = testSet.ChallengeL2
 	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BN254))
 }
+
+func TestZkMultiSwapWithDIHash(t *testing.T) {
+	assert := test.NewAssert(t)
+	var circuit, witness Circuit
+	testSetSize := uint32(4)
+
+	diHash, err := accumulator.NewDIHashWithOutputBits(1024)
+	if err != nil {
+		t.Fatalf("NewDIHashWithOutputBits returns error: %v", err)
+	}
+	setup := accumulator.TrustedSetup()
+	setup.Params = accumulator.NewParameterSetWithDIHash("2048-di1024", 2048, diHash)
+	circuit = *InitCircuitWithDIHash(testSetSize, diHash)
+
+	testSet := GenTestSet(testSetSize, setup)
+	if len(testSet.DIShiftsModL1) != diHash.Blocks()-1 {
+		t.Fatalf("test set has %d shifts, want %d", len(testSet.DIShiftsModL1), diHash.Blocks()-1)
+	}
+	witness = *AssignCircuit(testSet)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254))
+
+	witness = *AssignCircuit(testSet)
+	witness.DIShiftsModL1[0] = 1
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BN254))
+}